@@ -4,6 +4,7 @@ import (
 	"GoPVZ/config"
 	"GoPVZ/internal/app"
 	"log"
+	"os"
 )
 
 func main() {
@@ -13,6 +14,11 @@ func main() {
 		log.Fatalf("Config error: %s", err)
 	}
 
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "import-pvz" {
+		os.Exit(app.RunImportPVZ(cfg, os.Args[2:]))
+	}
+
 	// Run
-	app.Run(cfg)
+	os.Exit(app.Run(cfg))
 }
@@ -2,27 +2,60 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/url"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 )
 
 type (
 	Config struct {
-		Log        Log
-		DB         DB
-		HTTP       HTTP
-		GRPC       GRPC
-		Prometheus Prometheus
-		JWT        JWT
-		PGURL      PGURL
+		Log         Log
+		DB          DB
+		HTTP        HTTP
+		GRPC        GRPC
+		Prometheus  Prometheus
+		JWT         JWT
+		PGURL       PGURL
+		Outbox      Outbox
+		Webhook     Webhook
+		Events      Events
+		Idempotency Idempotency
+		Auth        Auth
+		OIDC        OIDC
+		Password    Password
+		Permissions Permissions
+		RateLimit   RateLimit
+		Tracing     Tracing
+		Health      Health
+		Shutdown    Shutdown
 	}
 
 	HTTP struct {
-		Port string `env:"HTTP_PORT" envDefault:"8080"`
+		Port              string        `env:"HTTP_PORT" envDefault:"8080"`
+		ReadTimeout       time.Duration `env:"HTTP_READ_TIMEOUT" envDefault:"10s"`
+		ReadHeaderTimeout time.Duration `env:"HTTP_READ_HEADER_TIMEOUT" envDefault:"5s"`
+		// WriteTimeout не действует на SSE и выгрузки: они снимают дедлайн сами
+		WriteTimeout    time.Duration `env:"HTTP_WRITE_TIMEOUT" envDefault:"10s"`
+		IdleTimeout     time.Duration `env:"HTTP_IDLE_TIMEOUT" envDefault:"60s"`
+		ShutdownTimeout time.Duration `env:"HTTP_SHUTDOWN_TIMEOUT" envDefault:"5s"`
+		MaxHeaderBytes  int           `env:"HTTP_MAX_HEADER_BYTES" envDefault:"1048576"`
+		// TLSCert и TLSKey включают HTTPS; файлы перечитываются по SIGHUP
+		TLSCert string `env:"HTTP_TLS_CERT"`
+		TLSKey  string `env:"HTTP_TLS_KEY"`
+		// H2C включает HTTP/2 без TLS, например за прокси
+		H2C         bool     `env:"HTTP_H2C" envDefault:"false"`
+		Compression bool     `env:"HTTP_COMPRESSION" envDefault:"true"`
+		CORSOrigins []string `env:"HTTP_CORS_ORIGINS"`
+		// TrustedProxies — адреса или CIDR прокси, которым доверяется X-Forwarded-For
+		TrustedProxies []string `env:"HTTP_TRUSTED_PROXIES"`
 	}
 
 	Prometheus struct {
 		Port string `env:"PROMETHEUS_PORT" envDefault:"9000"`
+		// RefreshInterval — период пересчета метрик-снимков из БД (открытые приемки, товары)
+		RefreshInterval time.Duration `env:"METRICS_REFRESH_INTERVAL" envDefault:"30s"`
 	}
 
 	GRPC struct {
@@ -30,24 +63,155 @@ type (
 	}
 
 	Log struct {
-		Level string `env:"LOG_LEVEL,required"`
+		// Env — local, dev или prod; задает уровень и формат логов по умолчанию
+		Env string `env:"APP_ENV" envDefault:"prod"`
+		// Level — debug, info, warn или error; пусто — по окружению
+		Level string `env:"LOG_LEVEL"`
+		// Format — pretty, text или json; пусто — по окружению
+		Format string `env:"LOG_FORMAT"`
 	}
 
+	Health struct {
+		// CheckTimeout — таймаут одной проверки /readyz
+		CheckTimeout time.Duration `env:"HEALTH_CHECK_TIMEOUT" envDefault:"2s"`
+	}
+
+	Shutdown struct {
+		// Timeout — общий дедлайн остановки всех компонентов, включая DrainDelay
+		Timeout time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"15s"`
+		// DrainDelay — пауза между SIGTERM и остановкой сервера, пока /readyz отвечает 503
+		DrainDelay time.Duration `env:"SHUTDOWN_DRAIN_DELAY" envDefault:"5s"`
+	}
+
+	Tracing struct {
+		// Exporter — none, stdout, file или otlp. Для otlp адрес берется из
+		// стандартных OTEL_EXPORTER_OTLP_ENDPOINT / OTEL_EXPORTER_OTLP_HEADERS
+		Exporter    string  `env:"TRACING_EXPORTER" envDefault:"none"`
+		FilePath    string  `env:"TRACING_FILE" envDefault:"traces.json"`
+		ServiceName string  `env:"TRACING_SERVICE_NAME" envDefault:"gopvz"`
+		SampleRatio float64 `env:"TRACING_SAMPLE_RATIO" envDefault:"1"`
+	}
+
+	// DB задает параметры подключения, если PG_URL не указан, и настройки пула
 	DB struct {
-		Host     string `env:"DB_HOST,required"`
-		User     string `env:"DB_USER,required"`
-		Name     string `env:"DB_NAME,required"`
-		Port     string `env:"DB_PORT,required"`
-		Password string `env:"DB_PASSWORD,required"`
-		SSLMode  string `env:"DB_SSL,required"`
+		Host     string `env:"DB_HOST"`
+		User     string `env:"DB_USER"`
+		Name     string `env:"DB_NAME"`
+		Port     string `env:"DB_PORT" envDefault:"5432"`
+		Password string `env:"DB_PASSWORD"`
+		SSLMode  string `env:"DB_SSL" envDefault:"disable"`
+
+		MaxConns          int           `env:"DB_MAX_CONNS" envDefault:"10"`
+		MinIdleConns      int           `env:"DB_MIN_IDLE_CONNS" envDefault:"2"`
+		MaxConnLifetime   time.Duration `env:"DB_MAX_CONN_LIFETIME" envDefault:"1h"`
+		MaxConnIdleTime   time.Duration `env:"DB_MAX_CONN_IDLE_TIME" envDefault:"30m"`
+		HealthCheckPeriod time.Duration `env:"DB_HEALTH_CHECK_PERIOD" envDefault:"1m"`
+		// StatementTimeout ограничивает любой SQL запрос на стороне PostgreSQL; 0 — без ограничения
+		StatementTimeout time.Duration `env:"DB_STATEMENT_TIMEOUT" envDefault:"30s"`
+		ConnAttempts     int           `env:"DB_CONN_ATTEMPTS" envDefault:"10"`
+		// ConnBackoff — первая задержка между попытками подключения, далее она удваивается до ConnMaxBackoff
+		ConnBackoff    time.Duration `env:"DB_CONN_BACKOFF" envDefault:"1s"`
+		ConnMaxBackoff time.Duration `env:"DB_CONN_MAX_BACKOFF" envDefault:"10s"`
+
+		// ReplicaURLs — строки подключения к репликам для чтения через запятую
+		ReplicaURLs []string `env:"DB_REPLICA_URLS"`
+		// ReadAfterWrite — окно после записи, в течение которого пользователь читает из основной БД
+		ReadAfterWrite       time.Duration `env:"DB_READ_AFTER_WRITE" envDefault:"5s"`
+		ReplicaCheckInterval time.Duration `env:"DB_REPLICA_CHECK_INTERVAL" envDefault:"5s"`
 	}
 
+	// PGURL имеет приоритет над DB_*; если не задан, собирается из них
 	PGURL struct {
 		URL string `env:"PG_URL"`
 	}
 
 	JWT struct {
-		Secret string `env:"JWT_SECRET,required"`
+		Secret   string        `env:"JWT_SECRET,required"`
+		TTL      time.Duration `env:"JWT_TTL" envDefault:"24h"`
+		Issuer   string        `env:"JWT_ISSUER" envDefault:"gopvz"`
+		Audience string        `env:"JWT_AUDIENCE" envDefault:"gopvz-api"`
+	}
+
+	Auth struct {
+		// DummyLoginEnabled включает /dummyLogin; только для разработки
+		DummyLoginEnabled  bool          `env:"AUTH_DUMMY_LOGIN_ENABLED" envDefault:"false"`
+		MaxLoginFailures   int           `env:"AUTH_MAX_LOGIN_FAILURES" envDefault:"5"`
+		LoginFailureWindow time.Duration `env:"AUTH_LOGIN_FAILURE_WINDOW" envDefault:"15m"`
+		LockoutDuration    time.Duration `env:"AUTH_LOCKOUT_DURATION" envDefault:"15m"`
+		// MFARequiredRoles — роли, которым при входе обязателен второй фактор (TOTP)
+		MFARequiredRoles []string      `env:"AUTH_MFA_REQUIRED_ROLES" envDefault:"moderator,admin"`
+		MFAChallengeTTL  time.Duration `env:"AUTH_MFA_CHALLENGE_TTL" envDefault:"5m"`
+		// MFAIssuer — имя сервиса в приложении-аутентификаторе
+		MFAIssuer string `env:"AUTH_MFA_ISSUER" envDefault:"GoPVZ"`
+	}
+
+	// OIDC — вход через корпоративный SSO; выключен, пока не задан Issuer
+	OIDC struct {
+		Issuer       string   `env:"OIDC_ISSUER"`
+		ClientID     string   `env:"OIDC_CLIENT_ID"`
+		ClientSecret string   `env:"OIDC_CLIENT_SECRET"`
+		RedirectURL  string   `env:"OIDC_REDIRECT_URL"`
+		Scopes       []string `env:"OIDC_SCOPES" envDefault:"openid,email,profile"`
+		GroupsClaim  string   `env:"OIDC_GROUPS_CLAIM" envDefault:"groups"`
+		// RoleMapping — записи group=role; первая совпавшая группа определяет роль
+		RoleMapping []string      `env:"OIDC_ROLE_MAPPING"`
+		DefaultRole string        `env:"OIDC_DEFAULT_ROLE"`
+		LoginTTL    time.Duration `env:"OIDC_LOGIN_TTL" envDefault:"10m"`
+		// SkipEmailVerified разрешает вход без email_verified=true; только для
+		// провайдеров, которые сами проверяют email, но не передают этот claim
+		SkipEmailVerified bool `env:"OIDC_SKIP_EMAIL_VERIFIED" envDefault:"false"`
+	}
+
+	Password struct {
+		MinLength     int  `env:"PASSWORD_MIN_LENGTH" envDefault:"8"`
+		RequireUpper  bool `env:"PASSWORD_REQUIRE_UPPER" envDefault:"false"`
+		RequireLower  bool `env:"PASSWORD_REQUIRE_LOWER" envDefault:"false"`
+		RequireDigit  bool `env:"PASSWORD_REQUIRE_DIGIT" envDefault:"false"`
+		RequireSymbol bool `env:"PASSWORD_REQUIRE_SYMBOL" envDefault:"false"`
+		// DenylistFile дополняет встроенный список распространенных паролей
+		DenylistFile  string        `env:"PASSWORD_DENYLIST_FILE"`
+		ResetTokenTTL time.Duration `env:"PASSWORD_RESET_TOKEN_TTL" envDefault:"30m"`
+	}
+
+	Permissions struct {
+		// PolicyFile — JSON с разрешениями ролей, переопределяет значения по умолчанию
+		PolicyFile string `env:"PERMISSIONS_POLICY_FILE"`
+	}
+
+	RateLimit struct {
+		IPRate          float64       `env:"RATE_LIMIT_IP_RATE" envDefault:"1"`
+		IPBurst         int           `env:"RATE_LIMIT_IP_BURST" envDefault:"10"`
+		EmailRate       float64       `env:"RATE_LIMIT_EMAIL_RATE" envDefault:"0.1"`
+		EmailBurst      int           `env:"RATE_LIMIT_EMAIL_BURST" envDefault:"5"`
+		CleanupInterval time.Duration `env:"RATE_LIMIT_CLEANUP_INTERVAL" envDefault:"5m"`
+	}
+
+	Outbox struct {
+		PollInterval time.Duration `env:"OUTBOX_POLL_INTERVAL" envDefault:"1s"`
+		BatchSize    int           `env:"OUTBOX_BATCH_SIZE" envDefault:"100"`
+		Publisher    string        `env:"OUTBOX_PUBLISHER" envDefault:"log"`
+		FilePath     string        `env:"OUTBOX_FILE_PATH"`
+		WebhookURL   string        `env:"OUTBOX_WEBHOOK_URL"`
+	}
+
+	Webhook struct {
+		DeliveryInterval time.Duration `env:"WEBHOOK_DELIVERY_INTERVAL" envDefault:"1s"`
+		BatchSize        int           `env:"WEBHOOK_BATCH_SIZE" envDefault:"50"`
+		MaxAttempts      int           `env:"WEBHOOK_MAX_ATTEMPTS" envDefault:"8"`
+		BaseBackoff      time.Duration `env:"WEBHOOK_BASE_BACKOFF" envDefault:"5s"`
+		Timeout          time.Duration `env:"WEBHOOK_TIMEOUT" envDefault:"10s"`
+		// AllowPrivateNetworks разрешает доставку на внутренние адреса — только для разработки
+		AllowPrivateNetworks bool `env:"WEBHOOK_ALLOW_PRIVATE_NETWORKS" envDefault:"false"`
+	}
+
+	Events struct {
+		Backend    string `env:"EVENTS_BACKEND" envDefault:"memory"`
+		BufferSize int    `env:"EVENTS_BUFFER_SIZE" envDefault:"64"`
+	}
+
+	Idempotency struct {
+		TTL             time.Duration `env:"IDEMPOTENCY_TTL" envDefault:"24h"`
+		CleanupInterval time.Duration `env:"IDEMPOTENCY_CLEANUP_INTERVAL" envDefault:"1h"`
 	}
 )
 
@@ -58,5 +222,27 @@ func NewConfig() (*Config, error) {
 		return nil, fmt.Errorf("config error: %w", err)
 	}
 
+	if cfg.PGURL.URL == "" {
+		if cfg.DB.Host == "" || cfg.DB.User == "" || cfg.DB.Name == "" {
+			return nil, fmt.Errorf("config error: PG_URL or DB_HOST, DB_USER and DB_NAME are required")
+		}
+		cfg.PGURL.URL = cfg.DB.URL()
+	}
+
 	return cfg, nil
 }
+
+// URL собирает строку подключения из DB_*. Логин и пароль экранируются,
+// поэтому могут содержать любые символы.
+func (d DB) URL() string {
+	u := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(d.User, d.Password),
+		Host:   net.JoinHostPort(d.Host, d.Port),
+		Path:   "/" + d.Name,
+	}
+	if d.SSLMode != "" {
+		u.RawQuery = url.Values{"sslmode": {d.SSLMode}}.Encode()
+	}
+	return u.String()
+}
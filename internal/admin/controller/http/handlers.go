@@ -0,0 +1,71 @@
+package http
+
+import (
+	"GoPVZ/internal/dto"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgValidator"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LogLevel — уровень логгера, который можно менять без перезапуска.
+type LogLevel interface {
+	Level() string
+	SetLevel(level string) error
+}
+
+type AdminHandler struct {
+	level LogLevel
+	log   pkgLogger.Interface
+}
+
+func NewAdminHandler(level LogLevel, log pkgLogger.Interface) *AdminHandler {
+	return &AdminHandler{level: level, log: log}
+}
+
+// GetLogLevel godoc
+// @Summary Текущий уровень логирования
+// @Tags Domain admin
+// @Produce json
+// @Success 200 {object} dto.LogLevel
+// @Failure 401 {object} dto.Error "Ошибка авторизации"
+// @Failure 403 {object} dto.Error "Доступ запрещен"
+// @Security BearerAuth
+// @Router /admin/log-level [get]
+func (h *AdminHandler) GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.LogLevel{Level: dto.LogLevelLevel(h.level.Level())})
+}
+
+// SetLogLevel godoc
+// @Summary Изменение уровня логирования
+// @Description Меняет уровень сразу для всех компонентов; после перезапуска снова действует LOG_LEVEL
+// @Tags Domain admin
+// @Accept json
+// @Produce json
+// @Param input body dto.LogLevel true "Новый уровень"
+// @Success 200 {object} dto.LogLevel
+// @Failure 400 {object} dto.Error "Неизвестный уровень"
+// @Failure 401 {object} dto.Error "Ошибка авторизации"
+// @Failure 403 {object} dto.Error "Доступ запрещен"
+// @Security BearerAuth
+// @Router /admin/log-level [put]
+func (h *AdminHandler) SetLogLevel(c *gin.Context) {
+	var req dto.PutAdminLogLevelJSONRequestBody
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: pkgValidator.ErrInvalidInput.Error()})
+		return
+	}
+
+	previous := h.level.Level()
+	if err := h.level.SetLevel(string(req.Level)); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: pkgValidator.ErrInvalidLogLevel.Error()})
+		return
+	}
+	h.log.WarnContext(c, "Log level changed",
+		slog.String("from", previous),
+		slog.String("to", h.level.Level()),
+	)
+	c.JSON(http.StatusOK, dto.LogLevel{Level: dto.LogLevelLevel(h.level.Level())})
+}
@@ -0,0 +1,43 @@
+package http
+
+import (
+	"GoPVZ/pkg/pkgLogger"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminRouter_LogLevel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	log := pkgLogger.Discard()
+	allow := func(c *gin.Context) { c.Next() }
+	router := gin.New()
+	NewAdminRouter(router.Group("/"), log, log, allow, allow)
+
+	do := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/admin/log-level", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"level":"info"}`, w.Body.String())
+
+	w = do(http.MethodPut, `{"level":"DEBUG"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"level":"debug"}`, w.Body.String())
+	require.Equal(t, "debug", log.Level())
+
+	// Неизвестный уровень не меняет текущий
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPut, `{"level":"verbose"}`).Code)
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPut, `{"level":`).Code)
+	require.Equal(t, "debug", log.Level())
+}
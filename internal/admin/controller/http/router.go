@@ -0,0 +1,23 @@
+package http
+
+import (
+	"GoPVZ/pkg/pkgLogger"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NewAdminRouter(
+	router *gin.RouterGroup,
+	level LogLevel,
+	log pkgLogger.Interface,
+	authMiddleware gin.HandlerFunc,
+	canManageSystem gin.HandlerFunc,
+) {
+	handler := NewAdminHandler(level, log)
+
+	// Routes for users with system:manage
+	adminRoutes := router.Group("/admin")
+	adminRoutes.Use(authMiddleware, canManageSystem)
+	adminRoutes.GET("/log-level", handler.GetLogLevel)
+	adminRoutes.PUT("/log-level", handler.SetLogLevel)
+}
@@ -2,27 +2,53 @@ package app
 
 import (
 	"GoPVZ/config"
+	"context"
+	"fmt"
 	_ "GoPVZ/docs" // для swagger
+	domainAdminControllerHttp "GoPVZ/internal/admin/controller/http"
 	domainAuthControllerHttp "GoPVZ/internal/auth/controller/http"
+	domainAuthNotifier "GoPVZ/internal/auth/notifier"
 	userEntity "GoPVZ/internal/auth/entity"
+	domainExportControllerHttp "GoPVZ/internal/export/controller/http"
+	domainExportRepo "GoPVZ/internal/export/repo"
+	domainExportUsecase "GoPVZ/internal/export/usecase"
+	domainIdempotencyControllerHttp "GoPVZ/internal/idempotency/controller/http"
+	domainIdempotencyRepo "GoPVZ/internal/idempotency/repo"
+	domainIdempotencyUsecase "GoPVZ/internal/idempotency/usecase"
 	domainAuthRepo "GoPVZ/internal/auth/repo"
 	domainAuthUsecase "GoPVZ/internal/auth/usecase"
+	domainOutboxPublisher "GoPVZ/internal/outbox/publisher"
+	domainOutboxRepo "GoPVZ/internal/outbox/repo"
+	domainOutboxUsecase "GoPVZ/internal/outbox/usecase"
 	domainPVZControllerHttp "GoPVZ/internal/pvz/controller/http"
 	domainPvzRepo "GoPVZ/internal/pvz/repo"
 	domainPvzUsecase "GoPVZ/internal/pvz/usecase"
+	domainWebhookControllerHttp "GoPVZ/internal/webhook/controller/http"
+	domainWebhookRepo "GoPVZ/internal/webhook/repo"
+	domainWebhookUsecase "GoPVZ/internal/webhook/usecase"
+	"GoPVZ/migrations"
+	"GoPVZ/pkg/pkgEgress"
+	"GoPVZ/pkg/pkgHealth"
 	"GoPVZ/pkg/pkgHttpserver"
+	"GoPVZ/pkg/pkgLifecycle"
 	"GoPVZ/pkg/pkgLogger"
 	"GoPVZ/pkg/pkgMetrics"
+	"GoPVZ/pkg/pkgOIDC"
 	"GoPVZ/pkg/pkgPostgres"
+	"GoPVZ/pkg/pkgPubSub"
+	"GoPVZ/pkg/pkgRateLimit"
+	"GoPVZ/pkg/pkgTracing"
+	"GoPVZ/pkg/pkgValidator"
+	"io"
 	"log/slog"
-	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	swaggerFiles "github.com/swaggo/files"
 
@@ -41,89 +67,328 @@ import (
 // @in header
 // @name Authorization
 // @description Вставьте JWT токен с префиксом 'Bearer '. Пример: Bearer eyJhbGciOiJIUzI1NiIs...
-func Run(cfg *config.Config) {
-	log := pkgLogger.New("local")
-	log.Info("Starting application")
+// Run запускает сервис и возвращает код выхода процесса.
+func Run(cfg *config.Config) int {
+	log, err := newLogger(cfg.Log, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Logger config error: %s\n", err)
+		return 1
+	}
+	log.Info("Starting application", slog.String("env", cfg.Log.Env), slog.String("level", log.Level()))
+
+	tracing, err := pkgTracing.New(context.Background(), pkgTracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		FilePath:    cfg.Tracing.FilePath,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.Error("Failed to init tracing", pkgLogger.Err(err))
+		return 1
+	}
+	log.Info("Tracing configured", slog.String("exporter", cfg.Tracing.Exporter))
+
+	// Компоненты останавливаются в порядке, обратном регистрации: сначала
+	// HTTP, затем воркеры, пул соединений и в конце трассировка
+	lifecycle := pkgLifecycle.New(log.With("component", "lifecycle"), pkgLifecycle.ShutdownTimeout(cfg.Shutdown.Timeout))
+	lifecycle.Add(pkgLifecycle.Hook{Name: "tracing", OnStop: tracing.Shutdown})
 
-	DBConn, err := pkgPostgres.New(cfg.PGURL.URL)
+	DBConn, err := pkgPostgres.New(cfg.PGURL.URL, newPostgresOptions(cfg.DB, log)...)
 	if err != nil {
 		log.Error("Failed to connect to database", pkgLogger.Err(err))
-		os.Exit(1)
+		return 1
 	}
 	log.Info("Connected to PostgreSQL")
+	prometheus.MustRegister(pkgPostgres.NewPoolCollector(DBConn.Pool, "primary"))
+	lifecycle.Add(pkgLifecycle.Hook{Name: "postgres", OnStop: func(context.Context) error {
+		DBConn.Close()
+		return nil
+	}})
 
-	// Запуск сервера метрик Prometheus 
-	go func() {
-    	addr := ":" + cfg.Prometheus.Port // Используем порт из конфига
-    	http.Handle("/metrics", promhttp.Handler())
-    	log.Info("Prometheus metrics server started", slog.String("port", cfg.Prometheus.Port))
-		
-    	if err := http.ListenAndServe(addr, nil); err != nil {
-    	    log.Error("Metrics server error", pkgLogger.Err(err))
-    	}
-	}()
+	cluster, replicas, err := newCluster(cfg.DB, DBConn, log)
+	if err != nil {
+		log.Error("Failed to configure database replicas", pkgLogger.Err(err))
+		return 1
+	}
+	for _, replica := range replicas {
+		lifecycle.Add(pkgLifecycle.Hook{Name: "postgres_replica", OnStop: func(context.Context) error {
+			replica.Close()
+			return nil
+		}})
+	}
 
 	// auth domain
-	userRepo := domainAuthRepo.NewUserRepo(DBConn.Pool)
-	jwtManager := domainAuthUsecase.NewJwtManager(cfg.JWT.Secret, 24*time.Hour)
-	authUC := domainAuthUsecase.NewAuthUseCase(userRepo, jwtManager)
+	authLog := log.With("component", "auth")
+	userRepo := domainAuthRepo.NewUserRepo(DBConn.Pool, authLog)
+	jwtManager := domainAuthUsecase.NewJwtManager(cfg.JWT.Secret, cfg.JWT.TTL, cfg.JWT.Issuer, cfg.JWT.Audience)
+	loginAttempts := domainAuthUsecase.NewMemoryLoginAttempts(
+		cfg.Auth.MaxLoginFailures,
+		cfg.Auth.LoginFailureWindow,
+		cfg.Auth.LockoutDuration,
+	)
+	mfaPolicy, err := newMFAPolicy(cfg.Auth)
+	if err != nil {
+		log.Error("Failed to configure two-factor authentication", pkgLogger.Err(err))
+		return 1
+	}
+	authUC := domainAuthUsecase.NewAuthUseCase(
+		userRepo,
+		domainAuthRepo.NewTOTPRepo(DBConn.Pool, authLog),
+		jwtManager,
+		loginAttempts,
+		domainAuthNotifier.NewLogNotifier(log),
+		authLog,
+		cfg.Password.ResetTokenTTL,
+		mfaPolicy,
+	)
+	permissionPolicy, err := newPermissionPolicy(cfg.Permissions)
+	if err != nil {
+		log.Error("Failed to load permission policy", pkgLogger.Err(err))
+		return 1
+	}
+	apiKeyUC := domainAuthUsecase.NewAPIKeyUseCase(domainAuthRepo.NewAPIKeyRepo(DBConn.Pool, authLog), permissionPolicy, authLog)
+	oidcUC, err := newOIDCUseCase(cfg.OIDC, userRepo, authUC, cfg.JWT.Secret, authLog)
+	if err != nil {
+		log.Error("Failed to configure OIDC login", pkgLogger.Err(err))
+		return 1
+	}
+	passwordPolicy, err := newPasswordPolicy(cfg.Password)
+	if err != nil {
+		log.Error("Failed to load password policy", pkgLogger.Err(err))
+		return 1
+	}
+
+	// outbox
+	// Транзакции кластера отмечают запись, после которой пользователь читает из основной БД
+	transactor := cluster
+	outboxLog := log.With("component", "outbox")
+	outboxRepo := domainOutboxRepo.NewOutboxRepo(DBConn.Pool, outboxLog)
+	outboxPublisher, err := newOutboxPublisher(cfg.Outbox)
+	if err != nil {
+		log.Error("Failed to create outbox publisher", pkgLogger.Err(err))
+		return 1
+	}
+
+	// webhook domain
+	webhookLog := log.With("component", "webhook")
+	webhookRepo := domainWebhookRepo.NewWebhookRepo(DBConn.Pool, webhookLog)
+	webhookUC := domainWebhookUsecase.NewWebhookUseCase(webhookRepo, webhookLog)
+	deliveryWorker := domainWebhookUsecase.NewDeliveryWorker(
+		webhookRepo,
+		pkgEgress.NewClient(
+			pkgEgress.Timeout(cfg.Webhook.Timeout),
+			pkgEgress.AllowPrivateNetworks(cfg.Webhook.AllowPrivateNetworks),
+		),
+		webhookLog,
+		cfg.Webhook.DeliveryInterval,
+		cfg.Webhook.BatchSize,
+		cfg.Webhook.MaxAttempts,
+		cfg.Webhook.BaseBackoff,
+	)
+
+	dispatcher := domainOutboxUsecase.NewDispatcher(
+		outboxRepo,
+		transactor,
+		domainOutboxPublisher.NewMultiPublisher(outboxPublisher, webhookUC),
+		outboxLog,
+		cfg.Outbox.PollInterval,
+		cfg.Outbox.BatchSize,
+	)
+
+	workers := pkgHealth.NewWorkers()
+	if len(replicas) > 0 {
+		workers.Add("postgres_replica_check", cluster.Run)
+	}
+	workers.Add("outbox_dispatcher", dispatcher.Run)
+	workers.Add("webhook_delivery", deliveryWorker.Run)
+	workers.Add("login_attempts_cleanup", func(ctx context.Context) {
+		loginAttempts.RunCleanup(ctx, cfg.RateLimit.CleanupInterval)
+	})
+	log.Info("Outbox dispatcher configured", slog.String("publisher", cfg.Outbox.Publisher))
+
+	// live events
+	var liveEvents pkgPubSub.Broker
+	switch cfg.Events.Backend {
+	case "postgres":
+		pgBroker := pkgPubSub.NewPostgres(DBConn.Pool, cfg.Events.BufferSize, log.With("component", "pubsub"))
+		workers.Add("live_events_listener", pgBroker.Run)
+		liveEvents = pgBroker
+	default:
+		liveEvents = pkgPubSub.NewMemory(cfg.Events.BufferSize)
+	}
+	log.Info("Live events broker configured", slog.String("backend", cfg.Events.Backend))
 
 	// pvz domain
-	pvzRepo := domainPvzRepo.NewPVZRepo(DBConn.Pool)
-	pvzUC := domainPvzUsecase.NewPVZUseCase(pvzRepo)
+	pvzLog := log.With("component", "pvz")
+	pvzRepo := domainPvzRepo.NewPVZRepo(cluster, pvzLog)
+	pvzUC := domainPvzUsecase.NewPVZUseCase(pvzRepo, outboxRepo, transactor, liveEvents, pvzLog)
+	workers.Add("pvz_metrics_refresh", func(ctx context.Context) {
+		pvzUC.RunMetricsRefresh(ctx, cfg.Prometheus.RefreshInterval)
+	})
 
-	// Создаем middleware
-	authMiddleware := domainAuthControllerHttp.JWTMiddleware(authUC.GetJwtManager())
-	employeeOnly := domainAuthControllerHttp.RolesMiddleware(userEntity.RoleEmployee)
-	moderatorOnly := domainAuthControllerHttp.RolesMiddleware(userEntity.RoleModerator)
-	employeeOrModerator := domainAuthControllerHttp.RolesMiddleware(userEntity.RoleEmployee, userEntity.RoleModerator)
-
-	server := pkgHttpserver.New(
-		pkgHttpserver.Port(cfg.HTTP.Port),
-		pkgHttpserver.ReadTimeout(10*time.Second),
+	// idempotency
+	idempotencyLog := log.With("component", "idempotency")
+	idempotencyUC := domainIdempotencyUsecase.NewIdempotencyUseCase(
+		domainIdempotencyRepo.NewIdempotencyRepo(DBConn.Pool, idempotencyLog),
+		idempotencyLog,
+		cfg.Idempotency.TTL,
 	)
+	workers.Add("idempotency_cleanup", func(ctx context.Context) {
+		idempotencyUC.RunCleanup(ctx, cfg.Idempotency.CleanupInterval)
+	})
+
+	// export domain
+	exportLog := log.With("component", "export")
+	exportRepo := domainExportRepo.NewExportRepo(DBConn.Pool, exportLog)
+	exportUC := domainExportUsecase.NewExportUseCase(exportRepo, cluster.ReadTransactor(), exportLog)
+
+	// Создаем middleware
+	authMiddleware := domainAuthControllerHttp.AuthMiddleware(authUC.GetJwtManager(), apiKeyUC)
+	requirePermission := domainAuthControllerHttp.NewPermissionMiddleware(permissionPolicy)
+	idempotency := domainIdempotencyControllerHttp.IdempotencyMiddleware(idempotencyUC)
+
+	ipLimiter := pkgRateLimit.NewMemory(cfg.RateLimit.IPRate, cfg.RateLimit.IPBurst)
+	emailLimiter := pkgRateLimit.NewMemory(cfg.RateLimit.EmailRate, cfg.RateLimit.EmailBurst)
+	workers.Add("rate_limit_ip_cleanup", func(ctx context.Context) {
+		ipLimiter.RunCleanup(ctx, cfg.RateLimit.CleanupInterval)
+	})
+	workers.Add("rate_limit_email_cleanup", func(ctx context.Context) {
+		emailLimiter.RunCleanup(ctx, cfg.RateLimit.CleanupInterval)
+	})
+	loginLimits := []gin.HandlerFunc{
+		domainAuthControllerHttp.RateLimitMiddleware(ipLimiter, domainAuthControllerHttp.ByClientIP),
+		domainAuthControllerHttp.RateLimitMiddleware(emailLimiter, domainAuthControllerHttp.ByJSONField("email")),
+	}
+	if !cfg.Auth.DummyLoginEnabled {
+		log.Info("/dummyLogin is disabled")
+	}
+
+	server := pkgHttpserver.New(newHTTPServerOptions(cfg.HTTP, log)...)
 	router := server.GetRouter()
 
-	// Добавляем middleware для метрик
-	router.Use(func(c *gin.Context) {
-		start := time.Now()
-		c.Next()
-
-		pkgMetrics.HttpRequestsTotal.WithLabelValues(
-			c.Request.Method,
-			c.Request.URL.Path,
-			strconv.Itoa(c.Writer.Status()),
-		).Inc()
-
-		pkgMetrics.HttpRequestDuration.WithLabelValues(
-			c.Request.Method,
-			c.Request.URL.Path,
-		).Observe(time.Since(start).Seconds())
+	// Пробы регистрируются до middleware: частые запросы балансировщика не
+	// попадают в access-лог, трассы и метрики
+	health := pkgHealth.New(pkgHealth.Timeout(cfg.Health.CheckTimeout))
+	health.Add("postgres", DBConn.Pool.Ping)
+	health.Add("migrations", DBConn.CheckSchema(migrations.Latest()))
+	health.Add("workers", workers.Check)
+	router.GET("/healthz", health.Liveness)
+	router.GET("/readyz", health.Readiness)
+
+	router.Use(pkgTracing.Middleware())
+	router.Use(pkgLogger.RequestMiddleware(log))
+
+	router.Use(pkgMetrics.NewHTTP(prometheus.DefaultRegisterer).Middleware())
+
+	registerRoutes(router, log, cfg.Auth, passwordPolicy, authUC, apiKeyUC, oidcUC, pvzUC, webhookUC, exportUC, authMiddleware, requirePermission, idempotency, loginLimits)
+
+	metricsServer := pkgHttpserver.New(
+		pkgHttpserver.Port(cfg.Prometheus.Port),
+		pkgHttpserver.Logger(log),
+		pkgHttpserver.Compression(false),
+	)
+	metricsServer.GetRouter().GET("/metrics", gin.WrapH(promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{
+		// OpenMetrics нужен для exemplar'ов с trace_id
+		EnableOpenMetrics: true,
+	})))
+
+	lifecycle.Add(pkgLifecycle.Hook{
+		Name:    "workers",
+		OnStart: workers.Start,
+		OnStop:  workers.Stop,
+		Done:    workers.Failed(),
+	})
+	lifecycle.Add(pkgLifecycle.Hook{
+		Name: "metrics_server",
+		OnStart: func(context.Context) error {
+			metricsServer.Start()
+			log.Info("Prometheus metrics server started", slog.String("port", cfg.Prometheus.Port))
+			return nil
+		},
+		OnStop: metricsServer.Shutdown,
+		Done:   metricsServer.Notify(),
+	})
+	lifecycle.Add(pkgLifecycle.Hook{
+		Name: "http_server",
+		OnStart: func(context.Context) error {
+			server.Start()
+			log.Info("Server started", slog.String("port", cfg.HTTP.Port))
+			return nil
+		},
+		OnStop: server.Shutdown,
+		Done:   server.Notify(),
+	})
+	// Останавливается первым: /readyz отвечает 503, пока балансировщик снимает
+	// трафик, а сервер продолжает обслуживать запросы
+	lifecycle.Add(pkgLifecycle.Hook{
+		Name: "drain",
+		OnStop: func(ctx context.Context) error {
+			health.SetDraining()
+			if cfg.Shutdown.DrainDelay <= 0 {
+				return nil
+			}
+			log.Info("Draining traffic before shutdown", slog.Duration("delay", cfg.Shutdown.DrainDelay))
+			select {
+			case <-time.After(cfg.Shutdown.DrainDelay):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
 	})
 
-	registerRoutes(router, authUC, pvzUC, authMiddleware, employeeOnly, moderatorOnly, employeeOrModerator)
-	server.Start()
-	log.Info("Server started on port ", slog.String("port", cfg.HTTP.Port))
-	waitForShutdown(server, log)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if err := lifecycle.Run(ctx); err != nil {
+		log.Error("Application stopped with error", pkgLogger.Err(err))
+		return 1
+	}
+	log.Info("Application exited gracefully")
+	return 0
 }
 
 
 func registerRoutes(
 	router *gin.Engine,
+	log *pkgLogger.Logger,
+	authCfg config.Auth,
+	passwordPolicy *pkgValidator.PasswordPolicy,
 	authUC *domainAuthUsecase.AuthUseCase,
+	apiKeyUC *domainAuthUsecase.APIKeyUseCase,
+	oidcUC *domainAuthUsecase.OIDCUseCase,
 	pvzUC *domainPvzUsecase.PVZUseCase,
+	webhookUC *domainWebhookUsecase.WebhookUseCase,
+	exportUC *domainExportUsecase.ExportUseCase,
 	authMiddleware gin.HandlerFunc,
-	employeeOnly gin.HandlerFunc,
-	moderatorOnly gin.HandlerFunc,
-	employeeOrModerator gin.HandlerFunc,
+	requirePermission domainAuthControllerHttp.PermissionMiddleware,
+	idempotency gin.HandlerFunc,
+	loginLimits []gin.HandlerFunc,
 ) {
 	api := router.Group("/")
+	authLog := log.With("component", "auth")
 
 	// Auth routes (public)
-	domainAuthControllerHttp.NewAuthRouter(api, authUC)
+	domainAuthControllerHttp.NewAuthRouter(api, authUC, passwordPolicy, authLog, authMiddleware, requirePermission, authCfg.DummyLoginEnabled, loginLimits...)
+
+	// SSO routes (public), только если настроен OIDC_ISSUER
+	if oidcUC != nil {
+		domainAuthControllerHttp.NewOIDCRouter(api, oidcUC, authLog, loginLimits...)
+	}
+
+	// API key routes (api_key:manage)
+	domainAuthControllerHttp.NewAPIKeyRouter(api, apiKeyUC, authLog, authMiddleware, requirePermission(userEntity.PermissionAPIKeyManage))
 
 	// PVZ routes (protected)
-	domainPVZControllerHttp.NewPVZRouter(api, pvzUC, authMiddleware, employeeOnly, moderatorOnly, employeeOrModerator)
+	domainPVZControllerHttp.NewPVZRouter(api, pvzUC, log.With("component", "pvz"), authMiddleware, requirePermission, idempotency)
+
+	// Webhook routes (webhook:manage)
+	domainWebhookControllerHttp.NewWebhookRouter(api, webhookUC, log.With("component", "webhook"), authMiddleware, requirePermission(userEntity.PermissionWebhookManage))
+
+	// Export routes (export:read)
+	domainExportControllerHttp.NewExportRouter(api, exportUC, log.With("component", "export"), authMiddleware, requirePermission(userEntity.PermissionExportRead))
+
+	// Admin routes (system:manage)
+	domainAdminControllerHttp.NewAdminRouter(api, log, log.With("component", "admin"), authMiddleware, requirePermission(userEntity.PermissionSystemManage))
 
 	// Swagger
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -132,15 +397,195 @@ func registerRoutes(
 	})
 }
 
-func waitForShutdown(server *pkgHttpserver.Server, log *pkgLogger.Logger) {
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+func newPostgresOptions(cfg config.DB, log *pkgLogger.Logger) []pkgPostgres.Option {
+	return []pkgPostgres.Option{
+		pkgPostgres.Logger(log.With("component", "postgres")),
+		pkgPostgres.MaxPoolSize(cfg.MaxConns),
+		pkgPostgres.MinIdleConns(cfg.MinIdleConns),
+		pkgPostgres.MaxConnLifetime(cfg.MaxConnLifetime),
+		pkgPostgres.MaxConnIdleTime(cfg.MaxConnIdleTime),
+		pkgPostgres.HealthCheckPeriod(cfg.HealthCheckPeriod),
+		pkgPostgres.StatementTimeout(cfg.StatementTimeout),
+		pkgPostgres.ConnAttempts(cfg.ConnAttempts),
+		pkgPostgres.ConnTimeout(cfg.ConnBackoff),
+		pkgPostgres.MaxConnBackoff(cfg.ConnMaxBackoff),
+	}
+}
+
+// newCluster подключает реплики из DB_REPLICA_URLS. Реплики создаются без
+// проверки соединения: недоступная реплика не мешает старту, чтение с нее
+// начнется после успешной проверки.
+func newCluster(cfg config.DB, primary *pkgPostgres.Postgres, log *pkgLogger.Logger) (*pkgPostgres.Cluster, []*pkgPostgres.Postgres, error) {
+	clusterLog := log.With("component", "postgres")
+	opts := []pkgPostgres.ClusterOption{
+		pkgPostgres.ReadAfterWrite(cfg.ReadAfterWrite),
+		pkgPostgres.CheckInterval(cfg.ReplicaCheckInterval),
+		pkgPostgres.SessionKey(userSessionKey),
+		pkgPostgres.ClusterLogger(clusterLog),
+	}
+
+	replicas := make([]*pkgPostgres.Postgres, 0, len(cfg.ReplicaURLs))
+	for i, url := range cfg.ReplicaURLs {
+		name := fmt.Sprintf("replica_%d", i+1)
+		replica, err := pkgPostgres.New(url, append(newPostgresOptions(cfg, log), pkgPostgres.LazyConnect())...)
+		if err != nil {
+			for _, r := range replicas {
+				r.Close()
+			}
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		prometheus.MustRegister(pkgPostgres.NewPoolCollector(replica.Pool, name))
+		replicas = append(replicas, replica)
+		opts = append(opts, pkgPostgres.Replica(name, replica.Pool))
+	}
+	if len(replicas) > 0 {
+		clusterLog.Info("Read replicas configured", slog.Int("replicas", len(replicas)))
+	}
+
+	return pkgPostgres.NewCluster(primary.Pool, opts...), replicas, nil
+}
+
+// userSessionKey — сессия для окна чтения после записи: пользователь из
+// AuthMiddleware. Контекст запроса — *gin.Context, который отдает значения
+// из c.Keys по строковому ключу.
+func userSessionKey(ctx context.Context) string {
+	userID, _ := ctx.Value("user_id").(string)
+	return userID
+}
+
+func newHTTPServerOptions(cfg config.HTTP, log pkgLogger.Interface) []pkgHttpserver.Option {
+	opts := []pkgHttpserver.Option{
+		pkgHttpserver.Port(cfg.Port),
+		pkgHttpserver.Logger(log),
+		pkgHttpserver.ReadTimeout(cfg.ReadTimeout),
+		pkgHttpserver.ReadHeaderTimeout(cfg.ReadHeaderTimeout),
+		pkgHttpserver.WriteTimeout(cfg.WriteTimeout),
+		pkgHttpserver.IdleTimeout(cfg.IdleTimeout),
+		pkgHttpserver.ShutdownTimeout(cfg.ShutdownTimeout),
+		pkgHttpserver.MaxHeaderBytes(cfg.MaxHeaderBytes),
+		pkgHttpserver.H2C(cfg.H2C),
+		pkgHttpserver.Compression(cfg.Compression),
+		pkgHttpserver.CORSOrigins(cfg.CORSOrigins),
+		pkgHttpserver.TrustedProxies(cfg.TrustedProxies),
+	}
+	if cfg.TLSCert != "" || cfg.TLSKey != "" {
+		opts = append(opts, pkgHttpserver.TLS(cfg.TLSCert, cfg.TLSKey))
+	}
+	return opts
+}
+
+func newOutboxPublisher(cfg config.Outbox) (domainOutboxPublisher.Publisher, error) {
+	switch cfg.Publisher {
+	case "log":
+		return domainOutboxPublisher.NewFilePublisher(cfg.FilePath)
+	case "webhook":
+		if cfg.WebhookURL == "" {
+			return nil, fmt.Errorf("OUTBOX_WEBHOOK_URL is required for webhook publisher")
+		}
+		return domainOutboxPublisher.NewWebhookPublisher(cfg.WebhookURL, nil), nil
+	default:
+		return nil, fmt.Errorf("unknown outbox publisher %q", cfg.Publisher)
+	}
+}
+
+func newPasswordPolicy(cfg config.Password) (*pkgValidator.PasswordPolicy, error) {
+	var denylist []string
+	if cfg.DenylistFile != "" {
+		f, err := os.Open(cfg.DenylistFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		if denylist, err = pkgValidator.ReadDenylist(f); err != nil {
+			return nil, fmt.Errorf("read %s: %w", cfg.DenylistFile, err)
+		}
+	}
+
+	return pkgValidator.NewPasswordPolicy(
+		cfg.MinLength,
+		cfg.RequireUpper,
+		cfg.RequireLower,
+		cfg.RequireDigit,
+		cfg.RequireSymbol,
+		denylist,
+	), nil
+}
+
+func newPermissionPolicy(cfg config.Permissions) (*userEntity.PermissionPolicy, error) {
+	if cfg.PolicyFile == "" {
+		return userEntity.NewPermissionPolicy(userEntity.DefaultRolePermissions())
+	}
+
+	f, err := os.Open(cfg.PolicyFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	policy, err := userEntity.ReadPermissionPolicy(f)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", cfg.PolicyFile, err)
+	}
+	return policy, nil
+}
+
+// newLogger строит логгер из конфигурации. Раньше окружение задавалось в
+// LOG_LEVEL (local, dev, prod); такое значение по-прежнему принимается.
+func newLogger(cfg config.Log, out io.Writer) (*pkgLogger.Logger, error) {
+	opts := pkgLogger.Options{Env: cfg.Env, Level: cfg.Level, Format: cfg.Format, Output: out}
+	legacy := false
+	switch strings.ToLower(cfg.Level) {
+	case "local", "dev", "prod":
+		opts.Env, opts.Level, legacy = cfg.Level, "", true
+	}
 
-	log.Info("Shutting down server...")
-	if err := server.Shutdown(); err != nil {
-		log.Error("Error during shutdown", pkgLogger.Err(err))
-	} else {
-		log.Info("Server exited gracefully")
+	log, err := pkgLogger.NewWithOptions(opts)
+	if err != nil {
+		return nil, fmt.Errorf("LOG_LEVEL/LOG_FORMAT: %w", err)
+	}
+	if legacy {
+		log.Warn("LOG_LEVEL with an environment name is deprecated, set APP_ENV instead", slog.String("LOG_LEVEL", cfg.Level))
 	}
+	return log, nil
+}
+
+func newMFAPolicy(cfg config.Auth) (domainAuthUsecase.MFAPolicy, error) {
+	policy := domainAuthUsecase.MFAPolicy{ChallengeTTL: cfg.MFAChallengeTTL, Issuer: cfg.MFAIssuer}
+	for _, name := range cfg.MFARequiredRoles {
+		role := userEntity.Role(name)
+		if !role.IsValid() {
+			return policy, fmt.Errorf("AUTH_MFA_REQUIRED_ROLES: %w: %s", pkgValidator.ErrInvalidRole, name)
+		}
+		policy.RequiredRoles = append(policy.RequiredRoles, role)
+	}
+	return policy, nil
+}
+
+// newOIDCUseCase возвращает nil, если SSO не настроен.
+func newOIDCUseCase(cfg config.OIDC, users domainAuthRepo.UserRepository, issuer domainAuthUsecase.LoginIssuer, stateSecret string, log pkgLogger.Interface) (*domainAuthUsecase.OIDCUseCase, error) {
+	if cfg.Issuer == "" {
+		return nil, nil
+	}
+	if cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, fmt.Errorf("OIDC_CLIENT_ID and OIDC_REDIRECT_URL are required with OIDC_ISSUER")
+	}
+
+	roles, err := domainAuthUsecase.ParseRoleMappings(cfg.RoleMapping)
+	if err != nil {
+		return nil, fmt.Errorf("OIDC_ROLE_MAPPING: %w", err)
+	}
+	defaultRole := userEntity.Role(cfg.DefaultRole)
+	if defaultRole != "" && !defaultRole.IsValid() {
+		return nil, fmt.Errorf("OIDC_DEFAULT_ROLE: %w", pkgValidator.ErrInvalidRole)
+	}
+
+	provider := pkgOIDC.New(pkgOIDC.Config{
+		Issuer:       cfg.Issuer,
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+		Scopes:       cfg.Scopes,
+	})
+	return domainAuthUsecase.NewOIDCUseCase(provider, users, issuer, log, cfg.GroupsClaim, roles, defaultRole, cfg.LoginTTL, cfg.SkipEmailVerified, stateSecret), nil
 }
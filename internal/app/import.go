@@ -0,0 +1,93 @@
+package app
+
+import (
+	"GoPVZ/config"
+	domainOutboxRepo "GoPVZ/internal/outbox/repo"
+	pvzEntity "GoPVZ/internal/pvz/entity"
+	"GoPVZ/internal/pvz/importer"
+	domainPvzRepo "GoPVZ/internal/pvz/repo"
+	domainPvzUsecase "GoPVZ/internal/pvz/usecase"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgPostgres"
+	"GoPVZ/pkg/pkgPubSub"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// RunImportPVZ — подкоманда import-pvz: импорт ПВЗ из CSV без HTTP сервера.
+// Отчет печатается в stdout в том же формате, что и у POST /pvz/import.
+// Код выхода: 0 — все строки валидны, 1 — ошибка, 2 — в файле есть невалидные строки.
+func RunImportPVZ(cfg *config.Config, args []string) int {
+	// Логи идут в stderr, чтобы не смешиваться с отчетом в stdout
+	log, err := newLogger(cfg.Log, os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Logger config error: %s\n", err)
+		return 1
+	}
+
+	fs := flag.NewFlagSet("import-pvz", flag.ContinueOnError)
+	file := fs.String("file", "", "path to CSV with city,address,external_id columns (- for stdin)")
+	dryRun := fs.Bool("dry-run", false, "validate and report without saving")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if *file == "" {
+		fs.Usage()
+		return 1
+	}
+
+	var input io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			log.Error("Failed to open import file", pkgLogger.Err(err))
+			return 1
+		}
+		defer f.Close()
+		input = f
+	}
+
+	rows, err := importer.ParseCSV(input)
+	if err != nil {
+		log.Error("Failed to parse import file", pkgLogger.Err(err))
+		return 1
+	}
+
+	DBConn, err := pkgPostgres.New(cfg.PGURL.URL, newPostgresOptions(cfg.DB, log)...)
+	if err != nil {
+		log.Error("Failed to connect to database", pkgLogger.Err(err))
+		return 1
+	}
+	defer DBConn.Close()
+
+	// События pvz.created попадают в outbox и публикуются запущенным сервисом
+	pvzLog := log.With("component", "pvz")
+	pvzUC := domainPvzUsecase.NewPVZUseCase(
+		domainPvzRepo.NewPVZRepo(pkgPostgres.NewCluster(DBConn.Pool), pvzLog),
+		domainOutboxRepo.NewOutboxRepo(DBConn.Pool, log.With("component", "outbox")),
+		pkgPostgres.NewTransactor(DBConn.Pool),
+		pkgPubSub.NewMemory(0),
+		pvzLog,
+	)
+
+	report, err := pvzUC.ImportPVZs(context.Background(), pvzEntity.Scope{}, rows, *dryRun)
+	if err != nil {
+		log.Error("Import failed", pkgLogger.Err(err))
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return 1
+	}
+
+	if report.Invalid > 0 {
+		return 2
+	}
+	return 0
+}
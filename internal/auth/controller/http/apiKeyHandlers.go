@@ -0,0 +1,155 @@
+package http
+
+import (
+	"GoPVZ/internal/auth/entity"
+	"GoPVZ/internal/auth/usecase"
+	"GoPVZ/internal/auth/validation"
+	"GoPVZ/internal/dto"
+	pvzEntity "GoPVZ/internal/pvz/entity"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgValidator"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	uc  *usecase.APIKeyUseCase
+	log pkgLogger.Interface
+}
+
+func NewAPIKeyHandler(uc *usecase.APIKeyUseCase, log pkgLogger.Interface) *APIKeyHandler {
+	return &APIKeyHandler{uc: uc, log: log}
+}
+
+// CreateAPIKey godoc
+// @Summary Выпуск API ключа сервисного аккаунта
+// @Description Ключ передается в заголовке X-API-Key вместо Bearer токена. Запросы с ключом выполняются с ролью service и только с разрешениями ключа. Ключу можно выдать только разрешения роли создателя, ключ ограничен регионами создателя
+// @Tags Domain auth
+// @Accept json
+// @Produce json
+// @Param input body dto.PostApiKeysJSONRequestBody true "Параметры ключа"
+// @Success 201 {object} dto.ApiKey "Ключ создан, сам ключ возвращается только в этом ответе"
+// @Failure 400 {object} dto.Error "Неверный формат запроса или ошибка валидации"
+// @Failure 401 {object} dto.Error "Ошибка авторизации"
+// @Failure 403 {object} dto.Error "Доступ запрещен или у роли нет запрошенного разрешения"
+// @Failure 500 {object} dto.Error "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req dto.PostApiKeysJSONRequestBody
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: pkgValidator.ErrInvalidInput.Error()})
+		return
+	}
+
+	validator := validation.NewAPIKeyValidator(req)
+	if err := validator.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: err.Error()})
+		return
+	}
+
+	permissions := make([]entity.Permission, 0, len(req.Permissions))
+	for _, p := range req.Permissions {
+		permissions = append(permissions, entity.Permission(p))
+	}
+
+	issuer := usecase.KeyIssuer{
+		UserID:  c.GetString("user_id"),
+		Role:    entity.Role(c.GetString("user_role")),
+		Regions: c.GetStringSlice("user_regions"),
+	}
+	key, secret, err := h.uc.Create(c, issuer, req.Name, permissions, req.ExpiresAt)
+	if errors.Is(err, pkgValidator.ErrPermissionNotHeld) {
+		c.JSON(http.StatusForbidden, dto.Error{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		respondInternalError(c, h.log, err)
+		return
+	}
+
+	resp := toAPIKeyDTO(key)
+	resp.Key = &secret
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ListAPIKeys godoc
+// @Summary Список API ключей
+// @Description Возвращает ключи, включая отозванные, без самих ключей. Пользователь, ограниченный городами, видит только выпущенные им ключи
+// @Tags Domain auth
+// @Produce json
+// @Success 200 {array} dto.ApiKey "Список ключей"
+// @Failure 401 {object} dto.Error "Ошибка авторизации"
+// @Failure 403 {object} dto.Error "Доступ запрещен"
+// @Failure 500 {object} dto.Error "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.uc.List(c, pvzEntity.NewScope(c.GetStringSlice("user_regions")), c.GetString("user_id"))
+	if err != nil {
+		respondInternalError(c, h.log, err)
+		return
+	}
+
+	response := make([]dto.ApiKey, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, toAPIKeyDTO(key))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeAPIKey godoc
+// @Summary Отзыв API ключа
+// @Description Отозванный ключ сразу перестает приниматься, запись остается в списке. Пользователь, ограниченный городами, отзывает только выпущенные им ключи
+// @Tags Domain auth
+// @Produce json
+// @Param keyId path string true "keyId"
+// @Success 204 "Ключ отозван"
+// @Failure 400 {object} dto.Error "Невалидный идентификатор"
+// @Failure 401 {object} dto.Error "Ошибка авторизации"
+// @Failure 403 {object} dto.Error "Доступ запрещен"
+// @Failure 404 {object} dto.Error "Ключ не найден, уже отозван или выпущен другим пользователем"
+// @Failure 500 {object} dto.Error "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /api-keys/{keyId} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	keyId := c.Param("keyId")
+
+	validator := validation.NewAPIKeyIDValidator(keyId)
+	if err := validator.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: err.Error()})
+		return
+	}
+
+	scope := pvzEntity.NewScope(c.GetStringSlice("user_regions"))
+	if err := h.uc.Revoke(c, scope, c.GetString("user_id"), keyId); err != nil {
+		if errors.Is(err, pkgValidator.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, dto.Error{Message: err.Error()})
+		} else {
+			respondInternalError(c, h.log, err)
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func toAPIKeyDTO(key *entity.APIKey) dto.ApiKey {
+	permissions := make([]string, 0, len(key.Permissions))
+	for _, p := range key.Permissions {
+		permissions = append(permissions, string(p))
+	}
+	return dto.ApiKey{
+		Id:          key.ID,
+		Name:        key.Name,
+		Prefix:      key.Prefix,
+		Permissions: permissions,
+		Regions:     key.Regions,
+		ExpiresAt:   key.ExpiresAt,
+		LastUsedAt:  key.LastUsedAt,
+		RevokedAt:   key.RevokedAt,
+		CreatedAt:   key.CreatedAt.UTC(),
+	}
+}
@@ -1,14 +1,21 @@
 package http
 
 import (
-    "slices"
+	"errors"
+	"log/slog"
 	"net/http"
+	"slices"
     "strings"
     "GoPVZ/internal/auth/usecase"
     "GoPVZ/internal/auth/entity"
+    "GoPVZ/pkg/pkgLogger"
+    "GoPVZ/pkg/pkgValidator"
     "github.com/gin-gonic/gin"
 )
 
+// APIKeyHeader — заголовок с ключом сервисного аккаунта.
+const APIKeyHeader = "X-API-Key"
+
 func JWTMiddleware(jm *usecase.JwtManager) gin.HandlerFunc {
     return func(c *gin.Context) {
         auth := c.GetHeader("Authorization")
@@ -23,21 +30,79 @@ func JWTMiddleware(jm *usecase.JwtManager) gin.HandlerFunc {
             return
         }
 
-        c.Set("user_id", (*claims)["user_id"])
-        c.Set("user_email", (*claims)["email"])
-        c.Set("user_role", (*claims)["role"])
+        c.Set("user_id", claims.Subject)
+        c.Set("user_email", claims.Email)
+        c.Set("user_role", claims.Role)
+        c.Set("user_regions", claims.Regions)
+        c.Set("token_expires_at", claims.ExpiresAt.Time)
+        pkgLogger.AddRequestAttrs(c, slog.String("user_id", claims.Subject), slog.String("role", claims.Role))
+        c.Next()
+    }
+}
+
+// AuthMiddleware принимает Bearer токен или ключ сервисного аккаунта в X-API-Key.
+// Запрос с ключом выполняется от роли service, но только с разрешениями и
+// регионами ключа.
+func AuthMiddleware(jm *usecase.JwtManager, keys *usecase.APIKeyUseCase) gin.HandlerFunc {
+    jwtAuth := JWTMiddleware(jm)
+    return func(c *gin.Context) {
+        secret := c.GetHeader(APIKeyHeader)
+        if secret == "" {
+            jwtAuth(c)
+            return
+        }
+
+        key, err := keys.Authenticate(c, secret)
+        if errors.Is(err, pkgValidator.ErrInvalidAPIKey) {
+            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+            return
+        }
+        if err != nil {
+            c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+            return
+        }
+
+        c.Set("user_id", key.ID.String())
+        c.Set("user_role", string(entity.RoleService))
+        c.Set("user_permissions", key.Permissions)
+        c.Set("user_regions", key.Regions)
+        c.Set("api_key_name", key.Name)
+        if key.ExpiresAt != nil {
+            c.Set("token_expires_at", *key.ExpiresAt)
+        }
+        pkgLogger.AddRequestAttrs(c, slog.String("user_id", key.ID.String()), slog.String("role", string(entity.RoleService)))
         c.Next()
     }
 }
 
-func RolesMiddleware(allowedRoles ...entity.Role) gin.HandlerFunc {
+// PermissionMiddleware строит middleware, требующее разрешение.
+type PermissionMiddleware func(permission entity.Permission) gin.HandlerFunc
+
+// NewPermissionMiddleware -.
+func NewPermissionMiddleware(policy *entity.PermissionPolicy) PermissionMiddleware {
+    return func(permission entity.Permission) gin.HandlerFunc {
+        return RequirePermission(policy, permission)
+    }
+}
+
+// RequirePermission пропускает запрос, если роли из токена выдано разрешение.
+// Для API ключа проверяются разрешения самого ключа. Должно идти после AuthMiddleware.
+func RequirePermission(policy *entity.PermissionPolicy, permission entity.Permission) gin.HandlerFunc {
     return func(c *gin.Context) {
-        roleVal, _ := c.Get("user_role")
-        role := entity.Role(roleVal.(string))
-        if slices.Contains(allowedRoles, role) {
+        if permissions, ok := c.Get("user_permissions"); ok {
+            if slices.Contains(permissions.([]entity.Permission), permission) {
                 c.Next()
                 return
             }
+            c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "forbidden"})
+            return
+        }
+
+        role := entity.Role(c.GetString("user_role"))
+        if policy.Allows(role, permission) {
+            c.Next()
+            return
+        }
         c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "forbidden"})
     }
-}
\ No newline at end of file
+}
@@ -0,0 +1,162 @@
+package http
+
+import (
+	"GoPVZ/internal/auth/entity"
+	"GoPVZ/internal/auth/usecase"
+	pvzEntity "GoPVZ/internal/pvz/entity"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgValidator"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	policy, err := entity.NewPermissionPolicy(entity.DefaultRolePermissions())
+	require.NoError(t, err)
+	requirePermission := NewPermissionMiddleware(policy)
+
+	tests := []struct {
+		name       string
+		role       entity.Role
+		permission entity.Permission
+		wantStatus int
+	}{
+		{"employee closes reception", entity.RoleEmployee, entity.PermissionReceptionClose, http.StatusOK},
+		{"employee creates pvz", entity.RoleEmployee, entity.PermissionPVZCreate, http.StatusForbidden},
+		{"auditor reads pvz", entity.RoleAuditor, entity.PermissionPVZRead, http.StatusOK},
+		{"auditor creates pvz", entity.RoleAuditor, entity.PermissionPVZCreate, http.StatusForbidden},
+		{"service imports pvz", entity.RoleService, entity.PermissionPVZImport, http.StatusOK},
+		{"no role", "", entity.PermissionPVZRead, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/", func(c *gin.Context) {
+				if tt.role != "" {
+					c.Set("user_role", string(tt.role))
+				}
+			}, requirePermission(tt.permission), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			require.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+// memoryAPIKeyRepo — хранилище ключей для проверки middleware без базы.
+type memoryAPIKeyRepo struct {
+	keys map[string]*entity.APIKey
+	used map[uuid.UUID]time.Time
+}
+
+func (r *memoryAPIKeyRepo) Create(_ context.Context, key *entity.APIKey) error {
+	r.keys[key.KeyHash] = key
+	return nil
+}
+
+func (r *memoryAPIKeyRepo) GetByHash(_ context.Context, keyHash string) (*entity.APIKey, error) {
+	key, ok := r.keys[keyHash]
+	if !ok {
+		return nil, pkgValidator.ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+func (r *memoryAPIKeyRepo) List(context.Context, *uuid.UUID) ([]*entity.APIKey, error) {
+	return nil, nil
+}
+
+func (r *memoryAPIKeyRepo) Revoke(_ context.Context, id uuid.UUID, _ *uuid.UUID, at time.Time) error {
+	for _, key := range r.keys {
+		if key.ID == id {
+			key.RevokedAt = &at
+			return nil
+		}
+	}
+	return pkgValidator.ErrAPIKeyNotFound
+}
+
+func (r *memoryAPIKeyRepo) TouchLastUsed(_ context.Context, id uuid.UUID, at time.Time, _ time.Duration) error {
+	r.used[id] = at
+	return nil
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	policy, err := entity.NewPermissionPolicy(entity.DefaultRolePermissions())
+	require.NoError(t, err)
+	requirePermission := NewPermissionMiddleware(policy)
+
+	jm := usecase.NewJwtManager("secret", time.Hour, "gopvz", "gopvz-api")
+	keyRepo := &memoryAPIKeyRepo{keys: map[string]*entity.APIKey{}, used: map[uuid.UUID]time.Time{}}
+	keys := usecase.NewAPIKeyUseCase(keyRepo, policy, pkgLogger.Discard())
+
+	issuer := usecase.KeyIssuer{Role: entity.RoleModerator, Regions: []string{"Kazan"}}
+	readKey, readSecret, err := keys.Create(context.Background(), issuer, "erp", []entity.Permission{entity.PermissionPVZRead}, nil)
+	require.NoError(t, err)
+	revokedKey, revokedSecret, err := keys.Create(context.Background(), issuer, "old", []entity.Permission{entity.PermissionPVZRead}, nil)
+	require.NoError(t, err)
+	require.NoError(t, keys.Revoke(context.Background(), pvzEntity.Scope{}, "", revokedKey.ID.String()))
+
+	token, err := jm.GenerateToken(&entity.User{ID: uuid.New(), Email: "e@example.com", Role: entity.RoleEmployee})
+	require.NoError(t, err)
+
+	router := gin.New()
+	auth := AuthMiddleware(jm, keys)
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/pvz", auth, requirePermission(entity.PermissionPVZRead), ok)
+	router.GET("/regions", auth, func(c *gin.Context) { c.JSON(http.StatusOK, c.GetStringSlice("user_regions")) })
+	router.POST("/receptions", auth, requirePermission(entity.PermissionReceptionCreate), ok)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		header     string
+		value      string
+		wantStatus int
+	}{
+		{"bearer token", http.MethodGet, "/pvz", "Authorization", "Bearer " + token, http.StatusOK},
+		{"api key with permission", http.MethodGet, "/pvz", APIKeyHeader, readSecret, http.StatusOK},
+		// Роль service может создавать приемки, но ключу это не выдано
+		{"api key without permission", http.MethodPost, "/receptions", APIKeyHeader, readSecret, http.StatusForbidden},
+		{"revoked api key", http.MethodGet, "/pvz", APIKeyHeader, revokedSecret, http.StatusUnauthorized},
+		{"unknown api key", http.MethodGet, "/pvz", APIKeyHeader, "pvz_unknown", http.StatusUnauthorized},
+		{"no credentials", http.MethodGet, "/pvz", "", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			require.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+
+	require.Contains(t, keyRepo.used, readKey.ID)
+
+	// Ключ ограничен регионами создателя
+	req := httptest.NewRequest(http.MethodGet, "/regions", nil)
+	req.Header.Set(APIKeyHeader, readSecret)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.JSONEq(t, `["Kazan"]`, w.Body.String())
+}
@@ -4,18 +4,25 @@ import (
 	"GoPVZ/internal/auth/usecase"
 	"GoPVZ/internal/auth/validation"
 	"GoPVZ/internal/dto"
+	pvzEntity "GoPVZ/internal/pvz/entity"
+	"GoPVZ/pkg/pkgLogger"
 	"GoPVZ/pkg/pkgValidator"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type AuthHandler struct {
-	uc *usecase.AuthUseCase
+	uc     *usecase.AuthUseCase
+	policy *pkgValidator.PasswordPolicy
+	log    pkgLogger.Interface
 }
 
-func NewAuthHandler(uc *usecase.AuthUseCase) *AuthHandler {
-	return &AuthHandler{uc: uc}
+func NewAuthHandler(uc *usecase.AuthUseCase, policy *pkgValidator.PasswordPolicy, log pkgLogger.Interface) *AuthHandler {
+	return &AuthHandler{uc: uc, policy: policy, log: log}
 }
 
 // DummyLogin godoc
@@ -27,6 +34,8 @@ func NewAuthHandler(uc *usecase.AuthUseCase) *AuthHandler {
 // @Param        input  body      dto.PostDummyLoginJSONBody  true  "Роль пользователя"
 // @Success      200    {object}  dto.TokenResponse
 // @Failure      400    {object}  dto.Error
+// @Failure      404    {object}  dto.Error "Маршрут отключен конфигурацией"
+// @Failure      429    {object}  dto.Error
 // @Failure      500    {object}  dto.Error
 // @Router       /dummyLogin [post]
 func (h *AuthHandler) DummyLogin(c *gin.Context) {
@@ -42,9 +51,15 @@ func (h *AuthHandler) DummyLogin(c *gin.Context) {
 		return
 	}
 
-	token, err := h.uc.DummyLogin(c, string(req.Role))
+	// Без regions токен не ограничен городами: /dummyLogin нужен для локальной разработки
+	regions := []string{pvzEntity.AllCities}
+	if req.Regions != nil {
+		regions = *req.Regions
+	}
+
+	token, err := h.uc.DummyLogin(c, string(req.Role), regions)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.Error{Message: err.Error()})
+		respondInternalError(c, h.log, err)
 		return
 	}
 	c.JSON(http.StatusOK, dto.TokenResponse{
@@ -70,7 +85,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	validator := validation.NewRegisterValidator(req)
+	validator := validation.NewRegisterValidator(req, h.policy)
 	if err := validator.Validate(); err != nil {
 		c.JSON(http.StatusBadRequest, dto.Error{Message: err.Error()})
 		return
@@ -78,7 +93,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 	user, err := h.uc.Register(c, req.Email, req.Password, string(req.Role))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.Error{Message: err.Error()})
+		respondInternalError(c, h.log, err)
 		return
 	}
 	c.JSON(http.StatusCreated, dto.User{Id: &user.ID, Email: req.Email, Role: dto.UserRole(user.Role)})
@@ -86,13 +101,15 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 // Login godoc
 // @Summary      Вход в систему
-// @Description  Аутентификация пользователя по email и паролю
+// @Description  Аутентификация пользователя по email и паролю. Если нужен второй фактор, вместо токена возвращается промежуточный токен для /login/2fa
 // @Tags         Domain auth
 // @Accept       json
 // @Produce      json
 // @Param        input  body      dto.PostLoginJSONBody  true  "Данные для входа"
 // @Success      200    {object}  dto.TokenResponse
+// @Success      202    {object}  dto.MfaChallenge "Нужен второй фактор"
 // @Failure      400    {object}  dto.Error
+// @Failure      429    {object}  dto.Error "Слишком много попыток или аккаунт временно заблокирован"
 // @Failure      500    {object}  dto.Error
 // @Router       /login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -108,12 +125,240 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := h.uc.Login(c, string(req.Email), req.Password)
+	result, err := h.uc.Login(c, string(req.Email), req.Password)
+	var locked *usecase.LockedError
+	if errors.As(err, &locked) {
+		setRetryAfter(c, locked.RetryAfter)
+		c.JSON(http.StatusTooManyRequests, dto.Error{Message: err.Error()})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.Error{Message: err.Error()})
+		respondInternalError(c, h.log, err)
+		return
+	}
+	respondLogin(c, result)
+}
+
+// respondLogin отвечает токеном или, если нужен второй фактор, промежуточным токеном для /login/2fa.
+func respondLogin(c *gin.Context, result *usecase.LoginResult) {
+	if result.Challenge != nil {
+		c.JSON(http.StatusAccepted, dto.MfaChallenge{
+			ChallengeToken:     result.Challenge.Token,
+			EnrollmentRequired: result.Challenge.EnrollmentRequired,
+			ExpiresAt:          result.Challenge.ExpiresAt.UTC().Truncate(time.Second),
+		})
 		return
 	}
 	c.JSON(http.StatusOK, dto.TokenResponse{
-		Token: token,
+		Token: result.Token,
 	})
 }
+
+// Me godoc
+// @Summary      Текущий пользователь
+// @Description  Возвращает пользователя из токена, закрепленные за ним ПВЗ и время истечения токена
+// @Tags         Domain auth
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200    {object}  dto.Me
+// @Failure      401    {object}  dto.Error
+// @Failure      404    {object}  dto.Error "Пользователь не найден, например для токена из /dummyLogin"
+// @Failure      500    {object}  dto.Error
+// @Router       /me [get]
+func (h *AuthHandler) Me(c *gin.Context) {
+	profile, err := h.uc.Me(c, c.GetString("user_id"), c.GetTime("token_expires_at"))
+	if errors.Is(err, pkgValidator.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, dto.Error{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		respondInternalError(c, h.log, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Me{
+		Id:             profile.User.ID,
+		Email:          profile.User.Email,
+		Role:           dto.UserRole(profile.User.Role),
+		Regions:        append([]string{}, profile.User.Regions...),
+		PvzIds:         append([]uuid.UUID{}, profile.PvzIDs...),
+		TokenExpiresAt: profile.TokenExpiresAt.UTC().Truncate(time.Second),
+	})
+}
+
+// SetRegions godoc
+// @Summary      Регион пользователя
+// @Description  Задает города пользователя: ["*"] — все города, пустой список — ни одного. Действует для токенов, выданных после изменения. Свои регионы менять нельзя, а пользователь, ограниченный городами, назначает только свои города
+// @Tags         Domain auth
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        userId  path      string                             true  "ID пользователя"
+// @Param        input   body      dto.PutUsersUserIdRegionsJSONBody  true  "Города"
+// @Success      200     {object}  dto.User
+// @Failure      400     {object}  dto.Error
+// @Failure      403     {object}  dto.Error "Нет права user:manage, свои регионы или города вне регионов пользователя"
+// @Failure      404     {object}  dto.Error "Пользователь не найден"
+// @Failure      500     {object}  dto.Error
+// @Router       /users/{userId}/regions [put]
+func (h *AuthHandler) SetRegions(c *gin.Context) {
+	userId := c.Param("userId")
+	if err := validation.NewUserIDValidator(userId).Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: err.Error()})
+		return
+	}
+
+	var req dto.PutUsersUserIdRegionsJSONBody
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: pkgValidator.ErrInvalidInput.Error()})
+		return
+	}
+
+	validator := validation.NewRegionsValidator(req.Regions)
+	if err := validator.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: err.Error()})
+		return
+	}
+
+	scope := pvzEntity.NewScope(c.GetStringSlice("user_regions"))
+	user, err := h.uc.SetRegions(c, scope, c.GetString("user_id"), userId, req.Regions)
+	if errors.Is(err, pkgValidator.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, dto.Error{Message: err.Error()})
+		return
+	}
+	if errors.Is(err, pkgValidator.ErrOwnRegions) || errors.Is(err, pkgValidator.ErrRegionNotHeld) {
+		c.JSON(http.StatusForbidden, dto.Error{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		respondInternalError(c, h.log, err)
+		return
+	}
+
+	regions := append([]string{}, user.Regions...)
+	c.JSON(http.StatusOK, dto.User{Id: &user.ID, Email: user.Email, Role: dto.UserRole(user.Role), Regions: &regions})
+}
+
+// ChangePassword godoc
+// @Summary      Смена пароля
+// @Description  Меняет пароль текущего пользователя. Новый пароль должен соответствовать политике паролей
+// @Tags         Domain auth
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        input  body      dto.PostMePasswordJSONBody  true  "Текущий и новый пароль"
+// @Success      204
+// @Failure      400    {object}  dto.Error "Неверный текущий пароль или новый пароль не соответствует политике"
+// @Failure      401    {object}  dto.Error
+// @Failure      404    {object}  dto.Error "Пользователь не найден"
+// @Failure      429    {object}  dto.Error "Слишком много неверных паролей, аккаунт временно заблокирован"
+// @Failure      500    {object}  dto.Error
+// @Router       /me/password [post]
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var req dto.PostMePasswordJSONBody
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: pkgValidator.ErrInvalidInput.Error()})
+		return
+	}
+
+	validator := validation.NewChangePasswordValidator(req, h.policy)
+	if err := validator.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: err.Error()})
+		return
+	}
+
+	err := h.uc.ChangePassword(c, c.GetString("user_id"), req.OldPassword, req.NewPassword)
+	var locked *usecase.LockedError
+	switch {
+	case errors.As(err, &locked):
+		setRetryAfter(c, locked.RetryAfter)
+		c.JSON(http.StatusTooManyRequests, dto.Error{Message: err.Error()})
+		return
+	case errors.Is(err, pkgValidator.ErrInvalidCredentials):
+		c.JSON(http.StatusBadRequest, dto.Error{Message: err.Error()})
+		return
+	case errors.Is(err, pkgValidator.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, dto.Error{Message: err.Error()})
+		return
+	case err != nil:
+		respondInternalError(c, h.log, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RequestPasswordReset godoc
+// @Summary      Запрос сброса пароля
+// @Description  Отправляет одноразовый токен сброса пароля на email. Ответ не зависит от того, зарегистрирован ли адрес
+// @Tags         Domain auth
+// @Accept       json
+// @Produce      json
+// @Param        input  body      dto.PostPasswordResetJSONBody  true  "Email пользователя"
+// @Success      202
+// @Failure      400    {object}  dto.Error
+// @Failure      429    {object}  dto.Error
+// @Failure      500    {object}  dto.Error
+// @Router       /password/reset [post]
+func (h *AuthHandler) RequestPasswordReset(c *gin.Context) {
+	var req dto.PostPasswordResetJSONBody
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: pkgValidator.ErrInvalidInput.Error()})
+		return
+	}
+
+	validator := validation.NewPasswordResetValidator(req)
+	if err := validator.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: err.Error()})
+		return
+	}
+
+	if err := h.uc.RequestPasswordReset(c, req.Email); err != nil {
+		respondInternalError(c, h.log, err)
+		return
+	}
+	c.Status(http.StatusAccepted)
+}
+
+// ConfirmPasswordReset godoc
+// @Summary      Сброс пароля по токену
+// @Description  Устанавливает новый пароль по токену из уведомления. Токен одноразовый и ограничен по времени
+// @Tags         Domain auth
+// @Accept       json
+// @Produce      json
+// @Param        input  body      dto.PostPasswordResetConfirmJSONBody  true  "Токен и новый пароль"
+// @Success      204
+// @Failure      400    {object}  dto.Error "Токен недействителен или пароль не соответствует политике"
+// @Failure      429    {object}  dto.Error
+// @Failure      500    {object}  dto.Error
+// @Router       /password/reset/confirm [post]
+func (h *AuthHandler) ConfirmPasswordReset(c *gin.Context) {
+	var req dto.PostPasswordResetConfirmJSONBody
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: pkgValidator.ErrInvalidInput.Error()})
+		return
+	}
+
+	validator := validation.NewPasswordResetConfirmValidator(req, h.policy)
+	if err := validator.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: err.Error()})
+		return
+	}
+
+	err := h.uc.ResetPassword(c, req.Token, req.NewPassword)
+	if errors.Is(err, pkgValidator.ErrInvalidResetToken) {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		respondInternalError(c, h.log, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// respondInternalError пишет ошибку в лог и отвечает 500. Маршрут, пользователь
+// и request_id попадают в запись из логгера запроса.
+func respondInternalError(c *gin.Context, log pkgLogger.Interface, err error) {
+	log.ErrorContext(c, "Request failed", pkgLogger.Err(err))
+	c.JSON(http.StatusInternalServerError, dto.Error{Message: err.Error()})
+}
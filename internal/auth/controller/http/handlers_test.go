@@ -7,17 +7,23 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"GoPVZ/internal/auth/entity"
+	"GoPVZ/internal/auth/notifier"
 	"GoPVZ/internal/auth/repo"
 	"GoPVZ/internal/auth/usecase"
 	"GoPVZ/internal/dto"
+	pvzEntity "GoPVZ/internal/pvz/entity"
+	"GoPVZ/pkg/pkgLogger"
 	"GoPVZ/pkg/pkgPostgres"
+	"GoPVZ/pkg/pkgTOTP"
 	"GoPVZ/pkg/pkgValidator"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
@@ -25,10 +31,29 @@ import (
 )
 
 var (
-	testConnStr string
-	pgContainer *postgres.PostgresContainer
+	testConnStr   string
+	pgContainer   *postgres.PostgresContainer
+	notifications = &recordingNotifier{}
 )
 
+// recordingNotifier запоминает отправленные сообщения, чтобы тест мог достать токен сброса.
+type recordingNotifier struct {
+	messages []notifier.Message
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, msg notifier.Message) error {
+	n.messages = append(n.messages, msg)
+	return nil
+}
+
+func (n *recordingNotifier) lastToken(t *testing.T) string {
+	require.NotEmpty(t, n.messages)
+	body := n.messages[len(n.messages)-1].Body
+	token, _, ok := strings.Cut(strings.TrimPrefix(body, "Токен для сброса пароля: "), "\n")
+	require.True(t, ok)
+	return token
+}
+
 func TestMain(m *testing.M) {
 	ctx := context.Background()
 
@@ -63,7 +88,38 @@ func TestMain(m *testing.M) {
 			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
 			email VARCHAR(255) NOT NULL UNIQUE,
 			password_hash VARCHAR(255) NOT NULL,
-			role VARCHAR(20) NOT NULL CHECK (role IN ('employee', 'moderator'))
+			role VARCHAR(20) NOT NULL CHECK (role IN ('employee', 'moderator')),
+			regions TEXT[] NOT NULL DEFAULT '{}'
+		);
+
+		CREATE TABLE IF NOT EXISTS user_pvz_assignments (
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			pvz_id UUID NOT NULL,
+			assigned_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (user_id, pvz_id)
+		);
+
+		CREATE TABLE IF NOT EXISTS password_reset_tokens (
+			token_hash CHAR(64) PRIMARY KEY,
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			expires_at TIMESTAMPTZ NOT NULL,
+			used_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		CREATE TABLE IF NOT EXISTS user_totp (
+			user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			secret VARCHAR(64) NOT NULL,
+			confirmed_at TIMESTAMPTZ,
+			last_step BIGINT NOT NULL DEFAULT 0,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		CREATE TABLE IF NOT EXISTS user_recovery_codes (
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			code_hash CHAR(64) NOT NULL,
+			used_at TIMESTAMPTZ,
+			PRIMARY KEY (user_id, code_hash)
 		);
 	`)
 	if err != nil {
@@ -81,16 +137,20 @@ func TestMain(m *testing.M) {
 }
 
 func setupTestHandler(t *testing.T) (*AuthHandler, func()) {
+	return setupTestHandlerWithMFA(t, usecase.MFAPolicy{ChallengeTTL: 5 * time.Minute, Issuer: "GoPVZ"})
+}
+
+func setupTestHandlerWithMFA(t *testing.T, mfa usecase.MFAPolicy) (*AuthHandler, func()) {
 	pg, err := pkgPostgres.New(testConnStr)
 	require.NoError(t, err)
 
 	_, err = pg.Pool.Exec(context.Background(), "TRUNCATE TABLE users CASCADE")
 	require.NoError(t, err)
 
-	userRepo := repo.NewUserRepo(pg.Pool)
-	jwtManager := usecase.NewJwtManager("test-secret", 3600)
-	uc := usecase.NewAuthUseCase(userRepo, jwtManager)
-	handler := NewAuthHandler(uc)
+	userRepo := repo.NewUserRepo(pg.Pool, pkgLogger.Discard())
+	jwtManager := usecase.NewJwtManager("test-secret", time.Hour, "gopvz", "gopvz-api")
+	uc := usecase.NewAuthUseCase(userRepo, repo.NewTOTPRepo(pg.Pool, pkgLogger.Discard()), jwtManager, usecase.NewMemoryLoginAttempts(5, time.Minute, time.Minute), notifications, pkgLogger.Discard(), time.Hour, mfa)
+	handler := NewAuthHandler(uc, pkgValidator.DefaultPasswordPolicy(), pkgLogger.Discard())
 
 	return handler, func() {
 		pg.Close()
@@ -114,7 +174,7 @@ func TestRegisterHandler(t *testing.T) {
 			name: "successful registration",
 			payload: dto.PostRegisterJSONBody{
 				Email:    "test@example.com",
-				Password: "password123",
+				Password: "pvz-Secret-42",
 				Role:     dto.Employee,
 			},
 			wantStatus: http.StatusCreated,
@@ -123,7 +183,7 @@ func TestRegisterHandler(t *testing.T) {
 			name: "invalid email format",
 			payload: dto.PostRegisterJSONBody{
 				Email:    "invalid-email",
-				Password: "password123",
+				Password: "pvz-Secret-42",
 				Role:     dto.Employee,
 			},
 			wantStatus:   http.StatusBadRequest,
@@ -143,7 +203,7 @@ func TestRegisterHandler(t *testing.T) {
 			name: "duplicate email",
 			payload: dto.PostRegisterJSONBody{
 				Email:    "duplicate@example.com",
-				Password: "password123",
+				Password: "pvz-Secret-42",
 				Role:     dto.Employee,
 			},
 			wantStatus:   http.StatusInternalServerError,
@@ -154,7 +214,7 @@ func TestRegisterHandler(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			if tt.name == "duplicate email" {
-				_, err := handler.uc.Register(context.Background(), "duplicate@example.com", "password123", string(entity.RoleEmployee))
+				_, err := handler.uc.Register(context.Background(), "duplicate@example.com", "pvz-Secret-42", string(entity.RoleEmployee))
 				require.NoError(t, err)
 			}
 
@@ -192,7 +252,7 @@ func TestLoginHandler(t *testing.T) {
 	router.POST("/login", handler.Login)
 
 	email := "test@example.com"
-	password := "password123"
+	password := "pvz-Secret-42"
 	_, err := handler.uc.Register(context.Background(), email, password, string(entity.RoleEmployee))
 	require.NoError(t, err)
 
@@ -223,7 +283,7 @@ func TestLoginHandler(t *testing.T) {
 			name: "invalid email format",
 			payload: dto.PostLoginJSONBody{
 				Email:    "invalid-email",
-				Password: "password123",
+				Password: "pvz-Secret-42",
 			},
 			wantStatus:   http.StatusBadRequest,
 			wantErrorMsg: pkgValidator.ErrInvalidEmail.Error(),
@@ -232,10 +292,10 @@ func TestLoginHandler(t *testing.T) {
 			name: "user not found",
 			payload: dto.PostLoginJSONBody{
 				Email:    "nonexistent@example.com",
-				Password: "password123",
+				Password: "pvz-Secret-42",
 			},
 			wantStatus:   http.StatusInternalServerError,
-			wantErrorMsg: "no rows in result set",
+			wantErrorMsg: pkgValidator.ErrInvalidCredentials.Error(),
 		},
 	}
 
@@ -331,3 +391,226 @@ func TestDummyLoginHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestPasswordHandlers(t *testing.T) {
+	handler, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	email := "reset@example.com"
+	user, err := handler.uc.Register(context.Background(), email, "pvz-Secret-42", string(entity.RoleEmployee))
+	require.NoError(t, err)
+
+	router := gin.Default()
+	router.POST("/login", handler.Login)
+	router.POST("/password/reset", handler.RequestPasswordReset)
+	router.POST("/password/reset/confirm", handler.ConfirmPasswordReset)
+	router.POST("/me/password", func(c *gin.Context) {
+		c.Set("user_id", user.ID.String())
+	}, handler.ChangePassword)
+
+	post := func(path string, payload interface{}) *httptest.ResponseRecorder {
+		bodyBytes, err := json.Marshal(payload)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(bodyBytes))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("change password", func(t *testing.T) {
+		w := post("/me/password", dto.PostMePasswordJSONBody{OldPassword: "wrong-password", NewPassword: "pvz-Secret-43"})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = post("/me/password", dto.PostMePasswordJSONBody{OldPassword: "pvz-Secret-42", NewPassword: "qwerty123"})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Contains(t, w.Body.String(), pkgValidator.ErrPasswordTooCommon.Error())
+
+		w = post("/me/password", dto.PostMePasswordJSONBody{OldPassword: "pvz-Secret-42", NewPassword: "pvz-Secret-43"})
+		require.Equal(t, http.StatusNoContent, w.Code)
+
+		w = post("/login", dto.PostLoginJSONBody{Email: email, Password: "pvz-Secret-43"})
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("reset password", func(t *testing.T) {
+		// Ответ для неизвестного email такой же, но уведомление не отправляется
+		sent := len(notifications.messages)
+		w := post("/password/reset", dto.PostPasswordResetJSONBody{Email: "nobody@example.com"})
+		require.Equal(t, http.StatusAccepted, w.Code)
+		require.Len(t, notifications.messages, sent)
+
+		w = post("/password/reset", dto.PostPasswordResetJSONBody{Email: email})
+		require.Equal(t, http.StatusAccepted, w.Code)
+		token := notifications.lastToken(t)
+
+		w = post("/password/reset/confirm", dto.PostPasswordResetConfirmJSONBody{Token: token, NewPassword: "pvz-Secret-44"})
+		require.Equal(t, http.StatusNoContent, w.Code)
+
+		// Токен одноразовый
+		w = post("/password/reset/confirm", dto.PostPasswordResetConfirmJSONBody{Token: token, NewPassword: "pvz-Secret-45"})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Contains(t, w.Body.String(), pkgValidator.ErrInvalidResetToken.Error())
+
+		w = post("/login", dto.PostLoginJSONBody{Email: email, Password: "pvz-Secret-44"})
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestMeHandler(t *testing.T) {
+	handler, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	user, err := handler.uc.Register(context.Background(), "me@example.com", "pvz-Secret-42", string(entity.RoleModerator))
+	require.NoError(t, err)
+	result, err := handler.uc.Login(context.Background(), "me@example.com", "pvz-Secret-42")
+	require.NoError(t, err)
+	token := result.Token
+	dummyToken, err := handler.uc.DummyLogin(context.Background(), string(entity.RoleEmployee), nil)
+	require.NoError(t, err)
+
+	router := gin.Default()
+	router.GET("/me", JWTMiddleware(handler.uc.GetJwtManager()), handler.Me)
+
+	get := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get(token)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp dto.Me
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, user.ID, resp.Id)
+	require.Equal(t, "me@example.com", resp.Email)
+	require.Equal(t, dto.UserRoleModerator, resp.Role)
+	require.NotNil(t, resp.PvzIds)
+	require.Empty(t, resp.PvzIds)
+	require.NotNil(t, resp.Regions)
+	require.Empty(t, resp.Regions)
+	require.WithinDuration(t, time.Now().Add(time.Hour), resp.TokenExpiresAt, time.Minute)
+
+	// Пользователя из /dummyLogin нет в базе
+	require.Equal(t, http.StatusNotFound, get(dummyToken).Code)
+	require.Equal(t, http.StatusUnauthorized, get("").Code)
+}
+
+func TestSetRegionsHandler(t *testing.T) {
+	handler, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	user, err := handler.uc.Register(context.Background(), "regions@example.com", "pvz-Secret-42", string(entity.RoleModerator))
+	require.NoError(t, err)
+
+	// Редактор — администратор без ограничения по городам
+	editorID, editorRegions := uuid.NewString(), []string{pvzEntity.AllCities}
+	router := gin.Default()
+	router.PUT("/users/:userId/regions", func(c *gin.Context) {
+		c.Set("user_id", editorID)
+		c.Set("user_regions", editorRegions)
+	}, handler.SetRegions)
+
+	put := func(userId string, body any) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPut, "/users/"+userId+"/regions", bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := put(user.ID.String(), dto.PutUsersUserIdRegionsJSONBody{Regions: []string{"Kazan"}})
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp dto.User
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, []string{"Kazan"}, *resp.Regions)
+
+	// Новый токен ограничен регионом
+	result, err := handler.uc.Login(context.Background(), "regions@example.com", "pvz-Secret-42")
+	require.NoError(t, err)
+	claims, err := handler.uc.GetJwtManager().VerifyToken(result.Token)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Kazan"}, claims.Regions)
+
+	require.Equal(t, http.StatusBadRequest, put(user.ID.String(), dto.PutUsersUserIdRegionsJSONBody{Regions: []string{"Berlin"}}).Code)
+	require.Equal(t, http.StatusBadRequest, put("not-a-uuid", dto.PutUsersUserIdRegionsJSONBody{}).Code)
+	require.Equal(t, http.StatusNotFound, put(uuid.New().String(), dto.PutUsersUserIdRegionsJSONBody{}).Code)
+
+	// Свои регионы менять нельзя, а ограниченный редактор не выходит за свои города
+	editorID = user.ID.String()
+	require.Equal(t, http.StatusForbidden, put(user.ID.String(), dto.PutUsersUserIdRegionsJSONBody{Regions: []string{}}).Code)
+	editorID, editorRegions = uuid.NewString(), []string{"Moscow"}
+	require.Equal(t, http.StatusForbidden, put(user.ID.String(), dto.PutUsersUserIdRegionsJSONBody{Regions: []string{"Moscow"}}).Code)
+}
+
+func TestTwoFactorHandlers(t *testing.T) {
+	handler, cleanup := setupTestHandlerWithMFA(t, usecase.MFAPolicy{
+		RequiredRoles: []entity.Role{entity.RoleModerator},
+		ChallengeTTL:  5 * time.Minute,
+		Issuer:        "GoPVZ",
+	})
+	defer cleanup()
+
+	_, err := handler.uc.Register(context.Background(), "2fa@example.com", "pvz-Secret-42", string(entity.RoleModerator))
+	require.NoError(t, err)
+
+	router := gin.Default()
+	router.POST("/login", handler.Login)
+	router.POST("/login/2fa", handler.CompleteLogin)
+	router.POST("/login/2fa/enroll", handler.EnrollTOTPOnLogin)
+
+	post := func(path string, body any) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	login := func() dto.MfaChallenge {
+		w := post("/login", dto.PostLoginJSONBody{Email: "2fa@example.com", Password: "pvz-Secret-42"})
+		require.Equal(t, http.StatusAccepted, w.Code)
+		var challenge dto.MfaChallenge
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &challenge))
+		return challenge
+	}
+
+	// Модератор без второго фактора сначала его настраивает
+	challenge := login()
+	require.True(t, challenge.EnrollmentRequired)
+
+	w := post("/login/2fa/enroll", dto.PostLogin2faEnrollJSONBody{ChallengeToken: challenge.ChallengeToken})
+	require.Equal(t, http.StatusOK, w.Code)
+	var enrollment dto.TotpEnrollment
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &enrollment))
+	require.True(t, strings.HasPrefix(enrollment.OtpauthUri, "otpauth://totp/"))
+
+	code, err := pkgTOTP.Code(enrollment.Secret, pkgTOTP.Step(time.Now()))
+	require.NoError(t, err)
+	w = post("/login/2fa", dto.PostLogin2faJSONBody{ChallengeToken: challenge.ChallengeToken, Code: code})
+	require.Equal(t, http.StatusOK, w.Code)
+	var completed dto.TwoFactorLoginResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &completed))
+	require.NotEmpty(t, completed.Token)
+	require.NotNil(t, completed.RecoveryCodes)
+
+	// Следующий вход требует код; повтор кода и промежуточный токен вместо access не принимаются
+	challenge = login()
+	require.False(t, challenge.EnrollmentRequired)
+	w = post("/login/2fa", dto.PostLogin2faJSONBody{ChallengeToken: challenge.ChallengeToken, Code: code})
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	w = post("/login/2fa", dto.PostLogin2faJSONBody{ChallengeToken: completed.Token, Code: (*completed.RecoveryCodes)[0]})
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = post("/login/2fa", dto.PostLogin2faJSONBody{ChallengeToken: challenge.ChallengeToken, Code: (*completed.RecoveryCodes)[0]})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = post("/login/2fa/enroll", dto.PostLogin2faEnrollJSONBody{ChallengeToken: challenge.ChallengeToken})
+	require.Equal(t, http.StatusConflict, w.Code)
+}
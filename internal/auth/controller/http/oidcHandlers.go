@@ -0,0 +1,114 @@
+package http
+
+import (
+	"GoPVZ/internal/auth/usecase"
+	"GoPVZ/internal/dto"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgOIDC"
+	"GoPVZ/pkg/pkgValidator"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// OIDCSessionCookie хранит подписанную сессию входа между /auth/oidc/login и
+	// /auth/oidc/callback
+	OIDCSessionCookie = "gopvz_oidc_login"
+	_oidcCookiePath   = "/auth/oidc"
+)
+
+type OIDCHandler struct {
+	uc  *usecase.OIDCUseCase
+	log pkgLogger.Interface
+}
+
+func NewOIDCHandler(uc *usecase.OIDCUseCase, log pkgLogger.Interface) *OIDCHandler {
+	return &OIDCHandler{uc: uc, log: log}
+}
+
+// Login godoc
+// @Summary Вход через корпоративный SSO
+// @Description Перенаправляет на страницу входа OIDC провайдера и выставляет cookie сессии входа. После входа провайдер вернет пользователя на /auth/oidc/callback
+// @Tags Domain auth
+// @Success 302 "Редирект на страницу входа провайдера"
+// @Failure 502 {object} dto.Error "Провайдер недоступен"
+// @Router /auth/oidc/login [get]
+func (h *OIDCHandler) Login(c *gin.Context) {
+	authURL, session, err := h.uc.Begin(c)
+	if err != nil {
+		h.log.ErrorContext(c, "OIDC provider is unavailable", pkgLogger.Err(err))
+		c.JSON(http.StatusBadGateway, dto.Error{Message: err.Error()})
+		return
+	}
+	setOIDCSession(c, session, int(h.uc.LoginTTL().Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// setOIDCSession выставляет cookie сессии входа; maxAge < 0 удаляет ее.
+// SameSite=Lax: провайдер возвращает браузер на callback обычным переходом.
+func setOIDCSession(c *gin.Context, session string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     OIDCSessionCookie,
+		Value:    session,
+		Path:     _oidcCookiePath,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// Callback godoc
+// @Summary Завершение входа через SSO
+// @Description Обменивает код провайдера на токен GoPVZ. Нужна cookie сессии входа, выставленная /auth/oidc/login в том же браузере. Пользователь создается при первом входе, роль определяется группами IdP. Если нужен второй фактор, как в /login возвращается промежуточный токен для /login/2fa
+// @Tags Domain auth
+// @Produce json
+// @Param code query string true "Код авторизации"
+// @Param state query string true "State из /auth/oidc/login"
+// @Success 200 {object} dto.TokenResponse "Токен GoPVZ"
+// @Success 202 {object} dto.MfaChallenge "Нужен второй фактор"
+// @Failure 400 {object} dto.Error "Сессия входа не найдена, истекла или начата в другом браузере"
+// @Failure 401 {object} dto.Error "Провайдер отклонил вход"
+// @Failure 403 {object} dto.Error "Группам пользователя не сопоставлена роль"
+// @Failure 409 {object} dto.Error "Email занят аккаунтом с паролем"
+// @Failure 500 {object} dto.Error "Внутренняя ошибка сервера"
+// @Router /auth/oidc/callback [get]
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	// Сессия одноразовая: cookie удаляется при любом исходе
+	session, _ := c.Cookie(OIDCSessionCookie)
+	setOIDCSession(c, "", -1)
+
+	// Провайдер сообщает об отказе параметром error вместо кода
+	if providerErr := c.Query("error"); providerErr != "" {
+		c.JSON(http.StatusUnauthorized, dto.Error{Message: providerErr})
+		return
+	}
+
+	state, code := c.Query("state"), c.Query("code")
+	if state == "" || code == "" {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: pkgValidator.ErrInvalidInput.Error()})
+		return
+	}
+
+	result, err := h.uc.Callback(c, session, state, code)
+	switch {
+	case err == nil:
+		respondLogin(c, result)
+	case errors.Is(err, pkgValidator.ErrInvalidOIDCState):
+		c.JSON(http.StatusBadRequest, dto.Error{Message: err.Error()})
+	case errors.Is(err, pkgOIDC.ErrExchange),
+		errors.Is(err, pkgOIDC.ErrInvalidIDToken):
+		h.log.WarnContext(c, "OIDC provider rejected login", pkgLogger.Err(err))
+		c.JSON(http.StatusUnauthorized, dto.Error{Message: err.Error()})
+	case errors.Is(err, pkgValidator.ErrOIDCEmailNotVerified):
+		c.JSON(http.StatusUnauthorized, dto.Error{Message: err.Error()})
+	case errors.Is(err, pkgValidator.ErrOIDCNoRole):
+		c.JSON(http.StatusForbidden, dto.Error{Message: err.Error()})
+	case errors.Is(err, pkgValidator.ErrOIDCAccountExists):
+		c.JSON(http.StatusConflict, dto.Error{Message: err.Error()})
+	default:
+		respondInternalError(c, h.log, err)
+	}
+}
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"GoPVZ/internal/auth/entity"
+	"GoPVZ/internal/auth/usecase"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgOIDC"
+	"GoPVZ/pkg/pkgOIDC/oidctest"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCHandler_SessionCookie(t *testing.T) {
+	idp := oidctest.NewServer("gopvz", "client-secret")
+	defer idp.Close()
+
+	provider := pkgOIDC.New(idp.Config("http://localhost/auth/oidc/callback"))
+	uc := usecase.NewOIDCUseCase(provider, nil, nil, pkgLogger.Discard(),
+		"groups", nil, entity.RoleEmployee, time.Minute, false, "state-secret")
+
+	router := gin.New()
+	NewOIDCRouter(router.Group("/"), uc, pkgLogger.Discard())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil))
+	require.Equal(t, http.StatusFound, w.Code)
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	cookie := cookies[0]
+	require.Equal(t, OIDCSessionCookie, cookie.Name)
+	require.True(t, cookie.HttpOnly)
+	require.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+	require.Equal(t, "/auth/oidc", cookie.Path)
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	state := location.Query().Get("state")
+
+	// Callback в браузере без cookie сессии не завершает вход, а cookie удаляется
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/callback?code=code&state="+state, nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Len(t, w.Result().Cookies(), 1)
+	require.Less(t, w.Result().Cookies()[0].MaxAge, 0)
+}
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"GoPVZ/internal/dto"
+	"GoPVZ/pkg/pkgRateLimit"
+	"GoPVZ/pkg/pkgValidator"
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// _maxKeyBodySize ограничивает тело, которое читается ради ключа лимита.
+const _maxKeyBodySize = 64 << 10
+
+// RateLimitKey извлекает ключ лимита из запроса; пустой ключ — запрос не ограничивается.
+type RateLimitKey func(c *gin.Context) string
+
+// ByClientIP -.
+func ByClientIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ByJSONField берет ключ из строкового поля JSON тела. Тело восстанавливается,
+// чтобы его мог прочитать обработчик.
+func ByJSONField(field string) RateLimitKey {
+	return func(c *gin.Context) string {
+		if c.Request.Body == nil {
+			return ""
+		}
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, _maxKeyBodySize))
+		if err != nil {
+			return ""
+		}
+		c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+
+		var payload map[string]any
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return ""
+		}
+		value, _ := payload[field].(string)
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			return ""
+		}
+		return field + ":" + value
+	}
+}
+
+// RateLimitMiddleware отвечает 429 с Retry-After, когда корзина ключа пуста.
+// Ошибка хранилища не блокирует запрос.
+func RateLimitMiddleware(limiter pkgRateLimit.Limiter, key RateLimitKey) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k := key(c)
+		if k == "" {
+			c.Next()
+			return
+		}
+
+		allowed, retryAfter, err := limiter.Allow(c, c.FullPath()+"|"+k)
+		if err != nil || allowed {
+			c.Next()
+			return
+		}
+
+		setRetryAfter(c, retryAfter)
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, dto.Error{Message: pkgValidator.ErrTooManyRequests.Error()})
+	}
+}
+
+// setRetryAfter выставляет Retry-After в целых секундах с округлением вверх.
+func setRetryAfter(c *gin.Context, d time.Duration) {
+	seconds := int(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+}
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"GoPVZ/pkg/pkgRateLimit"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	limited := router.Group("/",
+		RateLimitMiddleware(pkgRateLimit.NewMemory(0, 3), ByClientIP),
+		RateLimitMiddleware(pkgRateLimit.NewMemory(0, 2), ByJSONField("email")),
+	)
+	limited.POST("/login", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	})
+
+	login := func(ip, email string) *httptest.ResponseRecorder {
+		body := `{"email":"` + email + `","password":"password123"}`
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Обработчик получает тело целиком после чтения ключа
+	w := login("10.0.0.1", "a@example.com")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"email":"a@example.com"`)
+
+	// Лимит по email не зависит от IP и регистра
+	require.Equal(t, http.StatusOK, login("10.0.0.2", "A@example.com").Code)
+	w = login("10.0.0.3", "a@example.com")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	// Лимит по IP не зависит от email
+	require.Equal(t, http.StatusOK, login("10.0.0.1", "b@example.com").Code)
+	require.Equal(t, http.StatusOK, login("10.0.0.1", "c@example.com").Code)
+	require.Equal(t, http.StatusTooManyRequests, login("10.0.0.1", "d@example.com").Code)
+}
@@ -2,13 +2,70 @@ package http
 
 import (
 	"github.com/gin-gonic/gin"
+	"GoPVZ/internal/auth/entity"
 	"GoPVZ/internal/auth/usecase"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgValidator"
 )
 
-func NewAuthRouter(router *gin.RouterGroup, uc *usecase.AuthUseCase) {
-	handler := NewAuthHandler(uc)
+// NewAuthRouter регистрирует маршруты авторизации. loginLimits применяются к входу
+// и сбросу пароля; /dummyLogin регистрируется только при dummyLoginEnabled.
+func NewAuthRouter(
+	router *gin.RouterGroup,
+	uc *usecase.AuthUseCase,
+	policy *pkgValidator.PasswordPolicy,
+	log pkgLogger.Interface,
+	authMiddleware gin.HandlerFunc,
+	require PermissionMiddleware,
+	dummyLoginEnabled bool,
+	loginLimits ...gin.HandlerFunc,
+) {
+	handler := NewAuthHandler(uc, policy, log)
 
 	router.POST("/register", handler.Register)
-	router.POST("/login", handler.Login)
-	router.POST("/dummyLogin", handler.DummyLogin)
-}
\ No newline at end of file
+
+	limited := router.Group("/", loginLimits...)
+	limited.POST("/login", handler.Login)
+	limited.POST("/login/2fa", handler.CompleteLogin)
+	limited.POST("/login/2fa/enroll", handler.EnrollTOTPOnLogin)
+	limited.POST("/password/reset", handler.RequestPasswordReset)
+	limited.POST("/password/reset/confirm", handler.ConfirmPasswordReset)
+	if dummyLoginEnabled {
+		limited.POST("/dummyLogin", handler.DummyLogin)
+	}
+
+	protected := router.Group("/", authMiddleware)
+	protected.GET("/me", handler.Me)
+	protected.POST("/me/password", handler.ChangePassword)
+	protected.POST("/me/2fa", handler.EnrollTOTP)
+	protected.POST("/me/2fa/confirm", handler.ConfirmTOTP)
+	protected.PUT("/users/:userId/regions", require(entity.PermissionUserManage), handler.SetRegions)
+}
+
+// NewAPIKeyRouter регистрирует управление ключами сервисных аккаунтов.
+func NewAPIKeyRouter(
+	router *gin.RouterGroup,
+	uc *usecase.APIKeyUseCase,
+	log pkgLogger.Interface,
+	authMiddleware gin.HandlerFunc,
+	canManage gin.HandlerFunc,
+) {
+	handler := NewAPIKeyHandler(uc, log)
+
+	// Routes for users with api_key:manage
+	manageRoutes := router.Group("/api-keys")
+	manageRoutes.Use(authMiddleware, canManage)
+	manageRoutes.POST("", handler.CreateAPIKey)
+	manageRoutes.GET("", handler.ListAPIKeys)
+	manageRoutes.DELETE("/:keyId", handler.RevokeAPIKey)
+}
+
+// NewOIDCRouter регистрирует вход через внешний OIDC провайдер. loginLimits те же,
+// что у /login.
+func NewOIDCRouter(router *gin.RouterGroup, uc *usecase.OIDCUseCase, log pkgLogger.Interface, loginLimits ...gin.HandlerFunc) {
+	handler := NewOIDCHandler(uc, log)
+
+	oidc := router.Group("/auth/oidc", loginLimits...)
+	oidc.GET("/login", handler.Login)
+	oidc.GET("/callback", handler.Callback)
+}
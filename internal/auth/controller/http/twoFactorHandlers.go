@@ -0,0 +1,159 @@
+package http
+
+import (
+	"GoPVZ/internal/auth/entity"
+	"GoPVZ/internal/auth/usecase"
+	"GoPVZ/internal/auth/validation"
+	"GoPVZ/internal/dto"
+	"GoPVZ/pkg/pkgValidator"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CompleteLogin godoc
+// @Summary      Второй шаг входа
+// @Description  Завершает вход кодом из приложения-аутентификатора или кодом восстановления. Если второй фактор настраивался при этом входе, код его включает, и в ответе приходят коды восстановления
+// @Tags         Domain auth
+// @Accept       json
+// @Produce      json
+// @Param        input  body      dto.PostLogin2faJSONBody  true  "Промежуточный токен и код"
+// @Success      200    {object}  dto.TwoFactorLoginResponse
+// @Failure      400    {object}  dto.Error "Второй фактор не настроен"
+// @Failure      401    {object}  dto.Error "Неверный код или промежуточный токен истек"
+// @Failure      429    {object}  dto.Error "Слишком много попыток или аккаунт временно заблокирован"
+// @Failure      500    {object}  dto.Error
+// @Router       /login/2fa [post]
+func (h *AuthHandler) CompleteLogin(c *gin.Context) {
+	var req dto.PostLogin2faJSONBody
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: pkgValidator.ErrInvalidInput.Error()})
+		return
+	}
+
+	validator := validation.NewTwoFactorLoginValidator(req)
+	if err := validator.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: err.Error()})
+		return
+	}
+
+	result, err := h.uc.CompleteLogin(c, req.ChallengeToken, req.Code)
+	var locked *usecase.LockedError
+	switch {
+	case errors.As(err, &locked):
+		setRetryAfter(c, locked.RetryAfter)
+		c.JSON(http.StatusTooManyRequests, dto.Error{Message: err.Error()})
+		return
+	case errors.Is(err, pkgValidator.ErrInvalidMFAChallenge), errors.Is(err, pkgValidator.ErrInvalidTOTPCode):
+		c.JSON(http.StatusUnauthorized, dto.Error{Message: err.Error()})
+		return
+	case errors.Is(err, pkgValidator.ErrTOTPNotEnrolled):
+		c.JSON(http.StatusBadRequest, dto.Error{Message: err.Error()})
+		return
+	case err != nil:
+		respondInternalError(c, h.log, err)
+		return
+	}
+
+	response := dto.TwoFactorLoginResponse{Token: result.Token}
+	if len(result.RecoveryCodes) > 0 {
+		response.RecoveryCodes = &result.RecoveryCodes
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// EnrollTOTPOnLogin godoc
+// @Summary      Настройка второго фактора при входе
+// @Description  Для пользователя, которому второй фактор обязателен, но еще не настроен. Выдает секрет для приложения-аутентификатора; вход завершается кодом из него на /login/2fa
+// @Tags         Domain auth
+// @Accept       json
+// @Produce      json
+// @Param        input  body      dto.PostLogin2faEnrollJSONBody  true  "Промежуточный токен"
+// @Success      200    {object}  dto.TotpEnrollment
+// @Failure      400    {object}  dto.Error
+// @Failure      401    {object}  dto.Error "Промежуточный токен истек"
+// @Failure      409    {object}  dto.Error "Второй фактор уже включен"
+// @Failure      500    {object}  dto.Error
+// @Router       /login/2fa/enroll [post]
+func (h *AuthHandler) EnrollTOTPOnLogin(c *gin.Context) {
+	var req dto.PostLogin2faEnrollJSONBody
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: pkgValidator.ErrInvalidInput.Error()})
+		return
+	}
+
+	enrollment, err := h.uc.EnrollTOTPWithChallenge(c, req.ChallengeToken)
+	h.respondEnrollment(c, enrollment, err)
+}
+
+// EnrollTOTP godoc
+// @Summary      Настройка второго фактора
+// @Description  Выдает новый секрет для приложения-аутентификатора. Второй фактор включается после подтверждения кодом на /me/2fa/confirm
+// @Tags         Domain auth
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200    {object}  dto.TotpEnrollment
+// @Failure      401    {object}  dto.Error
+// @Failure      404    {object}  dto.Error "Пользователь не найден"
+// @Failure      409    {object}  dto.Error "Второй фактор уже включен"
+// @Failure      500    {object}  dto.Error
+// @Router       /me/2fa [post]
+func (h *AuthHandler) EnrollTOTP(c *gin.Context) {
+	enrollment, err := h.uc.EnrollTOTP(c, c.GetString("user_id"))
+	h.respondEnrollment(c, enrollment, err)
+}
+
+// ConfirmTOTP godoc
+// @Summary      Включение второго фактора
+// @Description  Включает второй фактор по коду из приложения и возвращает коды восстановления. Коды показываются один раз
+// @Tags         Domain auth
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        input  body      dto.PostMe2faConfirmJSONBody  true  "Код из приложения"
+// @Success      200    {object}  dto.RecoveryCodesResponse
+// @Failure      400    {object}  dto.Error "Неверный код или второй фактор не настроен"
+// @Failure      401    {object}  dto.Error
+// @Failure      409    {object}  dto.Error "Второй фактор уже включен"
+// @Failure      500    {object}  dto.Error
+// @Router       /me/2fa/confirm [post]
+func (h *AuthHandler) ConfirmTOTP(c *gin.Context) {
+	var req dto.PostMe2faConfirmJSONBody
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: pkgValidator.ErrInvalidInput.Error()})
+		return
+	}
+
+	codes, err := h.uc.ConfirmTOTP(c, c.GetString("user_id"), req.Code)
+	switch {
+	case errors.Is(err, pkgValidator.ErrInvalidTOTPCode), errors.Is(err, pkgValidator.ErrTOTPNotEnrolled):
+		c.JSON(http.StatusBadRequest, dto.Error{Message: err.Error()})
+		return
+	case errors.Is(err, pkgValidator.ErrTOTPAlreadyEnabled):
+		c.JSON(http.StatusConflict, dto.Error{Message: err.Error()})
+		return
+	case err != nil:
+		respondInternalError(c, h.log, err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *AuthHandler) respondEnrollment(c *gin.Context, enrollment *entity.TOTPEnrollment, err error) {
+	switch {
+	case errors.Is(err, pkgValidator.ErrInvalidMFAChallenge):
+		c.JSON(http.StatusUnauthorized, dto.Error{Message: err.Error()})
+		return
+	case errors.Is(err, pkgValidator.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, dto.Error{Message: err.Error()})
+		return
+	case errors.Is(err, pkgValidator.ErrTOTPAlreadyEnabled):
+		c.JSON(http.StatusConflict, dto.Error{Message: err.Error()})
+		return
+	case err != nil:
+		respondInternalError(c, h.log, err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.TotpEnrollment{Secret: enrollment.Secret, OtpauthUri: enrollment.URI})
+}
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey — ключ сервисного аккаунта для интеграций. В базе хранится только
+// хеш ключа; сам ключ возвращается один раз при создании. Regions — города
+// создателя на момент выпуска: ключ ограничен ими так же, как создатель.
+type APIKey struct {
+	ID          uuid.UUID
+	Name        string
+	Prefix      string
+	KeyHash     string
+	Permissions []Permission
+	Regions     []string
+	CreatedBy   *uuid.UUID
+	ExpiresAt   *time.Time
+	LastUsedAt  *time.Time
+	RevokedAt   *time.Time
+	CreatedAt   time.Time
+}
+
+// Active — ключ не отозван и не истек.
+func (k *APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
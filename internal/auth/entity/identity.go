@@ -0,0 +1,10 @@
+package entity
+
+// ExternalIdentity — пользователь внешнего OIDC провайдера. Аккаунт GoPVZ
+// связывается с ним по паре Issuer и Subject: email у провайдера может
+// смениться или совпасть с чужим локальным аккаунтом.
+type ExternalIdentity struct {
+	Issuer  string
+	Subject string
+	Email   string
+}
@@ -0,0 +1,148 @@
+package entity
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Permission — право на действие. Маршруты проверяют разрешения, а не роли,
+// поэтому новая роль настраивается без изменений в роутерах.
+type Permission string
+
+const (
+	PermissionPVZRead         Permission = "pvz:read"
+	PermissionPVZCreate       Permission = "pvz:create"
+	PermissionPVZImport       Permission = "pvz:import"
+	PermissionReceptionCreate Permission = "reception:create"
+	PermissionReceptionClose  Permission = "reception:close"
+	PermissionProductCreate   Permission = "product:create"
+	PermissionProductDelete   Permission = "product:delete"
+	PermissionExportRead      Permission = "export:read"
+	PermissionWebhookManage   Permission = "webhook:manage"
+	PermissionUserManage      Permission = "user:manage"
+	PermissionAPIKeyManage    Permission = "api_key:manage"
+	// PermissionSystemManage — служебные операции, например смена уровня логов
+	PermissionSystemManage Permission = "system:manage"
+)
+
+// AllPermissions -.
+var AllPermissions = []Permission{
+	PermissionPVZRead,
+	PermissionPVZCreate,
+	PermissionPVZImport,
+	PermissionReceptionCreate,
+	PermissionReceptionClose,
+	PermissionProductCreate,
+	PermissionProductDelete,
+	PermissionExportRead,
+	PermissionWebhookManage,
+	PermissionUserManage,
+	PermissionAPIKeyManage,
+	PermissionSystemManage,
+}
+
+func (p Permission) IsValid() bool {
+	for _, known := range AllPermissions {
+		if p == known {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultRolePermissions — разрешения ролей, если в конфигурации не задано иное.
+func DefaultRolePermissions() map[Role][]Permission {
+	return map[Role][]Permission{
+		RoleEmployee: {
+			PermissionPVZRead,
+			PermissionReceptionCreate,
+			PermissionReceptionClose,
+			PermissionProductCreate,
+			PermissionProductDelete,
+		},
+		RoleModerator: {
+			PermissionPVZRead,
+			PermissionPVZCreate,
+			PermissionPVZImport,
+			PermissionExportRead,
+		},
+		RoleRegionalManager: {
+			PermissionPVZRead,
+			PermissionPVZCreate,
+			PermissionExportRead,
+		},
+		RoleAuditor: {
+			PermissionPVZRead,
+			PermissionExportRead,
+		},
+		RoleService: {
+			PermissionPVZRead,
+			PermissionPVZImport,
+			PermissionReceptionCreate,
+			PermissionReceptionClose,
+			PermissionProductCreate,
+			PermissionProductDelete,
+		},
+		// Роль модератора выбирается при регистрации, поэтому права на
+		// управление есть только у администратора
+		RoleAdmin: {
+			PermissionPVZRead,
+			PermissionPVZCreate,
+			PermissionPVZImport,
+			PermissionExportRead,
+			PermissionWebhookManage,
+			PermissionUserManage,
+			PermissionAPIKeyManage,
+			PermissionSystemManage,
+		},
+	}
+}
+
+// PermissionPolicy отвечает, есть ли у роли разрешение.
+type PermissionPolicy struct {
+	roles map[Role]map[Permission]struct{}
+}
+
+// NewPermissionPolicy проверяет, что все роли и разрешения известны. Роль без
+// записи в rolePermissions не получает никаких разрешений.
+func NewPermissionPolicy(rolePermissions map[Role][]Permission) (*PermissionPolicy, error) {
+	policy := &PermissionPolicy{roles: make(map[Role]map[Permission]struct{}, len(rolePermissions))}
+
+	for role, permissions := range rolePermissions {
+		if !role.IsValid() {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRole, role)
+		}
+
+		set := make(map[Permission]struct{}, len(permissions))
+		for _, permission := range permissions {
+			if !permission.IsValid() {
+				return nil, fmt.Errorf("unknown permission %q for role %q", permission, role)
+			}
+			set[permission] = struct{}{}
+		}
+		policy.roles[role] = set
+	}
+
+	return policy, nil
+}
+
+// ReadPermissionPolicy читает JSON вида {"auditor": ["pvz:read", "export:read"]}.
+// Роли из файла заменяют значения по умолчанию, остальные роли остаются как есть.
+func ReadPermissionPolicy(r io.Reader) (*PermissionPolicy, error) {
+	var overrides map[Role][]Permission
+	if err := json.NewDecoder(r).Decode(&overrides); err != nil {
+		return nil, err
+	}
+
+	rolePermissions := DefaultRolePermissions()
+	for role, permissions := range overrides {
+		rolePermissions[role] = permissions
+	}
+	return NewPermissionPolicy(rolePermissions)
+}
+
+func (p *PermissionPolicy) Allows(role Role, permission Permission) bool {
+	_, ok := p.roles[role][permission]
+	return ok
+}
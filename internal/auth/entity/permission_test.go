@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultRolePermissions(t *testing.T) {
+	policy, err := NewPermissionPolicy(DefaultRolePermissions())
+	require.NoError(t, err)
+
+	require.True(t, policy.Allows(RoleEmployee, PermissionReceptionClose))
+	require.False(t, policy.Allows(RoleEmployee, PermissionPVZCreate))
+	require.True(t, policy.Allows(RoleModerator, PermissionPVZCreate))
+	require.False(t, policy.Allows(RoleModerator, PermissionReceptionCreate))
+
+	// Модератор выбирается при регистрации и не получает прав на управление
+	for _, p := range []Permission{PermissionUserManage, PermissionAPIKeyManage, PermissionWebhookManage, PermissionSystemManage} {
+		require.False(t, policy.Allows(RoleModerator, p))
+		require.True(t, policy.Allows(RoleAdmin, p))
+	}
+	require.False(t, RoleAdmin.IsSelfAssignable())
+
+	// Аудитор только читает
+	require.True(t, policy.Allows(RoleAuditor, PermissionPVZRead))
+	require.True(t, policy.Allows(RoleAuditor, PermissionExportRead))
+	require.False(t, policy.Allows(RoleAuditor, PermissionPVZCreate))
+
+	require.False(t, policy.Allows(Role("unknown"), PermissionPVZRead))
+}
+
+func TestReadPermissionPolicy(t *testing.T) {
+	policy, err := ReadPermissionPolicy(strings.NewReader(`{"auditor": ["pvz:read"]}`))
+	require.NoError(t, err)
+
+	// Роль из файла заменяется целиком, остальные остаются по умолчанию
+	require.True(t, policy.Allows(RoleAuditor, PermissionPVZRead))
+	require.False(t, policy.Allows(RoleAuditor, PermissionExportRead))
+	require.True(t, policy.Allows(RoleModerator, PermissionExportRead))
+
+	_, err = ReadPermissionPolicy(strings.NewReader(`{"auditor": ["pvz:delete"]}`))
+	require.ErrorContains(t, err, "unknown permission")
+
+	_, err = ReadPermissionPolicy(strings.NewReader(`{"root": ["pvz:read"]}`))
+	require.ErrorIs(t, err, ErrInvalidRole)
+}
@@ -0,0 +1,14 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Profile — текущий пользователь вместе с закрепленными за ним ПВЗ.
+type Profile struct {
+	User           *User
+	PvzIDs         []uuid.UUID
+	TokenExpiresAt time.Time
+}
@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TOTP — второй фактор пользователя. Пока ConfirmedAt пуст, секрет выдан, но
+// не подтвержден кодом и при входе не требуется.
+type TOTP struct {
+	UserID      uuid.UUID
+	Secret      string
+	ConfirmedAt *time.Time
+	// LastStep — шаг последнего принятого кода
+	LastStep  int64
+	CreatedAt time.Time
+}
+
+// Enabled — второй фактор подтвержден и проверяется при входе.
+func (t *TOTP) Enabled() bool {
+	return t != nil && t.ConfirmedAt != nil
+}
+
+// TOTPEnrollment — данные для добавления аккаунта в приложение-аутентификатор.
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+}
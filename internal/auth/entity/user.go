@@ -11,8 +11,16 @@ import (
 type Role string
 
 const (
-	RoleEmployee  Role = "employee"
-	RoleModerator Role = "moderator"
+	RoleEmployee        Role = "employee"
+	RoleModerator       Role = "moderator"
+	RoleRegionalManager Role = "regional_manager"
+	// RoleAuditor — доступ только на чтение
+	RoleAuditor Role = "auditor"
+	// RoleService — сервисный аккаунт для интеграций
+	RoleService Role = "service"
+	// RoleAdmin — управление пользователями, ключами, вебхуками и сервисом.
+	// Самостоятельно не выбирается
+	RoleAdmin Role = "admin"
 )
 
 type User struct {
@@ -20,6 +28,8 @@ type User struct {
 	Email        string    `json:"email" db:"email"         example:"user@example.com"`
 	PasswordHash string    `json:"-"     db:"password_hash" example:"strongpassword123"`
 	Role         Role      `json:"role"  db:"role"          example:"employee"`
+	// Regions — города, доступные пользователю; "*" — все города, пусто — ни одного
+	Regions      []string  `json:"regions" db:"regions"     example:"Moscow"`
 }
 
 var (
@@ -30,7 +40,7 @@ var (
 
 func (r Role) IsValid() bool {
 	switch r {
-	case RoleEmployee, RoleModerator:
+	case RoleEmployee, RoleModerator, RoleRegionalManager, RoleAuditor, RoleService, RoleAdmin:
 		return true
 	default:
 		return false
@@ -38,6 +48,12 @@ func (r Role) IsValid() bool {
 }
 
 
+// IsSelfAssignable сообщает, может ли пользователь выбрать роль сам при
+// регистрации или в /dummyLogin. Остальные роли выдаются администратором.
+func (r Role) IsSelfAssignable() bool {
+	return r == RoleEmployee || r == RoleModerator
+}
+
 func (r Role) Validate() error {
 	if !r.IsValid() {
 		return ErrInvalidRole
@@ -0,0 +1,28 @@
+package notifier
+
+import (
+	"GoPVZ/pkg/pkgLogger"
+	"context"
+	"log/slog"
+)
+
+// LogNotifier пишет сообщения в лог вместо отправки. Сообщение целиком, вместе
+// с токенами сброса, попадает в лог, поэтому он годится только для разработки.
+type LogNotifier struct {
+	log pkgLogger.Interface
+}
+
+var _ Notifier = (*LogNotifier)(nil)
+
+func NewLogNotifier(log pkgLogger.Interface) *LogNotifier {
+	return &LogNotifier{log: log}
+}
+
+func (n *LogNotifier) Notify(_ context.Context, msg Message) error {
+	n.log.Info("Notification",
+		slog.String("to", msg.To),
+		slog.String("subject", msg.Subject),
+		slog.String("body", msg.Body),
+	)
+	return nil
+}
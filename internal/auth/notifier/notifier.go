@@ -0,0 +1,16 @@
+// Package notifier содержит способы доставки уведомлений пользователям.
+package notifier
+
+import "context"
+
+// Message -.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier доставляет сообщение пользователю.
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
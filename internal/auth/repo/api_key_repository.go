@@ -0,0 +1,108 @@
+package repo
+
+import (
+    "context"
+    "errors"
+    "time"
+    "GoPVZ/internal/auth/entity"
+    "GoPVZ/pkg/pkgLogger"
+    "GoPVZ/pkg/pkgValidator"
+    "log/slog"
+
+    "github.com/google/uuid"
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type apiKeyRepo struct {
+    db  *pgxpool.Pool
+    log pkgLogger.Interface
+}
+
+func NewAPIKeyRepo(db *pgxpool.Pool, log pkgLogger.Interface) APIKeyRepository {
+    return &apiKeyRepo{db: db, log: log}
+}
+
+const apiKeyColumns = `id, name, prefix, key_hash, permissions, regions, created_by, expires_at, last_used_at, revoked_at, created_at`
+
+func scanAPIKey(row pgx.Row) (*entity.APIKey, error) {
+    var (
+        k           entity.APIKey
+        permissions []string
+    )
+    err := row.Scan(&k.ID, &k.Name, &k.Prefix, &k.KeyHash, &permissions, &k.Regions, &k.CreatedBy,
+        &k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &k.CreatedAt)
+    if err != nil {
+        return nil, err
+    }
+    for _, p := range permissions {
+        k.Permissions = append(k.Permissions, entity.Permission(p))
+    }
+    return &k, nil
+}
+
+func (r *apiKeyRepo) Create(ctx context.Context, key *entity.APIKey) error {
+    permissions := make([]string, 0, len(key.Permissions))
+    for _, p := range key.Permissions {
+        permissions = append(permissions, string(p))
+    }
+    _, err := r.db.Exec(ctx,
+        `INSERT INTO api_keys (id, name, prefix, key_hash, permissions, regions, created_by, expires_at, created_at) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)`,
+        key.ID, key.Name, key.Prefix, key.KeyHash, permissions, regionsOrEmpty(key.Regions), key.CreatedBy, key.ExpiresAt, key.CreatedAt,
+    )
+    return err
+}
+
+func (r *apiKeyRepo) GetByHash(ctx context.Context, keyHash string) (*entity.APIKey, error) {
+    key, err := scanAPIKey(r.db.QueryRow(ctx,
+        `SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash=$1`, keyHash,
+    ))
+    if errors.Is(err, pgx.ErrNoRows) {
+        return nil, pkgValidator.ErrAPIKeyNotFound
+    }
+    return key, err
+}
+
+func (r *apiKeyRepo) List(ctx context.Context, createdBy *uuid.UUID) ([]*entity.APIKey, error) {
+    rows, err := r.db.Query(ctx,
+        `SELECT `+apiKeyColumns+` FROM api_keys WHERE ($1::uuid IS NULL OR created_by=$1) ORDER BY created_at DESC`,
+        createdBy,
+    )
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    keys := []*entity.APIKey{}
+    for rows.Next() {
+        key, err := scanAPIKey(rows)
+        if err != nil {
+            return nil, err
+        }
+        keys = append(keys, key)
+    }
+    return keys, rows.Err()
+}
+
+func (r *apiKeyRepo) Revoke(ctx context.Context, id uuid.UUID, createdBy *uuid.UUID, at time.Time) error {
+    tag, err := r.db.Exec(ctx,
+        `UPDATE api_keys SET revoked_at=$2 WHERE id=$1 AND revoked_at IS NULL AND ($3::uuid IS NULL OR created_by=$3)`,
+        id, at, createdBy,
+    )
+    if err != nil {
+        return err
+    }
+    if tag.RowsAffected() == 0 {
+        r.log.DebugContext(ctx, "API key not found or already revoked", slog.String("api_key_id", id.String()))
+        return pkgValidator.ErrAPIKeyNotFound
+    }
+    return nil
+}
+
+func (r *apiKeyRepo) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time, minInterval time.Duration) error {
+    _, err := r.db.Exec(ctx,
+        `UPDATE api_keys SET last_used_at=$2 WHERE id=$1 AND (last_used_at IS NULL OR last_used_at < $3)`,
+        id, at, at.Add(-minInterval),
+    )
+    return err
+}
@@ -2,22 +2,30 @@ package repo
 
 import (
     "context"
+    "errors"
+    "log/slog"
     "GoPVZ/internal/auth/entity"
+    "GoPVZ/pkg/pkgLogger"
+    "GoPVZ/pkg/pkgValidator"
+
+    "github.com/google/uuid"
+    "github.com/jackc/pgx/v5"
     "github.com/jackc/pgx/v5/pgxpool"
 )
 
 type userRepo struct {
-    db *pgxpool.Pool
+    db  *pgxpool.Pool
+    log pkgLogger.Interface
 }
 
-func NewUserRepo(db *pgxpool.Pool) UserRepository {
-    return &userRepo{db: db}
+func NewUserRepo(db *pgxpool.Pool, log pkgLogger.Interface) UserRepository {
+    return &userRepo{db: db, log: log}
 }
 
 func (r *userRepo) Create(ctx context.Context, user *entity.User) error {
     _, err := r.db.Exec(ctx,
-        `INSERT INTO users (id, email, password_hash, role) VALUES ($1,$2,$3,$4)`,
-        user.ID, user.Email, user.PasswordHash, user.Role,
+        `INSERT INTO users (id, email, password_hash, role, regions) VALUES ($1,$2,$3,$4,$5)`,
+        user.ID, user.Email, user.PasswordHash, user.Role, regionsOrEmpty(user.Regions),
     )
     return err
 }
@@ -25,10 +33,166 @@ func (r *userRepo) Create(ctx context.Context, user *entity.User) error {
 func (r *userRepo) GetByEmail(ctx context.Context, email string) (*entity.User, error) {
     var u entity.User
     err := r.db.QueryRow(ctx,
-        `SELECT id, email, password_hash, role FROM users WHERE email=$1`, email,
-    ).Scan(&u.ID, &u.Email, &u.PasswordHash, &u.Role)
+        `SELECT id, email, password_hash, role, regions FROM users WHERE email=$1`, email,
+    ).Scan(&u.ID, &u.Email, &u.PasswordHash, &u.Role, &u.Regions)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return nil, pkgValidator.ErrUserNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &u, nil
+}
+
+func (r *userRepo) GetById(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+    var u entity.User
+    err := r.db.QueryRow(ctx,
+        `SELECT id, email, password_hash, role, regions FROM users WHERE id=$1`, id,
+    ).Scan(&u.ID, &u.Email, &u.PasswordHash, &u.Role, &u.Regions)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return nil, pkgValidator.ErrUserNotFound
+    }
     if err != nil {
         return nil, err
     }
     return &u, nil
-}
\ No newline at end of file
+}
+
+func (r *userRepo) GetPVZAssignments(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+    rows, err := r.db.Query(ctx,
+        `SELECT pvz_id FROM user_pvz_assignments WHERE user_id=$1 ORDER BY assigned_at, pvz_id`, userID,
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    pvzIDs, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+    if err != nil {
+        return nil, err
+    }
+    return pvzIDs, nil
+}
+
+func (r *userRepo) UpdatePasswordHash(ctx context.Context, id uuid.UUID, passwordHash string) error {
+    tag, err := r.db.Exec(ctx,
+        `UPDATE users SET password_hash=$2 WHERE id=$1`, id, passwordHash,
+    )
+    if err != nil {
+        return err
+    }
+    if tag.RowsAffected() == 0 {
+        return pkgValidator.ErrUserNotFound
+    }
+    return nil
+}
+
+func (r *userRepo) UpdateRegions(ctx context.Context, id uuid.UUID, regions []string) (*entity.User, error) {
+    var u entity.User
+    err := r.db.QueryRow(ctx,
+        `UPDATE users SET regions=$2 WHERE id=$1 RETURNING id, email, password_hash, role, regions`,
+        id, regionsOrEmpty(regions),
+    ).Scan(&u.ID, &u.Email, &u.PasswordHash, &u.Role, &u.Regions)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return nil, pkgValidator.ErrUserNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &u, nil
+}
+
+// _noPassword не является bcrypt хешем, поэтому вход по паролю для таких
+// пользователей всегда отклоняется.
+const _noPassword = "!"
+
+func (r *userRepo) UpsertExternalUser(ctx context.Context, identity entity.ExternalIdentity, role entity.Role) (*entity.User, error) {
+    var u entity.User
+    err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+        // Роль синхронизируется только у аккаунтов, созданных через SSO:
+        // локальный аккаунт с тем же email сюда не попадает
+        err := tx.QueryRow(ctx, `
+            UPDATE users SET role = $3
+            FROM user_identities i
+            WHERE i.issuer = $1 AND i.subject = $2 AND users.id = i.user_id
+            RETURNING users.id, users.email, users.password_hash, users.role, users.regions`,
+            identity.Issuer, identity.Subject, role,
+        ).Scan(&u.ID, &u.Email, &u.PasswordHash, &u.Role, &u.Regions)
+        if err == nil {
+            r.log.DebugContext(ctx, "External user role synced", slog.String("user_id", u.ID.String()), slog.String("role", string(role)))
+            return nil
+        }
+        if !errors.Is(err, pgx.ErrNoRows) {
+            return err
+        }
+
+        err = tx.QueryRow(ctx, `
+            INSERT INTO users (id, email, password_hash, role, regions) VALUES ($1,$2,$3,$4,'{}')
+            ON CONFLICT (email) DO NOTHING
+            RETURNING id, email, password_hash, role, regions`,
+            uuid.New(), identity.Email, _noPassword, role,
+        ).Scan(&u.ID, &u.Email, &u.PasswordHash, &u.Role, &u.Regions)
+        if errors.Is(err, pgx.ErrNoRows) {
+            r.log.WarnContext(ctx, "SSO login rejected: email belongs to another account", slog.String("issuer", identity.Issuer), slog.String("subject", identity.Subject))
+            return pkgValidator.ErrOIDCAccountExists
+        }
+        if err != nil {
+            return err
+        }
+
+        if _, err := tx.Exec(ctx,
+            `INSERT INTO user_identities (issuer, subject, user_id) VALUES ($1,$2,$3)`,
+            identity.Issuer, identity.Subject, u.ID,
+        ); err != nil {
+            return err
+        }
+        r.log.InfoContext(ctx, "External user provisioned", slog.String("user_id", u.ID.String()), slog.String("role", string(role)))
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    return &u, nil
+}
+
+// regionsOrEmpty нужен, потому что nil-срез pgx записывает как NULL, а колонка NOT NULL.
+func regionsOrEmpty(regions []string) []string {
+    if regions == nil {
+        return []string{}
+    }
+    return regions
+}
+
+func (r *userRepo) CreateResetToken(ctx context.Context, token *entity.PasswordResetToken) error {
+    _, err := r.db.Exec(ctx,
+        `INSERT INTO password_reset_tokens (token_hash, user_id, expires_at, created_at) VALUES ($1,$2,$3,$4)`,
+        token.TokenHash, token.UserID, token.ExpiresAt, token.CreatedAt,
+    )
+    return err
+}
+
+func (r *userRepo) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (*entity.User, error) {
+    var u entity.User
+    err := r.db.QueryRow(ctx, `
+        WITH consumed AS (
+            UPDATE password_reset_tokens
+            SET used_at = NOW()
+            WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+            RETURNING user_id
+        ), revoked AS (
+            DELETE FROM password_reset_tokens
+            WHERE user_id IN (SELECT user_id FROM consumed) AND token_hash <> $1
+        )
+        UPDATE users SET password_hash = $2
+        FROM consumed
+        WHERE users.id = consumed.user_id
+        RETURNING users.id, users.email, users.password_hash, users.role, users.regions`,
+        tokenHash, passwordHash,
+    ).Scan(&u.ID, &u.Email, &u.PasswordHash, &u.Role, &u.Regions)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return nil, pkgValidator.ErrInvalidResetToken
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &u, nil
+}
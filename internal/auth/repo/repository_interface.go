@@ -2,10 +2,53 @@ package repo
 
 import (
     "context"
+    "time"
     "GoPVZ/internal/auth/entity"
+
+    "github.com/google/uuid"
 )
 
 type UserRepository interface {
     Create(ctx context.Context, user *entity.User) error
     GetByEmail(ctx context.Context, email string) (*entity.User, error)
-}
\ No newline at end of file
+    GetById(ctx context.Context, id uuid.UUID) (*entity.User, error)
+    GetPVZAssignments(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+    UpdatePasswordHash(ctx context.Context, id uuid.UUID, passwordHash string) error
+    UpdateRegions(ctx context.Context, id uuid.UUID, regions []string) (*entity.User, error)
+    // UpsertExternalUser находит аккаунт по (issuer, subject) и обновляет его роль
+    // или создает новый аккаунт без пароля для /login. Если email уже занят
+    // аккаунтом без этой связи, возвращается ErrOIDCAccountExists.
+    UpsertExternalUser(ctx context.Context, identity entity.ExternalIdentity, role entity.Role) (*entity.User, error)
+    CreateResetToken(ctx context.Context, token *entity.PasswordResetToken) error
+    // ResetPassword гасит токен и меняет пароль одним запросом; остальные токены
+    // пользователя удаляются. Возвращает пользователя, которому принадлежал токен.
+    ResetPassword(ctx context.Context, tokenHash, passwordHash string) (*entity.User, error)
+}
+
+type APIKeyRepository interface {
+    Create(ctx context.Context, key *entity.APIKey) error
+    GetByHash(ctx context.Context, keyHash string) (*entity.APIKey, error)
+    // List возвращает ключи, выпущенные createdBy; при nil — все ключи.
+    List(ctx context.Context, createdBy *uuid.UUID) ([]*entity.APIKey, error)
+    // Revoke отзывает активный ключ, выпущенный createdBy (при nil — любой);
+    // отозванный, чужой или неизвестный — ErrAPIKeyNotFound.
+    Revoke(ctx context.Context, id uuid.UUID, createdBy *uuid.UUID, at time.Time) error
+    // TouchLastUsed обновляет last_used_at не чаще minInterval, чтобы не писать
+    // в базу на каждый запрос.
+    TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time, minInterval time.Duration) error
+}
+
+type TOTPRepository interface {
+    // Get возвращает второй фактор пользователя; без него — ErrTOTPNotEnrolled.
+    Get(ctx context.Context, userID uuid.UUID) (*entity.TOTP, error)
+    // SaveSecret сохраняет новый неподтвержденный секрет. Подтвержденный второй
+    // фактор не перезаписывается: ErrTOTPAlreadyEnabled.
+    SaveSecret(ctx context.Context, userID uuid.UUID, secret string, at time.Time) error
+    // Confirm включает второй фактор и заменяет коды восстановления.
+    Confirm(ctx context.Context, userID uuid.UUID, step int64, recoveryCodeHashes []string, at time.Time) error
+    // UseStep принимает код шага step, если он новее последнего принятого;
+    // иначе ErrInvalidTOTPCode.
+    UseStep(ctx context.Context, userID uuid.UUID, step int64) error
+    // UseRecoveryCode гасит неиспользованный код восстановления; иначе ErrInvalidTOTPCode.
+    UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, at time.Time) error
+}
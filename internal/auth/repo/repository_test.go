@@ -3,13 +3,17 @@ package repo
 import (
 	"context"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"GoPVZ/internal/auth/entity"
+	"GoPVZ/pkg/pkgLogger"
 	"GoPVZ/pkg/pkgPostgres"
+	"GoPVZ/pkg/pkgValidator"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
@@ -19,6 +23,7 @@ import (
 var (
 	testConnStr string
 	pgContainer *postgres.PostgresContainer
+	testPool    *pgxpool.Pool
 )
 
 func TestMain(m *testing.M) {
@@ -56,7 +61,64 @@ func TestMain(m *testing.M) {
 			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
 			email VARCHAR(255) NOT NULL UNIQUE,
 			password_hash VARCHAR(255) NOT NULL,
-			role VARCHAR(20) NOT NULL CHECK (role IN ('employee', 'moderator'))
+			role VARCHAR(20) NOT NULL CHECK (role IN ('employee', 'moderator')),
+			regions TEXT[] NOT NULL DEFAULT '{}'
+		);
+
+		CREATE TABLE IF NOT EXISTS password_reset_tokens (
+			token_hash CHAR(64) PRIMARY KEY,
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			expires_at TIMESTAMPTZ NOT NULL,
+			used_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		CREATE TABLE IF NOT EXISTS pvz (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid()
+		);
+
+		CREATE TABLE IF NOT EXISTS user_pvz_assignments (
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			pvz_id UUID NOT NULL REFERENCES pvz(id) ON DELETE CASCADE,
+			assigned_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (user_id, pvz_id)
+		);
+
+		CREATE TABLE IF NOT EXISTS api_keys (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			name VARCHAR(100) NOT NULL,
+			prefix VARCHAR(16) NOT NULL,
+			key_hash CHAR(64) NOT NULL UNIQUE,
+			permissions TEXT[] NOT NULL,
+			regions TEXT[] NOT NULL DEFAULT '{}',
+			created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+			expires_at TIMESTAMPTZ,
+			last_used_at TIMESTAMPTZ,
+			revoked_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		CREATE TABLE IF NOT EXISTS user_totp (
+			user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			secret VARCHAR(64) NOT NULL,
+			confirmed_at TIMESTAMPTZ,
+			last_step BIGINT NOT NULL DEFAULT 0,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		CREATE TABLE IF NOT EXISTS user_recovery_codes (
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			code_hash CHAR(64) NOT NULL,
+			used_at TIMESTAMPTZ,
+			PRIMARY KEY (user_id, code_hash)
+		);
+
+		CREATE TABLE IF NOT EXISTS user_identities (
+			issuer TEXT NOT NULL,
+			subject TEXT NOT NULL,
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (issuer, subject)
 		);
 	`)
 	if err != nil {
@@ -80,7 +142,8 @@ func setupTestRepo(t *testing.T) (UserRepository, func()) {
 	_, err = pg.Pool.Exec(context.Background(), "TRUNCATE TABLE users CASCADE")
 	require.NoError(t, err)
 
-	repo := NewUserRepo(pg.Pool)
+	repo := NewUserRepo(pg.Pool, pkgLogger.Discard())
+	testPool = pg.Pool
 
 	return repo, func() {
 		pg.Close()
@@ -168,14 +231,14 @@ func TestUserRepository_GetByEmail(t *testing.T) {
 			email:       "nonexistent@example.com",
 			wantUser:    nil,
 			wantError:   true,
-			errorString: "no rows in result set",
+			errorString: pkgValidator.ErrUserNotFound.Error(),
 		},
 		{
 			name:        "empty email",
 			email:       "",
 			wantUser:    nil,
 			wantError:   true,
-			errorString: "no rows in result set",
+			errorString: pkgValidator.ErrUserNotFound.Error(),
 		},
 	}
 
@@ -200,3 +263,252 @@ func TestUserRepository_GetByEmail(t *testing.T) {
 	}
 }
 
+func TestUserRepository_UpdatePasswordHash(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	user := &entity.User{ID: uuid.New(), Email: "update@example.com", PasswordHash: "old_hash", Role: entity.RoleEmployee}
+	require.NoError(t, repo.Create(ctx, user))
+
+	require.NoError(t, repo.UpdatePasswordHash(ctx, user.ID, "new_hash"))
+
+	stored, err := repo.GetById(ctx, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, "new_hash", stored.PasswordHash)
+
+	require.ErrorIs(t, repo.UpdatePasswordHash(ctx, uuid.New(), "hash"), pkgValidator.ErrUserNotFound)
+	_, err = repo.GetById(ctx, uuid.New())
+	require.ErrorIs(t, err, pkgValidator.ErrUserNotFound)
+}
+
+func TestUserRepository_ResetPassword(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	user := &entity.User{ID: uuid.New(), Email: "reset@example.com", PasswordHash: "old_hash", Role: entity.RoleEmployee}
+	require.NoError(t, repo.Create(ctx, user))
+
+	now := time.Now().UTC()
+	newToken := func(hash string, expiresAt time.Time) {
+		require.NoError(t, repo.CreateResetToken(ctx, &entity.PasswordResetToken{
+			TokenHash: hash,
+			UserID:    user.ID,
+			ExpiresAt: expiresAt,
+			CreatedAt: now,
+		}))
+	}
+	newToken(strings.Repeat("a", 64), now.Add(-time.Minute))
+	newToken(strings.Repeat("b", 64), now.Add(time.Hour))
+	newToken(strings.Repeat("c", 64), now.Add(time.Hour))
+
+	// Просроченный токен не принимается
+	_, err := repo.ResetPassword(ctx, strings.Repeat("a", 64), "new_hash")
+	require.ErrorIs(t, err, pkgValidator.ErrInvalidResetToken)
+
+	reset, err := repo.ResetPassword(ctx, strings.Repeat("b", 64), "new_hash")
+	require.NoError(t, err)
+	require.Equal(t, user.ID, reset.ID)
+	require.Equal(t, "new_hash", reset.PasswordHash)
+
+	// Повторно тот же токен не работает, остальные токены пользователя отозваны
+	_, err = repo.ResetPassword(ctx, strings.Repeat("b", 64), "other_hash")
+	require.ErrorIs(t, err, pkgValidator.ErrInvalidResetToken)
+	_, err = repo.ResetPassword(ctx, strings.Repeat("c", 64), "other_hash")
+	require.ErrorIs(t, err, pkgValidator.ErrInvalidResetToken)
+
+	stored, err := repo.GetByEmail(ctx, user.Email)
+	require.NoError(t, err)
+	require.Equal(t, "new_hash", stored.PasswordHash)
+}
+
+func TestUserRepository_GetPVZAssignments(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	user := &entity.User{ID: uuid.New(), Email: "assigned@example.com", PasswordHash: "hash", Role: entity.RoleEmployee}
+	require.NoError(t, repo.Create(ctx, user))
+
+	pvzIDs, err := repo.GetPVZAssignments(ctx, user.ID)
+	require.NoError(t, err)
+	require.Empty(t, pvzIDs)
+
+	first, second := uuid.New(), uuid.New()
+	_, err = testPool.Exec(ctx, `INSERT INTO pvz (id) VALUES ($1), ($2)`, first, second)
+	require.NoError(t, err)
+	_, err = testPool.Exec(ctx,
+		`INSERT INTO user_pvz_assignments (user_id, pvz_id, assigned_at) VALUES ($1, $2, $4), ($1, $3, $5)`,
+		user.ID, first, second, time.Now().Add(-time.Hour), time.Now(),
+	)
+	require.NoError(t, err)
+
+	pvzIDs, err = repo.GetPVZAssignments(ctx, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{first, second}, pvzIDs)
+}
+
+func TestUserRepository_UpdateRegions(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	user := &entity.User{ID: uuid.New(), Email: "regions@example.com", PasswordHash: "hash", Role: entity.RoleModerator}
+	require.NoError(t, repo.Create(ctx, user))
+
+	stored, err := repo.GetById(ctx, user.ID)
+	require.NoError(t, err)
+	require.Empty(t, stored.Regions)
+
+	updated, err := repo.UpdateRegions(ctx, user.ID, []string{"Moscow", "Kazan"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"Moscow", "Kazan"}, updated.Regions)
+
+	stored, err = repo.GetByEmail(ctx, user.Email)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Moscow", "Kazan"}, stored.Regions)
+
+	// nil снимает ограничение
+	updated, err = repo.UpdateRegions(ctx, user.ID, nil)
+	require.NoError(t, err)
+	require.Empty(t, updated.Regions)
+
+	_, err = repo.UpdateRegions(ctx, uuid.New(), nil)
+	require.ErrorIs(t, err, pkgValidator.ErrUserNotFound)
+}
+
+func TestUserRepository_UpsertExternalUser(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	identity := entity.ExternalIdentity{Issuer: "https://idp.example.com", Subject: "42", Email: "sso@example.com"}
+	created, err := repo.UpsertExternalUser(ctx, identity, entity.RoleEmployee)
+	require.NoError(t, err)
+	require.Equal(t, entity.RoleEmployee, created.Role)
+	require.Empty(t, created.Regions)
+
+	// Повторный вход обновляет роль, пользователь тот же, даже если email сменился
+	identity.Email = "renamed@example.com"
+	updated, err := repo.UpsertExternalUser(ctx, identity, entity.RoleModerator)
+	require.NoError(t, err)
+	require.Equal(t, created.ID, updated.ID)
+	require.Equal(t, entity.RoleModerator, updated.Role)
+
+	// Локальный аккаунт с тем же email не связывается и не меняет роль
+	local := &entity.User{ID: uuid.New(), Email: "local@example.com", PasswordHash: "hash", Role: entity.RoleEmployee}
+	require.NoError(t, repo.Create(ctx, local))
+	other := entity.ExternalIdentity{Issuer: "https://idp.example.com", Subject: "43", Email: local.Email}
+	_, err = repo.UpsertExternalUser(ctx, other, entity.RoleModerator)
+	require.ErrorIs(t, err, pkgValidator.ErrOIDCAccountExists)
+
+	stored, err := repo.GetByEmail(ctx, local.Email)
+	require.NoError(t, err)
+	require.Equal(t, entity.RoleEmployee, stored.Role)
+}
+
+func TestAPIKeyRepository(t *testing.T) {
+	users, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, err := testPool.Exec(ctx, "TRUNCATE TABLE api_keys")
+	require.NoError(t, err)
+	repo := NewAPIKeyRepo(testPool, pkgLogger.Discard())
+
+	owner := &entity.User{ID: uuid.New(), Email: "keys@example.com", PasswordHash: "hash", Role: entity.RoleModerator}
+	require.NoError(t, users.Create(ctx, owner))
+	other := uuid.New()
+
+	expiresAt := time.Now().UTC().Add(time.Hour).Truncate(time.Microsecond)
+	key := &entity.APIKey{
+		ID:          uuid.New(),
+		Name:        "erp",
+		Prefix:      "pvz_abcdefgh",
+		KeyHash:     strings.Repeat("a", 64),
+		Permissions: []entity.Permission{entity.PermissionPVZRead, entity.PermissionExportRead},
+		Regions:     []string{"Kazan"},
+		ExpiresAt:   &expiresAt,
+		CreatedAt:   time.Now().UTC(),
+		CreatedBy:   &owner.ID,
+	}
+	require.NoError(t, repo.Create(ctx, key))
+
+	stored, err := repo.GetByHash(ctx, key.KeyHash)
+	require.NoError(t, err)
+	require.Equal(t, key.Name, stored.Name)
+	require.Equal(t, key.Permissions, stored.Permissions)
+	require.Equal(t, key.Regions, stored.Regions)
+	require.True(t, expiresAt.Equal(*stored.ExpiresAt))
+	require.Nil(t, stored.LastUsedAt)
+
+	_, err = repo.GetByHash(ctx, strings.Repeat("b", 64))
+	require.ErrorIs(t, err, pkgValidator.ErrAPIKeyNotFound)
+
+	// Повторная отметка в пределах интервала не перезаписывает время
+	first := time.Now().UTC().Truncate(time.Microsecond)
+	require.NoError(t, repo.TouchLastUsed(ctx, key.ID, first, time.Minute))
+	require.NoError(t, repo.TouchLastUsed(ctx, key.ID, first.Add(time.Second), time.Minute))
+	stored, err = repo.GetByHash(ctx, key.KeyHash)
+	require.NoError(t, err)
+	require.True(t, first.Equal(*stored.LastUsedAt))
+
+	// Чужой ключ не виден и не отзывается
+	keys, err := repo.List(ctx, &other)
+	require.NoError(t, err)
+	require.Empty(t, keys)
+	require.ErrorIs(t, repo.Revoke(ctx, key.ID, &other, time.Now().UTC()), pkgValidator.ErrAPIKeyNotFound)
+
+	require.NoError(t, repo.Revoke(ctx, key.ID, &owner.ID, time.Now().UTC()))
+	require.ErrorIs(t, repo.Revoke(ctx, key.ID, nil, time.Now().UTC()), pkgValidator.ErrAPIKeyNotFound)
+
+	for _, createdBy := range []*uuid.UUID{nil, &owner.ID} {
+		keys, err = repo.List(ctx, createdBy)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		require.NotNil(t, keys[0].RevokedAt)
+	}
+}
+
+func TestTOTPRepository(t *testing.T) {
+	users, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	user := &entity.User{ID: uuid.New(), Email: "totp@example.com", PasswordHash: "hash", Role: entity.RoleModerator}
+	require.NoError(t, users.Create(ctx, user))
+	repo := NewTOTPRepo(testPool, pkgLogger.Discard())
+
+	_, err := repo.Get(ctx, user.ID)
+	require.ErrorIs(t, err, pkgValidator.ErrTOTPNotEnrolled)
+
+	// До подтверждения секрет можно перевыпустить
+	now := time.Now().UTC()
+	require.NoError(t, repo.SaveSecret(ctx, user.ID, "FIRSTSECRET", now))
+	require.NoError(t, repo.SaveSecret(ctx, user.ID, "SECONDSECRET", now))
+	stored, err := repo.Get(ctx, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, "SECONDSECRET", stored.Secret)
+	require.False(t, stored.Enabled())
+	require.ErrorIs(t, repo.UseStep(ctx, user.ID, 100), pkgValidator.ErrInvalidTOTPCode)
+
+	hashes := []string{strings.Repeat("a", 64), strings.Repeat("b", 64)}
+	require.NoError(t, repo.Confirm(ctx, user.ID, 100, hashes, now))
+	require.ErrorIs(t, repo.Confirm(ctx, user.ID, 101, hashes, now), pkgValidator.ErrTOTPAlreadyEnabled)
+	require.ErrorIs(t, repo.SaveSecret(ctx, user.ID, "THIRDSECRET", now), pkgValidator.ErrTOTPAlreadyEnabled)
+
+	stored, err = repo.Get(ctx, user.ID)
+	require.NoError(t, err)
+	require.True(t, stored.Enabled())
+	require.Equal(t, int64(100), stored.LastStep)
+
+	// Шаг принимается только новее последнего
+	require.ErrorIs(t, repo.UseStep(ctx, user.ID, 100), pkgValidator.ErrInvalidTOTPCode)
+	require.NoError(t, repo.UseStep(ctx, user.ID, 101))
+
+	// Код восстановления одноразовый
+	require.NoError(t, repo.UseRecoveryCode(ctx, user.ID, hashes[0], now))
+	require.ErrorIs(t, repo.UseRecoveryCode(ctx, user.ID, hashes[0], now), pkgValidator.ErrInvalidTOTPCode)
+	require.ErrorIs(t, repo.UseRecoveryCode(ctx, user.ID, strings.Repeat("c", 64), now), pkgValidator.ErrInvalidTOTPCode)
+}
@@ -0,0 +1,109 @@
+package repo
+
+import (
+    "context"
+    "errors"
+    "time"
+    "GoPVZ/internal/auth/entity"
+    "GoPVZ/pkg/pkgLogger"
+    "GoPVZ/pkg/pkgValidator"
+    "log/slog"
+
+    "github.com/google/uuid"
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+type totpRepo struct {
+    db  *pgxpool.Pool
+    log pkgLogger.Interface
+}
+
+func NewTOTPRepo(db *pgxpool.Pool, log pkgLogger.Interface) TOTPRepository {
+    return &totpRepo{db: db, log: log}
+}
+
+func (r *totpRepo) Get(ctx context.Context, userID uuid.UUID) (*entity.TOTP, error) {
+    var t entity.TOTP
+    err := r.db.QueryRow(ctx,
+        `SELECT user_id, secret, confirmed_at, last_step, created_at FROM user_totp WHERE user_id=$1`, userID,
+    ).Scan(&t.UserID, &t.Secret, &t.ConfirmedAt, &t.LastStep, &t.CreatedAt)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return nil, pkgValidator.ErrTOTPNotEnrolled
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &t, nil
+}
+
+func (r *totpRepo) SaveSecret(ctx context.Context, userID uuid.UUID, secret string, at time.Time) error {
+    tag, err := r.db.Exec(ctx, `
+        INSERT INTO user_totp (user_id, secret, created_at) VALUES ($1,$2,$3)
+        ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, last_step = 0, created_at = EXCLUDED.created_at
+        WHERE user_totp.confirmed_at IS NULL`,
+        userID, secret, at,
+    )
+    if err != nil {
+        return err
+    }
+    if tag.RowsAffected() == 0 {
+        return pkgValidator.ErrTOTPAlreadyEnabled
+    }
+    return nil
+}
+
+func (r *totpRepo) Confirm(ctx context.Context, userID uuid.UUID, step int64, recoveryCodeHashes []string, at time.Time) error {
+    return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+        tag, err := tx.Exec(ctx,
+            `UPDATE user_totp SET confirmed_at=$2, last_step=$3 WHERE user_id=$1 AND confirmed_at IS NULL`,
+            userID, at, step,
+        )
+        if err != nil {
+            return err
+        }
+        if tag.RowsAffected() == 0 {
+            return pkgValidator.ErrTOTPAlreadyEnabled
+        }
+
+        if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id=$1`, userID); err != nil {
+            return err
+        }
+        _, err = tx.Exec(ctx,
+            `INSERT INTO user_recovery_codes (user_id, code_hash) SELECT $1, unnest($2::text[])`,
+            userID, recoveryCodeHashes,
+        )
+        return err
+    })
+}
+
+func (r *totpRepo) UseStep(ctx context.Context, userID uuid.UUID, step int64) error {
+    tag, err := r.db.Exec(ctx,
+        `UPDATE user_totp SET last_step=$2 WHERE user_id=$1 AND confirmed_at IS NOT NULL AND last_step < $2`,
+        userID, step,
+    )
+    if err != nil {
+        return err
+    }
+    if tag.RowsAffected() == 0 {
+        // Код из уже использованного интервала — возможный повтор перехваченного кода
+        r.log.DebugContext(ctx, "TOTP step rejected as reused", slog.String("user_id", userID.String()), slog.Int64("step", step))
+        return pkgValidator.ErrInvalidTOTPCode
+    }
+    return nil
+}
+
+func (r *totpRepo) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, at time.Time) error {
+    tag, err := r.db.Exec(ctx,
+        `UPDATE user_recovery_codes SET used_at=$3 WHERE user_id=$1 AND code_hash=$2 AND used_at IS NULL`,
+        userID, codeHash, at,
+    )
+    if err != nil {
+        return err
+    }
+    if tag.RowsAffected() == 0 {
+        return pkgValidator.ErrInvalidTOTPCode
+    }
+    r.log.InfoContext(ctx, "Recovery code used", slog.String("user_id", userID.String()))
+    return nil
+}
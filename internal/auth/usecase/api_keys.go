@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"GoPVZ/internal/auth/entity"
+	"GoPVZ/internal/auth/repo"
+	pvzEntity "GoPVZ/internal/pvz/entity"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgValidator"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// _apiKeyScheme отличает API ключи от JWT и токенов сброса в логах и конфигах.
+	_apiKeyScheme = "pvz_"
+	// _apiKeyPrefixLen — сколько символов ключа сохраняется открыто для списка ключей.
+	_apiKeyPrefixLen = len(_apiKeyScheme) + 8
+	// _lastUsedInterval — как часто обновляется last_used_at.
+	_lastUsedInterval = time.Minute
+)
+
+type APIKeyUseCase struct {
+	repo   repo.APIKeyRepository
+	policy *entity.PermissionPolicy
+	log    pkgLogger.Interface
+	now    func() time.Time
+}
+
+func NewAPIKeyUseCase(r repo.APIKeyRepository, policy *entity.PermissionPolicy, log pkgLogger.Interface) *APIKeyUseCase {
+	return &APIKeyUseCase{repo: r, policy: policy, log: log, now: time.Now}
+}
+
+// KeyIssuer — пользователь, выпускающий ключ.
+type KeyIssuer struct {
+	UserID  string
+	Role    entity.Role
+	Regions []string
+}
+
+// Create выпускает ключ сервисного аккаунта. Возвращаемая строка — сам ключ,
+// повторно получить его нельзя. Ключ не получает разрешений, которых нет у роли
+// создателя, и ограничен его регионами.
+func (uc *APIKeyUseCase) Create(ctx context.Context, issuer KeyIssuer, name string, permissions []entity.Permission, expiresAt *time.Time) (*entity.APIKey, string, error) {
+	for _, p := range permissions {
+		if !uc.policy.Allows(issuer.Role, p) {
+			uc.log.WarnContext(ctx, "API key rejected: permission is not held by the issuer",
+				slog.String("user_id", issuer.UserID),
+				slog.String("role", string(issuer.Role)),
+				slog.String("permission", string(p)),
+			)
+			return nil, "", pkgValidator.ErrPermissionNotHeld
+		}
+	}
+
+	secret, err := newSecretToken()
+	if err != nil {
+		return nil, "", err
+	}
+	secret = _apiKeyScheme + secret
+
+	key := &entity.APIKey{
+		ID:          uuid.New(),
+		Name:        name,
+		Prefix:      secret[:_apiKeyPrefixLen],
+		KeyHash:     hashSecretToken(secret),
+		Permissions: permissions,
+		Regions:     issuer.Regions,
+		ExpiresAt:   expiresAt,
+		CreatedAt:   uc.now().UTC(),
+	}
+	if id, err := uuid.Parse(issuer.UserID); err == nil {
+		key.CreatedBy = &id
+	}
+
+	if err := uc.repo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+	uc.log.InfoContext(ctx, "API key created",
+		slog.String("key_id", key.ID.String()),
+		slog.String("prefix", key.Prefix),
+		slog.String("created_by", issuer.UserID),
+	)
+	return key, secret, nil
+}
+
+// List возвращает ключи, видимые пользователю: без ограничения по городам — все,
+// иначе только выпущенные им самим.
+func (uc *APIKeyUseCase) List(ctx context.Context, scope pvzEntity.Scope, userID string) ([]*entity.APIKey, error) {
+	return uc.repo.List(ctx, keyOwner(scope, userID))
+}
+
+// Revoke отзывает ключ. Пользователь, ограниченный городами, отзывает только
+// свои ключи; чужой ключ для него не существует — ErrAPIKeyNotFound.
+func (uc *APIKeyUseCase) Revoke(ctx context.Context, scope pvzEntity.Scope, userID, id string) error {
+	keyID, err := uuid.Parse(id)
+	if err != nil {
+		return pkgValidator.ErrAPIKeyNotFound
+	}
+	if err := uc.repo.Revoke(ctx, keyID, keyOwner(scope, userID), uc.now().UTC()); err != nil {
+		return err
+	}
+	uc.log.InfoContext(ctx, "API key revoked", slog.String("key_id", id), slog.String("revoked_by", userID))
+	return nil
+}
+
+// keyOwner — фильтр по создателю ключа; nil — без фильтра.
+func keyOwner(scope pvzEntity.Scope, userID string) *uuid.UUID {
+	if !scope.Restricted() {
+		return nil
+	}
+	// Без идентификатора пользователя фильтр не совпадет ни с одним ключом
+	owner, err := uuid.Parse(userID)
+	if err != nil {
+		owner = uuid.Nil
+	}
+	return &owner
+}
+
+// Authenticate находит активный ключ и отмечает его использование.
+func (uc *APIKeyUseCase) Authenticate(ctx context.Context, secret string) (*entity.APIKey, error) {
+	key, err := uc.repo.GetByHash(ctx, hashSecretToken(secret))
+	if errors.Is(err, pkgValidator.ErrAPIKeyNotFound) {
+		return nil, pkgValidator.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := uc.now().UTC()
+	if !key.Active(now) {
+		uc.log.WarnContext(ctx, "Inactive API key used", slog.String("key_id", key.ID.String()), slog.String("prefix", key.Prefix))
+		return nil, pkgValidator.ErrInvalidAPIKey
+	}
+
+	// Ошибка учета не должна отклонять запрос
+	if err := uc.repo.TouchLastUsed(ctx, key.ID, now, _lastUsedInterval); err != nil {
+		uc.log.WarnContext(ctx, "Failed to update API key last use", slog.String("key_id", key.ID.String()), pkgLogger.Err(err))
+	}
+	return key, nil
+}
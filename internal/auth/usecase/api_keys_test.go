@@ -0,0 +1,176 @@
+package usecase
+
+import (
+	"GoPVZ/internal/auth/entity"
+	pvzEntity "GoPVZ/internal/pvz/entity"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgValidator"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAPIKeyRepo struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepo) Create(ctx context.Context, key *entity.APIKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepo) GetByHash(ctx context.Context, keyHash string) (*entity.APIKey, error) {
+	args := m.Called(ctx, keyHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepo) List(ctx context.Context, createdBy *uuid.UUID) ([]*entity.APIKey, error) {
+	args := m.Called(ctx, createdBy)
+	return args.Get(0).([]*entity.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepo) Revoke(ctx context.Context, id uuid.UUID, createdBy *uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, id, createdBy, at)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepo) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time, minInterval time.Duration) error {
+	args := m.Called(ctx, id, at, minInterval)
+	return args.Error(0)
+}
+
+func newTestPermissionPolicy(t *testing.T) *entity.PermissionPolicy {
+	policy, err := entity.NewPermissionPolicy(entity.DefaultRolePermissions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return policy
+}
+
+func TestAPIKeyUseCase_Create(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepo)
+	uc := NewAPIKeyUseCase(mockRepo, newTestPermissionPolicy(t), pkgLogger.Discard())
+	createdBy := uuid.New()
+
+	var stored *entity.APIKey
+	mockRepo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*entity.APIKey)
+	}).Return(nil)
+
+	issuer := KeyIssuer{UserID: createdBy.String(), Role: entity.RoleModerator, Regions: []string{"Kazan"}}
+	key, secret, err := uc.Create(context.Background(), issuer, "erp", []entity.Permission{entity.PermissionPVZRead}, nil)
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(secret, "pvz_"))
+	assert.Equal(t, secret[:len(key.Prefix)], key.Prefix)
+	// Открытый ключ в базу не попадает
+	assert.Equal(t, hashSecretToken(secret), stored.KeyHash)
+	assert.NotContains(t, stored.KeyHash, secret)
+	assert.Equal(t, &createdBy, stored.CreatedBy)
+	assert.Equal(t, []entity.Permission{entity.PermissionPVZRead}, stored.Permissions)
+	assert.Equal(t, []string{"Kazan"}, stored.Regions)
+}
+
+// Ключ не может получить разрешение, которого нет у роли создателя
+func TestAPIKeyUseCase_CreatePermissionNotHeld(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepo)
+	uc := NewAPIKeyUseCase(mockRepo, newTestPermissionPolicy(t), pkgLogger.Discard())
+
+	issuer := KeyIssuer{UserID: uuid.NewString(), Role: entity.RoleModerator}
+	permissions := []entity.Permission{entity.PermissionPVZRead, entity.PermissionReceptionCreate}
+	key, secret, err := uc.Create(context.Background(), issuer, "erp", permissions, nil)
+
+	assert.ErrorIs(t, err, pkgValidator.ErrPermissionNotHeld)
+	assert.Nil(t, key)
+	assert.Empty(t, secret)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestAPIKeyUseCase_Authenticate(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		key     *entity.APIKey
+		repoErr error
+		wantErr error
+	}{
+		{name: "active key", key: &entity.APIKey{ID: uuid.New()}},
+		{name: "key with expiry in future", key: &entity.APIKey{ID: uuid.New(), ExpiresAt: &future}},
+		{name: "expired key", key: &entity.APIKey{ID: uuid.New(), ExpiresAt: &past}, wantErr: pkgValidator.ErrInvalidAPIKey},
+		{name: "revoked key", key: &entity.APIKey{ID: uuid.New(), RevokedAt: &past}, wantErr: pkgValidator.ErrInvalidAPIKey},
+		{name: "unknown key", repoErr: pkgValidator.ErrAPIKeyNotFound, wantErr: pkgValidator.ErrInvalidAPIKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockAPIKeyRepo)
+			uc := NewAPIKeyUseCase(mockRepo, newTestPermissionPolicy(t), pkgLogger.Discard())
+			uc.now = func() time.Time { return now }
+
+			mockRepo.On("GetByHash", mock.Anything, hashSecretToken("pvz_secret")).Return(tt.key, tt.repoErr)
+			if tt.wantErr == nil {
+				mockRepo.On("TouchLastUsed", mock.Anything, tt.key.ID, now, _lastUsedInterval).Return(nil)
+			}
+
+			key, err := uc.Authenticate(context.Background(), "pvz_secret")
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, key)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.key, key)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAPIKeyUseCase_Revoke(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepo)
+	uc := NewAPIKeyUseCase(mockRepo, newTestPermissionPolicy(t), pkgLogger.Discard())
+	id := uuid.New()
+	userID := uuid.New()
+
+	// Без ограничения по городам отзывается любой ключ, иначе — только свой
+	mockRepo.On("Revoke", mock.Anything, id, (*uuid.UUID)(nil), mock.Anything).Return(nil).Once()
+	mockRepo.On("Revoke", mock.Anything, id, &userID, mock.Anything).Return(pkgValidator.ErrAPIKeyNotFound).Once()
+
+	assert.NoError(t, uc.Revoke(context.Background(), pvzEntity.NewScope([]string{pvzEntity.AllCities}), userID.String(), id.String()))
+	assert.ErrorIs(t, uc.Revoke(context.Background(), pvzEntity.NewScope([]string{"Kazan"}), userID.String(), id.String()), pkgValidator.ErrAPIKeyNotFound)
+	assert.ErrorIs(t, uc.Revoke(context.Background(), pvzEntity.Scope{}, userID.String(), "not-a-uuid"), pkgValidator.ErrAPIKeyNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAPIKeyUseCase_List(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepo)
+	uc := NewAPIKeyUseCase(mockRepo, newTestPermissionPolicy(t), pkgLogger.Discard())
+	userID := uuid.New()
+
+	mockRepo.On("List", mock.Anything, (*uuid.UUID)(nil)).Return([]*entity.APIKey{{}, {}}, nil).Once()
+	mockRepo.On("List", mock.Anything, &userID).Return([]*entity.APIKey{{}}, nil).Once()
+	mockRepo.On("List", mock.Anything, &uuid.Nil).Return([]*entity.APIKey{}, nil).Once()
+
+	keys, err := uc.List(context.Background(), pvzEntity.Scope{}, userID.String())
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+
+	keys, err = uc.List(context.Background(), pvzEntity.NewScope([]string{"Kazan"}), userID.String())
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+
+	keys, err = uc.List(context.Background(), pvzEntity.NewScope([]string{"Kazan"}), "")
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+	mockRepo.AssertExpectations(t)
+}
@@ -2,44 +2,121 @@ package usecase
 
 import (
 	"GoPVZ/internal/auth/entity"
-	"fmt"
+	pvzEntity "GoPVZ/internal/pvz/entity"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
+// _challengeAudienceSuffix отличает аудиторию токена второго шага входа, поэтому
+// VerifyToken не примет его как access-токен.
+const _challengeAudienceSuffix = "/mfa"
+
+// Claims — содержимое access-токена. user_id дублирует sub для клиентов,
+// которые читают старое поле.
+type Claims struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+	// Regions — города, доступные пользователю; "*" — все города, пусто — ни одного
+	Regions []string `json:"regions,omitempty"`
+	jwt.RegisteredClaims
+}
+
 type JwtManager struct {
 	secretKey     string
 	tokenDuration time.Duration
+	issuer        string
+	audience      string
+}
+
+func NewJwtManager(secret string, duration time.Duration, issuer, audience string) *JwtManager {
+	return &JwtManager{secretKey: secret, tokenDuration: duration, issuer: issuer, audience: audience}
 }
 
-func NewJwtManager(secret string, duration time.Duration) *JwtManager {
-	return &JwtManager{secretKey: secret, tokenDuration: duration}
+// tokenRegions — города для токена. Администратор не ограничен городами: иначе
+// регионы было бы некому назначить.
+func tokenRegions(user *entity.User) []string {
+	if user.Role == entity.RoleAdmin {
+		return []string{pvzEntity.AllCities}
+	}
+	return user.Regions
 }
 
 func (jm *JwtManager) GenerateToken(user *entity.User) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": user.ID.String(),
-		"email":   user.Email,
-		"role":    string(user.Role),
-		"exp":     time.Now().Add(jm.tokenDuration).Unix(),
+	now := time.Now()
+	claims := &Claims{
+		UserID:  user.ID.String(),
+		Email:   user.Email,
+		Role:    string(user.Role),
+		Regions: tokenRegions(user),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.ID.String(),
+			Issuer:    jm.issuer,
+			Audience:  jwt.ClaimStrings{jm.audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(jm.tokenDuration)),
+		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(jm.secretKey))
 }
 
-func (jm *JwtManager) VerifyToken(tokenStr string) (*jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-		}
+// VerifyToken проверяет подпись, срок действия, издателя и аудиторию токена.
+func (jm *JwtManager) VerifyToken(tokenStr string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
 		return []byte(jm.secretKey), nil
-	})
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(jm.issuer),
+		jwt.WithAudience(jm.audience),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	)
 	if err != nil {
 		return nil, err
 	}
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return &claims, nil
+	if !token.Valid || claims.Subject == "" {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	return claims, nil
+}
+
+// GenerateChallengeToken выдает промежуточный токен после проверки пароля; с ним
+// вход завершается вторым фактором.
+func (jm *JwtManager) GenerateChallengeToken(userID uuid.UUID, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims := &jwt.RegisteredClaims{
+		Subject:   userID.String(),
+		Issuer:    jm.issuer,
+		Audience:  jwt.ClaimStrings{jm.audience + _challengeAudienceSuffix},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(jm.secretKey))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// VerifyChallengeToken проверяет токен из GenerateChallengeToken и возвращает ID пользователя.
+func (jm *JwtManager) VerifyChallengeToken(tokenStr string) (uuid.UUID, error) {
+	claims := &jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(jm.secretKey), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(jm.issuer),
+		jwt.WithAudience(jm.audience+_challengeAudienceSuffix),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return uuid.Nil, err
 	}
-	return nil, jwt.ErrTokenInvalidClaims
+	return uuid.Parse(claims.Subject)
 }
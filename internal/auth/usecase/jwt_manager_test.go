@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"GoPVZ/internal/auth/entity"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJwtManager_GenerateAndVerify(t *testing.T) {
+	jm := NewJwtManager("secret", time.Hour, "gopvz", "gopvz-api")
+	user := &entity.User{ID: uuid.New(), Email: "test@example.com", Role: entity.RoleModerator}
+
+	token, err := jm.GenerateToken(user)
+	require.NoError(t, err)
+
+	claims, err := jm.VerifyToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID.String(), claims.Subject)
+	assert.Equal(t, user.ID.String(), claims.UserID)
+	assert.Equal(t, user.Email, claims.Email)
+	assert.Equal(t, string(user.Role), claims.Role)
+	assert.Equal(t, "gopvz", claims.Issuer)
+	assert.Equal(t, jwt.ClaimStrings{"gopvz-api"}, claims.Audience)
+	assert.WithinDuration(t, time.Now(), claims.IssuedAt.Time, time.Minute)
+	assert.WithinDuration(t, time.Now(), claims.NotBefore.Time, time.Minute)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), claims.ExpiresAt.Time, time.Minute)
+}
+
+func TestJwtManager_VerifyRejects(t *testing.T) {
+	jm := NewJwtManager("secret", time.Hour, "gopvz", "gopvz-api")
+	user := &entity.User{ID: uuid.New(), Email: "test@example.com", Role: entity.RoleEmployee}
+
+	sign := func(method jwt.SigningMethod, key interface{}, modify func(c *Claims)) string {
+		now := time.Now()
+		claims := &Claims{
+			UserID: user.ID.String(),
+			Email:  user.Email,
+			Role:   string(user.Role),
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   user.ID.String(),
+				Issuer:    "gopvz",
+				Audience:  jwt.ClaimStrings{"gopvz-api"},
+				IssuedAt:  jwt.NewNumericDate(now),
+				NotBefore: jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			},
+		}
+		modify(claims)
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		require.NoError(t, err)
+		return token
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{
+			name:  "other issuer",
+			token: sign(jwt.SigningMethodHS256, []byte("secret"), func(c *Claims) { c.Issuer = "someone-else" }),
+		},
+		{
+			name:  "other audience",
+			token: sign(jwt.SigningMethodHS256, []byte("secret"), func(c *Claims) { c.Audience = jwt.ClaimStrings{"other-api"} }),
+		},
+		{
+			name: "expired",
+			token: sign(jwt.SigningMethodHS256, []byte("secret"), func(c *Claims) {
+				c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+			}),
+		},
+		{
+			name:  "without expiry",
+			token: sign(jwt.SigningMethodHS256, []byte("secret"), func(c *Claims) { c.ExpiresAt = nil }),
+		},
+		{
+			name: "not yet valid",
+			token: sign(jwt.SigningMethodHS256, []byte("secret"), func(c *Claims) {
+				c.NotBefore = jwt.NewNumericDate(time.Now().Add(time.Hour))
+			}),
+		},
+		{
+			name:  "without subject",
+			token: sign(jwt.SigningMethodHS256, []byte("secret"), func(c *Claims) { c.Subject = "" }),
+		},
+		{
+			name:  "wrong secret",
+			token: sign(jwt.SigningMethodHS256, []byte("other-secret"), func(c *Claims) {}),
+		},
+		{
+			name:  "other algorithm",
+			token: sign(jwt.SigningMethodHS512, []byte("secret"), func(c *Claims) {}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jm.VerifyToken(tt.token)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestJwtManager_ChallengeToken(t *testing.T) {
+	jm := NewJwtManager("secret", time.Hour, "gopvz", "gopvz-api")
+	user := &entity.User{ID: uuid.New(), Email: "mod@example.com", Role: entity.RoleModerator}
+
+	challenge, expiresAt, err := jm.GenerateChallengeToken(user.ID, 5*time.Minute)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), expiresAt, time.Minute)
+
+	userID, err := jm.VerifyChallengeToken(challenge)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, userID)
+
+	// Промежуточный токен не дает доступа к API, а access-токен не завершает вход
+	_, err = jm.VerifyToken(challenge)
+	assert.Error(t, err)
+	access, err := jm.GenerateToken(user)
+	require.NoError(t, err)
+	_, err = jm.VerifyChallengeToken(access)
+	assert.Error(t, err)
+}
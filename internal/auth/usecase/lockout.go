@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LoginAttempts учитывает неудачные попытки входа и временно блокирует аккаунт.
+type LoginAttempts interface {
+	// LockedFor возвращает оставшееся время блокировки; 0 — аккаунт не заблокирован.
+	LockedFor(ctx context.Context, email string) (time.Duration, error)
+	Fail(ctx context.Context, email string) error
+	Reset(ctx context.Context, email string) error
+}
+
+// lockoutKey приводит email к виду, по которому считаются попытки: иначе
+// "User@Example.com " и "user@example.com" блокировались бы отдельно.
+func lockoutKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+type attempts struct {
+	failures    int
+	firstFail   time.Time
+	lockedUntil time.Time
+}
+
+// MemoryLoginAttempts блокирует аккаунт на lockDuration после maxFailures
+// неудачных попыток в пределах window.
+type MemoryLoginAttempts struct {
+	mu           sync.Mutex
+	accounts     map[string]*attempts
+	maxFailures  int
+	window       time.Duration
+	lockDuration time.Duration
+	now          func() time.Time
+}
+
+var _ LoginAttempts = (*MemoryLoginAttempts)(nil)
+
+func NewMemoryLoginAttempts(maxFailures int, window, lockDuration time.Duration) *MemoryLoginAttempts {
+	return &MemoryLoginAttempts{
+		accounts:     make(map[string]*attempts),
+		maxFailures:  maxFailures,
+		window:       window,
+		lockDuration: lockDuration,
+		now:          time.Now,
+	}
+}
+
+func (m *MemoryLoginAttempts) LockedFor(_ context.Context, email string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.accounts[email]
+	if !ok {
+		return 0, nil
+	}
+	if left := a.lockedUntil.Sub(m.now()); left > 0 {
+		return left, nil
+	}
+	return 0, nil
+}
+
+func (m *MemoryLoginAttempts) Fail(_ context.Context, email string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	a, ok := m.accounts[email]
+	if !ok || now.Sub(a.firstFail) > m.window {
+		a = &attempts{firstFail: now}
+		m.accounts[email] = a
+	}
+
+	a.failures++
+	if m.maxFailures > 0 && a.failures >= m.maxFailures {
+		a.lockedUntil = now.Add(m.lockDuration)
+		// После блокировки счет начинается заново
+		a.failures = 0
+		a.firstFail = now
+	}
+	return nil
+}
+
+func (m *MemoryLoginAttempts) Reset(_ context.Context, email string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.accounts, email)
+	return nil
+}
+
+// RunCleanup периодически удаляет записи с истекшим окном и блокировкой.
+func (m *MemoryLoginAttempts) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.cleanup()
+		}
+	}
+}
+
+func (m *MemoryLoginAttempts) cleanup() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for email, a := range m.accounts {
+		if now.Sub(a.firstFail) > m.window && !now.Before(a.lockedUntil) {
+			delete(m.accounts, email)
+		}
+	}
+}
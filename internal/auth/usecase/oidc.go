@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"GoPVZ/internal/auth/entity"
+	"GoPVZ/internal/auth/repo"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgOIDC"
+	"GoPVZ/pkg/pkgValidator"
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// OIDCProvider -.
+type OIDCProvider interface {
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*pkgOIDC.IDToken, error)
+}
+
+var _ OIDCProvider = (*pkgOIDC.Provider)(nil)
+
+// LoginIssuer завершает вход после первого фактора; для SSO второй фактор
+// требуется так же, как для входа по паролю.
+type LoginIssuer interface {
+	IssueLogin(ctx context.Context, user *entity.User) (*LoginResult, error)
+}
+
+var _ LoginIssuer = (*AuthUseCase)(nil)
+
+// RoleMapping сопоставляет группу IdP с ролью GoPVZ.
+type RoleMapping struct {
+	Group string
+	Role  entity.Role
+}
+
+// OIDCUseCase реализует вход через внешний OIDC провайдер. Пользователь создается
+// при первом входе, роль берется из групп IdP при каждом входе.
+type OIDCUseCase struct {
+	provider    OIDCProvider
+	repo        repo.UserRepository
+	issuer      LoginIssuer
+	log         pkgLogger.Interface
+	groupsClaim string
+	roles       []RoleMapping
+	defaultRole entity.Role
+	loginTTL    time.Duration
+	// skipEmailVerified разрешает вход без email_verified=true для провайдеров,
+	// которые не передают этот claim
+	skipEmailVerified bool
+	// stateKey подписывает незавершенные входы
+	stateKey []byte
+
+	now func() time.Time
+}
+
+// NewOIDCUseCase -. Роль определяется первым совпадением в roles; без совпадений
+// выдается defaultRole, а если она пуста — вход запрещается. stateSecret
+// подписывает cookie незавершенного входа и должен совпадать на всех репликах.
+func NewOIDCUseCase(
+	provider OIDCProvider,
+	r repo.UserRepository,
+	issuer LoginIssuer,
+	log pkgLogger.Interface,
+	groupsClaim string,
+	roles []RoleMapping,
+	defaultRole entity.Role,
+	loginTTL time.Duration,
+	skipEmailVerified bool,
+	stateSecret string,
+) *OIDCUseCase {
+	return &OIDCUseCase{
+		provider:          provider,
+		repo:              r,
+		issuer:            issuer,
+		log:               log,
+		groupsClaim:       groupsClaim,
+		roles:             roles,
+		defaultRole:       defaultRole,
+		loginTTL:          loginTTL,
+		skipEmailVerified: skipEmailVerified,
+		stateKey:          []byte(stateSecret),
+		now:               time.Now,
+	}
+}
+
+// ParseRoleMappings разбирает записи вида "group=role"; порядок записей задает приоритет.
+func ParseRoleMappings(values []string) ([]RoleMapping, error) {
+	mappings := make([]RoleMapping, 0, len(values))
+	for _, value := range values {
+		group, role, ok := strings.Cut(value, "=")
+		group, role = strings.TrimSpace(group), strings.TrimSpace(role)
+		if !ok || group == "" {
+			return nil, pkgValidator.ErrInvalidInput
+		}
+		if !entity.Role(role).IsValid() {
+			return nil, pkgValidator.ErrInvalidRole
+		}
+		mappings = append(mappings, RoleMapping{Group: group, Role: entity.Role(role)})
+	}
+	return mappings, nil
+}
+
+// Begin начинает вход и возвращает адрес страницы входа провайдера и сессию
+// входа. Сессию нужно отдать браузеру в cookie и передать в Callback.
+func (uc *OIDCUseCase) Begin(ctx context.Context) (authURL, session string, err error) {
+	state, err := pkgOIDC.NewRandom()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := pkgOIDC.NewRandom()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := pkgOIDC.NewRandom()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err = uc.provider.AuthCodeURL(ctx, state, nonce, pkgOIDC.CodeChallenge(verifier))
+	if err != nil {
+		return "", "", err
+	}
+
+	session, err = sealLogin(uc.stateKey, oidcLogin{
+		State:     state,
+		Nonce:     nonce,
+		Verifier:  verifier,
+		ExpiresAt: uc.now().Add(uc.loginTTL).Unix(),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return authURL, session, nil
+}
+
+// LoginTTL — время жизни сессии входа.
+func (uc *OIDCUseCase) LoginTTL() time.Duration {
+	return uc.loginTTL
+}
+
+// Callback завершает вход: проверяет сессию из Begin и ее state, обменивает код,
+// создает или обновляет пользователя и
+// выдает обычный JWT GoPVZ либо, если нужен второй фактор, MFAChallenge для
+// /login/2fa — как Login. Аккаунт связывается с пользователем IdP по issuer и
+// subject; локальный аккаунт с тем же email не связывается — ErrOIDCAccountExists.
+func (uc *OIDCUseCase) Callback(ctx context.Context, session, state, code string) (*LoginResult, error) {
+	login, ok := openLogin(uc.stateKey, session, state, uc.now())
+	if !ok {
+		return nil, pkgValidator.ErrInvalidOIDCState
+	}
+
+	idToken, err := uc.provider.Exchange(ctx, code, login.Verifier, login.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(idToken.Email))
+	if email == "" {
+		return nil, pkgValidator.ErrOIDCEmailNotVerified
+	}
+	if !idToken.EmailVerified && !uc.skipEmailVerified {
+		uc.log.WarnContext(ctx, "SSO login rejected: email is not verified", slog.String("email", email))
+		return nil, pkgValidator.ErrOIDCEmailNotVerified
+	}
+
+	groups := idToken.Strings(uc.groupsClaim)
+	role, ok := uc.mapRole(groups)
+	if !ok {
+		uc.log.WarnContext(ctx, "SSO login rejected: no group is mapped to a role", slog.String("email", email), slog.Any("groups", groups))
+		return nil, pkgValidator.ErrOIDCNoRole
+	}
+
+	identity := entity.ExternalIdentity{Issuer: idToken.Issuer, Subject: idToken.Subject, Email: email}
+	user, err := uc.repo.UpsertExternalUser(ctx, identity, role)
+	if err != nil {
+		return nil, err
+	}
+	uc.log.InfoContext(ctx, "User authenticated with SSO", slog.String("user_id", user.ID.String()), slog.String("role", string(role)))
+	return uc.issuer.IssueLogin(ctx, user)
+}
+
+func (uc *OIDCUseCase) mapRole(groups []string) (entity.Role, bool) {
+	for _, mapping := range uc.roles {
+		for _, group := range groups {
+			if group == mapping.Group {
+				return mapping.Role, true
+			}
+		}
+	}
+	if uc.defaultRole != "" {
+		return uc.defaultRole, true
+	}
+	return "", false
+}
@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// _oidcLoginLabel отделяет подпись незавершенного входа от других подписей
+// тем же секретом.
+const _oidcLoginLabel = "gopvz-oidc-login."
+
+// oidcLogin — незавершенный вход. Хранится у браузера в подписанной cookie,
+// поэтому callback может прийти на любую реплику.
+type oidcLogin struct {
+	State     string `json:"s"`
+	Nonce     string `json:"n"`
+	Verifier  string `json:"v"`
+	ExpiresAt int64  `json:"e"`
+}
+
+// sealLogin подписывает вход: payload.signature в base64url.
+func sealLogin(key []byte, login oidcLogin) (string, error) {
+	data, err := json.Marshal(login)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(signLogin(key, payload)), nil
+}
+
+// openLogin проверяет подпись и срок входа и сравнивает его state с пришедшим
+// от провайдера: так вход завершается только в браузере, который его начал.
+func openLogin(key []byte, session, state string, now time.Time) (oidcLogin, bool) {
+	payload, signature, ok := strings.Cut(session, ".")
+	if !ok {
+		return oidcLogin{}, false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, signLogin(key, payload)) {
+		return oidcLogin{}, false
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return oidcLogin{}, false
+	}
+
+	var login oidcLogin
+	if err := json.Unmarshal(data, &login); err != nil {
+		return oidcLogin{}, false
+	}
+	if login.State == "" || subtle.ConstantTimeCompare([]byte(login.State), []byte(state)) != 1 {
+		return oidcLogin{}, false
+	}
+	if !now.Before(time.Unix(login.ExpiresAt, 0)) {
+		return oidcLogin{}, false
+	}
+	return login, true
+}
+
+func signLogin(key []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(_oidcLoginLabel))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
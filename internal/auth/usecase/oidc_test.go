@@ -0,0 +1,255 @@
+package usecase
+
+import (
+	"GoPVZ/internal/auth/entity"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgOIDC"
+	"GoPVZ/pkg/pkgOIDC/oidctest"
+	"GoPVZ/pkg/pkgValidator"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// followLogin проходит страницу входа мок-провайдера и возвращает state и code
+// из редиректа на callback.
+func followLogin(t *testing.T, authURL string) (state, code string) {
+	t.Helper()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	return location.Query().Get("state"), location.Query().Get("code")
+}
+
+// newSSOAuthUseCase возвращает AuthUseCase, который не требует второй фактор ни от одной роли.
+func newSSOAuthUseCase(r *MockUserRepo, jm *JwtManager) *AuthUseCase {
+	return NewAuthUseCase(r, newMemoryTOTPRepo(), jm, NewMemoryLoginAttempts(5, time.Minute, time.Minute), &fakeNotifier{}, pkgLogger.Discard(), time.Hour, MFAPolicy{})
+}
+
+func TestOIDCUseCase_Callback(t *testing.T) {
+	idp := oidctest.NewServer("gopvz", "client-secret")
+	defer idp.Close()
+
+	roles, err := ParseRoleMappings([]string{"pvz-moderators=moderator", "pvz-staff=employee"})
+	require.NoError(t, err)
+	jm := NewJwtManager("secret", time.Hour, "gopvz", "gopvz-api")
+
+	tests := []struct {
+		name              string
+		claims            map[string]any
+		defaultRole       entity.Role
+		skipEmailVerified bool
+		repoErr           error
+		wantEmail         string
+		wantRole          entity.Role
+		wantErr           error
+	}{
+		{
+			name:   "first matching group wins",
+			claims: map[string]any{"sub": "1", "email": "Mod@Example.com", "email_verified": true, "groups": []string{"pvz-staff", "pvz-moderators"}},
+			// Email нормализуется так же, как при регистрации
+			wantEmail: "mod@example.com",
+			wantRole:  entity.RoleModerator,
+		},
+		{
+			name:    "email_verified is required",
+			claims:  map[string]any{"sub": "2", "email": "staff@example.com", "groups": "pvz-staff"},
+			wantErr: pkgValidator.ErrOIDCEmailNotVerified,
+		},
+		{
+			name:              "email_verified check skipped by config",
+			claims:            map[string]any{"sub": "2", "email": "staff@example.com", "groups": "pvz-staff"},
+			skipEmailVerified: true,
+			wantEmail:         "staff@example.com",
+			wantRole:          entity.RoleEmployee,
+		},
+		{
+			name:        "default role without matching group",
+			claims:      map[string]any{"sub": "3", "email": "guest@example.com", "email_verified": true, "groups": []string{"other"}},
+			defaultRole: entity.RoleAuditor,
+			wantEmail:   "guest@example.com",
+			wantRole:    entity.RoleAuditor,
+		},
+		{
+			name:    "no matching group",
+			claims:  map[string]any{"sub": "4", "email": "guest@example.com", "email_verified": true, "groups": []string{"other"}},
+			wantErr: pkgValidator.ErrOIDCNoRole,
+		},
+		{
+			name:      "email belongs to a local account",
+			claims:    map[string]any{"sub": "7", "email": "local@example.com", "email_verified": true, "groups": []string{"pvz-moderators"}},
+			repoErr:   pkgValidator.ErrOIDCAccountExists,
+			wantEmail: "local@example.com",
+			wantRole:  entity.RoleModerator,
+			wantErr:   pkgValidator.ErrOIDCAccountExists,
+		},
+		{
+			name:    "unverified email",
+			claims:  map[string]any{"sub": "5", "email": "mod@example.com", "email_verified": false, "groups": []string{"pvz-moderators"}},
+			wantErr: pkgValidator.ErrOIDCEmailNotVerified,
+		},
+		{
+			name:    "no email",
+			claims:  map[string]any{"sub": "6", "groups": []string{"pvz-moderators"}},
+			wantErr: pkgValidator.ErrOIDCEmailNotVerified,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp.SetUser(tt.claims)
+			mockRepo := new(MockUserRepo)
+			provider := pkgOIDC.New(idp.Config("http://localhost/auth/oidc/callback"))
+			uc := NewOIDCUseCase(provider, mockRepo, newSSOAuthUseCase(mockRepo, jm), pkgLogger.Discard(), "groups", roles, tt.defaultRole, time.Minute, tt.skipEmailVerified, "state-secret")
+
+			// Аккаунт ищется по issuer и subject провайдера
+			identity := entity.ExternalIdentity{Issuer: idp.Issuer(), Subject: tt.claims["sub"].(string), Email: tt.wantEmail}
+			var user *entity.User
+			if tt.repoErr == nil {
+				user = &entity.User{ID: uuid.New(), Email: tt.wantEmail, Role: tt.wantRole}
+			}
+			mockRepo.On("UpsertExternalUser", mock.Anything, identity, tt.wantRole).Return(user, tt.repoErr).Maybe()
+
+			authURL, session, err := uc.Begin(context.Background())
+			require.NoError(t, err)
+			state, code := followLogin(t, authURL)
+
+			result, err := uc.Callback(context.Background(), session, state, code)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				if tt.repoErr == nil {
+					mockRepo.AssertNotCalled(t, "UpsertExternalUser", mock.Anything, mock.Anything, mock.Anything)
+				}
+				return
+			}
+			require.NoError(t, err)
+			require.Nil(t, result.Challenge)
+			claims, err := jm.VerifyToken(result.Token)
+			require.NoError(t, err)
+			assert.Equal(t, user.ID.String(), claims.Subject)
+			assert.Equal(t, string(tt.wantRole), claims.Role)
+			assert.Equal(t, tt.wantEmail, claims.Email)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOIDCUseCase_State(t *testing.T) {
+	idp := oidctest.NewServer("gopvz", "client-secret")
+	defer idp.Close()
+	idp.SetUser(map[string]any{"sub": "1", "email": "staff@example.com", "email_verified": true, "groups": []string{"pvz-staff"}})
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("UpsertExternalUser", mock.Anything, mock.Anything, entity.RoleEmployee).
+		Return(&entity.User{ID: uuid.New(), Email: "staff@example.com", Role: entity.RoleEmployee}, nil)
+
+	provider := pkgOIDC.New(idp.Config("http://localhost/auth/oidc/callback"))
+	uc := NewOIDCUseCase(provider, mockRepo, newSSOAuthUseCase(mockRepo, NewJwtManager("secret", time.Hour, "gopvz", "gopvz-api")), pkgLogger.Discard(),
+		"groups", []RoleMapping{{Group: "pvz-staff", Role: entity.RoleEmployee}}, "", time.Minute, false, "state-secret")
+
+	authURL, session, err := uc.Begin(context.Background())
+	require.NoError(t, err)
+	state, code := followLogin(t, authURL)
+
+	// Без сессии, с чужой или подделанной сессией вход не завершается
+	_, otherSession, err := uc.Begin(context.Background())
+	require.NoError(t, err)
+	payload, signature, _ := strings.Cut(session, ".")
+	for _, s := range []string{"", otherSession, payload + "x." + signature, "garbage"} {
+		_, err = uc.Callback(context.Background(), s, state, code)
+		assert.ErrorIs(t, err, pkgValidator.ErrInvalidOIDCState)
+	}
+	_, err = uc.Callback(context.Background(), session, "unknown-state", code)
+	assert.ErrorIs(t, err, pkgValidator.ErrInvalidOIDCState)
+
+	// Сессию проверяет любая реплика с тем же секретом
+	replica := NewOIDCUseCase(provider, mockRepo, newSSOAuthUseCase(mockRepo, NewJwtManager("secret", time.Hour, "gopvz", "gopvz-api")), pkgLogger.Discard(),
+		"groups", []RoleMapping{{Group: "pvz-staff", Role: entity.RoleEmployee}}, "", time.Minute, false, "state-secret")
+	_, err = replica.Callback(context.Background(), session, state, code)
+	require.NoError(t, err)
+
+	// Другой секрет подпись не принимает
+	authURL, session, err = uc.Begin(context.Background())
+	require.NoError(t, err)
+	state, code = followLogin(t, authURL)
+	foreign := NewOIDCUseCase(provider, mockRepo, newSSOAuthUseCase(mockRepo, NewJwtManager("secret", time.Hour, "gopvz", "gopvz-api")), pkgLogger.Discard(),
+		"groups", nil, entity.RoleEmployee, time.Minute, false, "other-secret")
+	_, err = foreign.Callback(context.Background(), session, state, code)
+	assert.ErrorIs(t, err, pkgValidator.ErrInvalidOIDCState)
+
+	// Просроченный вход не принимается
+	uc.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	_, err = uc.Callback(context.Background(), session, state, code)
+	assert.ErrorIs(t, err, pkgValidator.ErrInvalidOIDCState)
+}
+
+func TestOIDCUseCase_CallbackSecondFactor(t *testing.T) {
+	idp := oidctest.NewServer("gopvz", "client-secret")
+	defer idp.Close()
+	ctx := context.Background()
+
+	authUC, user, _ := newTOTPTestUseCase(t, entity.RoleModerator)
+	enrollment, err := authUC.EnrollTOTP(ctx, user.ID.String())
+	require.NoError(t, err)
+	recoveryCodes, err := authUC.ConfirmTOTP(ctx, user.ID.String(), currentCode(t, enrollment.Secret))
+	require.NoError(t, err)
+
+	idp.SetUser(map[string]any{"sub": "1", "email": user.Email, "email_verified": true, "groups": []string{"pvz-moderators"}})
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("UpsertExternalUser", mock.Anything, mock.Anything, entity.RoleModerator).Return(user, nil)
+
+	provider := pkgOIDC.New(idp.Config("http://localhost/auth/oidc/callback"))
+	uc := NewOIDCUseCase(provider, mockRepo, authUC, pkgLogger.Discard(),
+		"groups", []RoleMapping{{Group: "pvz-moderators", Role: entity.RoleModerator}}, "", time.Minute, false, "state-secret")
+
+	authURL, session, err := uc.Begin(ctx)
+	require.NoError(t, err)
+	state, code := followLogin(t, authURL)
+
+	// Модератор с включенным вторым фактором получает промежуточный токен, а не токен доступа
+	result, err := uc.Callback(ctx, session, state, code)
+	require.NoError(t, err)
+	assert.Empty(t, result.Token)
+	require.NotNil(t, result.Challenge)
+	assert.False(t, result.Challenge.EnrollmentRequired)
+
+	_, err = authUC.CompleteLogin(ctx, result.Challenge.Token, "000000")
+	assert.ErrorIs(t, err, pkgValidator.ErrInvalidTOTPCode)
+
+	completed, err := authUC.CompleteLogin(ctx, result.Challenge.Token, recoveryCodes[0])
+	require.NoError(t, err)
+	claims, err := authUC.GetJwtManager().VerifyToken(completed.Token)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID.String(), claims.Subject)
+	assert.Equal(t, string(entity.RoleModerator), claims.Role)
+}
+
+func TestParseRoleMappings(t *testing.T) {
+	roles, err := ParseRoleMappings([]string{"pvz-moderators = moderator", "staff=employee"})
+	require.NoError(t, err)
+	assert.Equal(t, []RoleMapping{
+		{Group: "pvz-moderators", Role: entity.RoleModerator},
+		{Group: "staff", Role: entity.RoleEmployee},
+	}, roles)
+
+	_, err = ParseRoleMappings([]string{"staff"})
+	assert.ErrorIs(t, err, pkgValidator.ErrInvalidInput)
+	_, err = ParseRoleMappings([]string{"staff=root"})
+	assert.ErrorIs(t, err, pkgValidator.ErrInvalidRole)
+}
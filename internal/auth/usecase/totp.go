@@ -0,0 +1,244 @@
+package usecase
+
+import (
+	"GoPVZ/internal/auth/entity"
+	"GoPVZ/pkg/pkgTOTP"
+	"GoPVZ/pkg/pkgTracing"
+	"GoPVZ/pkg/pkgValidator"
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	_recoveryCodeCount = 10
+	// _totpSkew — сколько соседних шагов принимается из-за расхождения часов
+	_totpSkew = 1
+)
+
+// MFAPolicy задает, когда при входе нужен второй фактор.
+type MFAPolicy struct {
+	// RequiredRoles — роли, которым второй фактор обязателен; остальные включают его сами
+	RequiredRoles []entity.Role
+	// ChallengeTTL — сколько действует промежуточный токен после проверки пароля
+	ChallengeTTL time.Duration
+	// Issuer — имя сервиса в приложении-аутентификаторе
+	Issuer string
+}
+
+func (p MFAPolicy) requires(role entity.Role) bool {
+	return slices.Contains(p.RequiredRoles, role)
+}
+
+// LoginResult — итог проверки пароля: либо Token, либо Challenge.
+type LoginResult struct {
+	Token     string
+	Challenge *MFAChallenge
+}
+
+// MFAChallenge — промежуточный токен, с которым вход завершается кодом TOTP
+// или кодом восстановления. Доступа к API он не дает.
+type MFAChallenge struct {
+	Token     string
+	ExpiresAt time.Time
+	// EnrollmentRequired — второй фактор обязателен, но не настроен: сначала
+	// EnrollTOTPWithChallenge, затем CompleteLogin с кодом из приложения
+	EnrollmentRequired bool
+}
+
+// TwoFactorLogin — итог CompleteLogin. RecoveryCodes заполнены, только если
+// при входе был включен второй фактор.
+type TwoFactorLogin struct {
+	Token         string
+	RecoveryCodes []string
+}
+
+// EnrollTOTP выдает новый секрет. Второй фактор включается после ConfirmTOTP;
+// до этого EnrollTOTP можно повторять, старый секрет заменяется.
+func (uc *AuthUseCase) EnrollTOTP(ctx context.Context, userID string) (_ *entity.TOTPEnrollment, err error) {
+	ctx, span := _tracer.Start(ctx, "AuthUseCase.EnrollTOTP")
+	defer func() { pkgTracing.End(span, err) }()
+
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, pkgValidator.ErrUserNotFound
+	}
+	user, err := uc.repo.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return uc.enrollTOTP(ctx, user)
+}
+
+// ConfirmTOTP включает второй фактор по первому коду из приложения и возвращает
+// коды восстановления. Коды показываются один раз.
+func (uc *AuthUseCase) ConfirmTOTP(ctx context.Context, userID, code string) (_ []string, err error) {
+	ctx, span := _tracer.Start(ctx, "AuthUseCase.ConfirmTOTP")
+	defer func() { pkgTracing.End(span, err) }()
+
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, pkgValidator.ErrUserNotFound
+	}
+	return uc.confirmTOTP(ctx, id, code)
+}
+
+// EnrollTOTPWithChallenge — EnrollTOTP для пользователя, которому второй фактор
+// обязателен, но еще не настроен: токена доступа у него нет, только промежуточный.
+func (uc *AuthUseCase) EnrollTOTPWithChallenge(ctx context.Context, challengeToken string) (_ *entity.TOTPEnrollment, err error) {
+	ctx, span := _tracer.Start(ctx, "AuthUseCase.EnrollTOTPWithChallenge")
+	defer func() { pkgTracing.End(span, err) }()
+
+	user, err := uc.challengeUser(ctx, challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	return uc.enrollTOTP(ctx, user)
+}
+
+// CompleteLogin завершает вход после Login: принимает код TOTP или код
+// восстановления. Если второй фактор еще не подтвержден, код его подтверждает.
+// Неверные коды засчитываются в блокировку аккаунта, как неверный пароль.
+func (uc *AuthUseCase) CompleteLogin(ctx context.Context, challengeToken, code string) (_ *TwoFactorLogin, err error) {
+	ctx, span := _tracer.Start(ctx, "AuthUseCase.CompleteLogin")
+	defer func() { pkgTracing.End(span, err) }()
+
+	user, err := uc.challengeUser(ctx, challengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	lockedFor, err := uc.attempts.LockedFor(ctx, lockoutKey(user.Email))
+	if err != nil {
+		return nil, err
+	}
+	if lockedFor > 0 {
+		return nil, &LockedError{RetryAfter: lockedFor}
+	}
+
+	totp, err := uc.totp.Get(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TwoFactorLogin{}
+	if totp.Enabled() {
+		err = uc.verifySecondFactor(ctx, totp, code)
+	} else {
+		result.RecoveryCodes, err = uc.confirmTOTP(ctx, user.ID, code)
+	}
+	if errors.Is(err, pkgValidator.ErrInvalidTOTPCode) {
+		return nil, uc.failLogin(ctx, user.Email, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.attempts.Reset(ctx, lockoutKey(user.Email)); err != nil {
+		return nil, err
+	}
+	result.Token, err = uc.jwtManager.GenerateToken(user)
+	if err != nil {
+		return nil, err
+	}
+	uc.log.InfoContext(ctx, "User logged in with second factor", slog.String("user_id", user.ID.String()))
+	return result, nil
+}
+
+func (uc *AuthUseCase) challengeUser(ctx context.Context, challengeToken string) (*entity.User, error) {
+	id, err := uc.jwtManager.VerifyChallengeToken(challengeToken)
+	if err != nil {
+		return nil, pkgValidator.ErrInvalidMFAChallenge
+	}
+	user, err := uc.repo.GetById(ctx, id)
+	if errors.Is(err, pkgValidator.ErrUserNotFound) {
+		return nil, pkgValidator.ErrInvalidMFAChallenge
+	}
+	return user, err
+}
+
+func (uc *AuthUseCase) enrollTOTP(ctx context.Context, user *entity.User) (*entity.TOTPEnrollment, error) {
+	secret, err := pkgTOTP.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.totp.SaveSecret(ctx, user.ID, secret, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	return &entity.TOTPEnrollment{
+		Secret: secret,
+		URI:    pkgTOTP.URI(uc.mfa.Issuer, user.Email, secret),
+	}, nil
+}
+
+func (uc *AuthUseCase) confirmTOTP(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	totp, err := uc.totp.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if totp.Enabled() {
+		return nil, pkgValidator.ErrTOTPAlreadyEnabled
+	}
+
+	step, ok := pkgTOTP.Validate(totp.Secret, strings.TrimSpace(code), time.Now(), _totpSkew)
+	if !ok {
+		return nil, pkgValidator.ErrInvalidTOTPCode
+	}
+
+	codes := make([]string, 0, _recoveryCodeCount)
+	hashes := make([]string, 0, _recoveryCodeCount)
+	for range _recoveryCodeCount {
+		recoveryCode, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, recoveryCode)
+		hashes = append(hashes, hashSecretToken(normalizeRecoveryCode(recoveryCode)))
+	}
+
+	if err := uc.totp.Confirm(ctx, userID, step, hashes, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	uc.log.InfoContext(ctx, "Two-factor authentication enabled", slog.String("user_id", userID.String()))
+	return codes, nil
+}
+
+// verifySecondFactor принимает код TOTP, а если он не подошел — код восстановления.
+func (uc *AuthUseCase) verifySecondFactor(ctx context.Context, totp *entity.TOTP, code string) error {
+	if step, ok := pkgTOTP.Validate(totp.Secret, strings.TrimSpace(code), time.Now(), _totpSkew); ok {
+		return uc.totp.UseStep(ctx, totp.UserID, step)
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	if normalized == "" {
+		return pkgValidator.ErrInvalidTOTPCode
+	}
+	if err := uc.totp.UseRecoveryCode(ctx, totp.UserID, hashSecretToken(normalized), time.Now().UTC()); err != nil {
+		return err
+	}
+	uc.log.WarnContext(ctx, "Recovery code used", slog.String("user_id", totp.UserID.String()))
+	return nil
+}
+
+// newRecoveryCode генерирует код вида "abcde-fghij".
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// normalizeRecoveryCode позволяет вводить код без дефиса и в любом регистре.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
@@ -0,0 +1,183 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"GoPVZ/internal/auth/entity"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgTOTP"
+	"GoPVZ/pkg/pkgValidator"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// memoryTOTPRepo — хранилище второго фактора с той же семантикой, что у базы.
+type memoryTOTPRepo struct {
+	totp     map[uuid.UUID]*entity.TOTP
+	recovery map[uuid.UUID]map[string]bool
+}
+
+func newMemoryTOTPRepo() *memoryTOTPRepo {
+	return &memoryTOTPRepo{totp: map[uuid.UUID]*entity.TOTP{}, recovery: map[uuid.UUID]map[string]bool{}}
+}
+
+func (r *memoryTOTPRepo) Get(_ context.Context, userID uuid.UUID) (*entity.TOTP, error) {
+	t, ok := r.totp[userID]
+	if !ok {
+		return nil, pkgValidator.ErrTOTPNotEnrolled
+	}
+	copied := *t
+	return &copied, nil
+}
+
+func (r *memoryTOTPRepo) SaveSecret(_ context.Context, userID uuid.UUID, secret string, at time.Time) error {
+	if r.totp[userID].Enabled() {
+		return pkgValidator.ErrTOTPAlreadyEnabled
+	}
+	r.totp[userID] = &entity.TOTP{UserID: userID, Secret: secret, CreatedAt: at}
+	return nil
+}
+
+func (r *memoryTOTPRepo) Confirm(_ context.Context, userID uuid.UUID, step int64, hashes []string, at time.Time) error {
+	t, ok := r.totp[userID]
+	if !ok || t.Enabled() {
+		return pkgValidator.ErrTOTPAlreadyEnabled
+	}
+	t.ConfirmedAt, t.LastStep = &at, step
+	r.recovery[userID] = map[string]bool{}
+	for _, h := range hashes {
+		r.recovery[userID][h] = true
+	}
+	return nil
+}
+
+func (r *memoryTOTPRepo) UseStep(_ context.Context, userID uuid.UUID, step int64) error {
+	t, ok := r.totp[userID]
+	if !ok || !t.Enabled() || step <= t.LastStep {
+		return pkgValidator.ErrInvalidTOTPCode
+	}
+	t.LastStep = step
+	return nil
+}
+
+func (r *memoryTOTPRepo) UseRecoveryCode(_ context.Context, userID uuid.UUID, codeHash string, _ time.Time) error {
+	if !r.recovery[userID][codeHash] {
+		return pkgValidator.ErrInvalidTOTPCode
+	}
+	delete(r.recovery[userID], codeHash)
+	return nil
+}
+
+func newTOTPTestUseCase(t *testing.T, role entity.Role) (*AuthUseCase, *entity.User, *memoryTOTPRepo) {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("pvz-Secret-42"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := &entity.User{ID: uuid.New(), Email: "mod@example.com", PasswordHash: string(hash), Role: role}
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	mockRepo.On("GetById", mock.Anything, user.ID).Return(user, nil)
+
+	totp := newMemoryTOTPRepo()
+	uc := NewAuthUseCase(mockRepo, totp, NewJwtManager("secret", time.Hour, "gopvz", "gopvz-api"),
+		NewMemoryLoginAttempts(3, time.Minute, time.Minute), &fakeNotifier{}, pkgLogger.Discard(), time.Hour,
+		MFAPolicy{RequiredRoles: []entity.Role{entity.RoleModerator}, ChallengeTTL: 5 * time.Minute, Issuer: "GoPVZ"})
+	return uc, user, totp
+}
+
+func currentCode(t *testing.T, secret string) string {
+	t.Helper()
+	code, err := pkgTOTP.Code(secret, pkgTOTP.Step(time.Now()))
+	require.NoError(t, err)
+	return code
+}
+
+func TestAuthUseCase_TwoFactorEnrollmentOnLogin(t *testing.T) {
+	uc, user, totp := newTOTPTestUseCase(t, entity.RoleModerator)
+	ctx := context.Background()
+
+	// Модератору без второго фактора токен не выдается, только вызов с настройкой
+	result, err := uc.Login(ctx, user.Email, "pvz-Secret-42")
+	require.NoError(t, err)
+	assert.Empty(t, result.Token)
+	require.NotNil(t, result.Challenge)
+	assert.True(t, result.Challenge.EnrollmentRequired)
+
+	// Промежуточный токен не является access-токеном
+	_, err = uc.GetJwtManager().VerifyToken(result.Challenge.Token)
+	assert.Error(t, err)
+
+	enrollment, err := uc.EnrollTOTPWithChallenge(ctx, result.Challenge.Token)
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/GoPVZ:mod@example.com")
+
+	_, err = uc.CompleteLogin(ctx, result.Challenge.Token, "000000x")
+	assert.ErrorIs(t, err, pkgValidator.ErrInvalidTOTPCode)
+
+	completed, err := uc.CompleteLogin(ctx, result.Challenge.Token, currentCode(t, enrollment.Secret))
+	require.NoError(t, err)
+	assert.NotEmpty(t, completed.Token)
+	assert.Len(t, completed.RecoveryCodes, _recoveryCodeCount)
+	assert.True(t, totp.totp[user.ID].Enabled())
+
+	// После включения секрет не перевыпускается
+	_, err = uc.EnrollTOTPWithChallenge(ctx, result.Challenge.Token)
+	assert.ErrorIs(t, err, pkgValidator.ErrTOTPAlreadyEnabled)
+}
+
+func TestAuthUseCase_TwoFactorLogin(t *testing.T) {
+	uc, user, totp := newTOTPTestUseCase(t, entity.RoleEmployee)
+	ctx := context.Background()
+
+	// Сотруднику второй фактор не обязателен
+	result, err := uc.Login(ctx, user.Email, "pvz-Secret-42")
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.Token)
+
+	enrollment, err := uc.EnrollTOTP(ctx, user.ID.String())
+	require.NoError(t, err)
+	// Код, принятый при подтверждении, нельзя использовать повторно
+	confirmCode := currentCode(t, enrollment.Secret)
+	recoveryCodes, err := uc.ConfirmTOTP(ctx, user.ID.String(), confirmCode)
+	require.NoError(t, err)
+	require.Len(t, recoveryCodes, _recoveryCodeCount)
+
+	result, err = uc.Login(ctx, user.Email, "pvz-Secret-42")
+	require.NoError(t, err)
+	require.NotNil(t, result.Challenge)
+	assert.False(t, result.Challenge.EnrollmentRequired)
+
+	_, err = uc.CompleteLogin(ctx, result.Challenge.Token, confirmCode)
+	assert.ErrorIs(t, err, pkgValidator.ErrInvalidTOTPCode)
+
+	// Код восстановления принимается в любом регистре и без дефиса, но один раз
+	recovery := recoveryCodes[0]
+	completed, err := uc.CompleteLogin(ctx, result.Challenge.Token, " "+strings.ToUpper(recovery[:5]+recovery[6:])+" ")
+	require.NoError(t, err)
+	assert.NotEmpty(t, completed.Token)
+	assert.Empty(t, completed.RecoveryCodes)
+
+	_, err = uc.CompleteLogin(ctx, result.Challenge.Token, recovery)
+	assert.ErrorIs(t, err, pkgValidator.ErrInvalidTOTPCode)
+
+	// Неверные коды засчитываются в блокировку аккаунта
+	for range 2 {
+		_, err = uc.CompleteLogin(ctx, result.Challenge.Token, "bad-code")
+		assert.ErrorIs(t, err, pkgValidator.ErrInvalidTOTPCode)
+	}
+	_, err = uc.CompleteLogin(ctx, result.Challenge.Token, recoveryCodes[1])
+	var locked *LockedError
+	assert.ErrorAs(t, err, &locked)
+	assert.Len(t, totp.recovery[user.ID], _recoveryCodeCount-1)
+
+	_, err = uc.CompleteLogin(ctx, "not-a-token", recoveryCodes[1])
+	assert.ErrorIs(t, err, pkgValidator.ErrInvalidMFAChallenge)
+}
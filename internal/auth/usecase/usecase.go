@@ -2,42 +2,112 @@ package usecase
 
 import (
 	"GoPVZ/internal/auth/entity"
+	"GoPVZ/internal/auth/notifier"
 	"GoPVZ/internal/auth/repo"
+	pvzEntity "GoPVZ/internal/pvz/entity"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgTracing"
 	"GoPVZ/pkg/pkgValidator"
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
 	"golang.org/x/crypto/bcrypt"
 )
 
+var _tracer = otel.Tracer("GoPVZ/internal/auth/usecase")
+
+// _dummyPasswordHash сравнивается с паролем, когда у email нет пароля: ответ
+// для неизвестного email занимает столько же времени, сколько для неверного пароля.
+var _dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, err := bcrypt.GenerateFromPassword([]byte("gopvz-dummy-password"), bcrypt.DefaultCost)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+})
+
 type AuthUseCase struct {
-	repo       repo.UserRepository
-	jwtManager *JwtManager
+	repo          repo.UserRepository
+	totp          repo.TOTPRepository
+	jwtManager    *JwtManager
+	attempts      LoginAttempts
+	notifier      notifier.Notifier
+	log           pkgLogger.Interface
+	resetTokenTTL time.Duration
+	mfa           MFAPolicy
+}
+
+// LockedError возвращается при входе в заблокированный аккаунт.
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return pkgValidator.ErrAccountLocked.Error()
+}
+
+func (e *LockedError) Unwrap() error {
+	return pkgValidator.ErrAccountLocked
 }
 
 func (uc *AuthUseCase) GetJwtManager() *JwtManager {
 	return uc.jwtManager
 }
 
-func NewAuthUseCase(r repo.UserRepository, jm *JwtManager) *AuthUseCase {
-	return &AuthUseCase{repo: r, jwtManager: jm}
+func NewAuthUseCase(
+	r repo.UserRepository,
+	totp repo.TOTPRepository,
+	jm *JwtManager,
+	attempts LoginAttempts,
+	n notifier.Notifier,
+	log pkgLogger.Interface,
+	resetTokenTTL time.Duration,
+	mfa MFAPolicy,
+) *AuthUseCase {
+	return &AuthUseCase{
+		repo:          r,
+		totp:          totp,
+		jwtManager:    jm,
+		attempts:      attempts,
+		notifier:      n,
+		log:           log,
+		resetTokenTTL: resetTokenTTL,
+		mfa:           mfa,
+	}
 }
 
-func (uc *AuthUseCase) DummyLogin(ctx context.Context, role string) (string, error) {
-	if role != string(entity.RoleEmployee) && role != string(entity.RoleModerator) {
+func (uc *AuthUseCase) DummyLogin(ctx context.Context, role string, regions []string) (_ string, err error) {
+	ctx, span := _tracer.Start(ctx, "AuthUseCase.DummyLogin")
+	defer func() { pkgTracing.End(span, err) }()
+
+	if !entity.Role(role).IsSelfAssignable() {
 		return "", pkgValidator.ErrInvalidRole
 	}
 
 	user := &entity.User{
-		ID:    uuid.New(),
-		Email: "dummy@pvz",
-		Role:  entity.Role(role),
+		ID:      uuid.New(),
+		Email:   "dummy@pvz",
+		Role:    entity.Role(role),
+		Regions: regions,
 	}
 	return uc.jwtManager.GenerateToken(user)
 }
 
-func (uc *AuthUseCase) Register(ctx context.Context, email, password, role string) (*entity.User, error) {
-	if role != string(entity.RoleEmployee) && role != string(entity.RoleModerator) {
+func (uc *AuthUseCase) Register(ctx context.Context, email, password, role string) (_ *entity.User, err error) {
+	ctx, span := _tracer.Start(ctx, "AuthUseCase.Register")
+	defer func() { pkgTracing.End(span, err) }()
+
+	if !entity.Role(role).IsSelfAssignable() {
 		return nil, pkgValidator.ErrInvalidRole
 	}
 
@@ -60,16 +130,280 @@ func (uc *AuthUseCase) Register(ctx context.Context, email, password, role strin
 	if err := uc.repo.Create(ctx, user); err != nil {
 		return nil, err
 	}
+	uc.log.InfoContext(ctx, "User registered", slog.String("user_id", user.ID.String()), slog.String("role", role))
 	return user, nil
 }
 
-func (uc *AuthUseCase) Login(ctx context.Context, email, password string) (string, error) {
+// Login проверяет пароль. После серии неудачных попыток аккаунт блокируется:
+// пока блокировка действует, пароль не проверяется и возвращается *LockedError.
+// Если пользователю нужен второй фактор, вместо токена возвращается MFAChallenge,
+// а вход завершает CompleteLogin.
+func (uc *AuthUseCase) Login(ctx context.Context, email, password string) (_ *LoginResult, err error) {
+	ctx, span := _tracer.Start(ctx, "AuthUseCase.Login")
+	defer func() { pkgTracing.End(span, err) }()
+
+	lockedFor, err := uc.attempts.LockedFor(ctx, lockoutKey(email))
+	if err != nil {
+		return nil, err
+	}
+	if lockedFor > 0 {
+		uc.log.WarnContext(ctx, "Login rejected: account is locked", slog.String("email", email), slog.Duration("retry_after", lockedFor))
+		return nil, &LockedError{RetryAfter: lockedFor}
+	}
+
+	// Неизвестный email и аккаунт без пароля (SSO) неотличимы от неверного
+	// пароля ни по ответу, ни по времени, и тоже считаются в блокировку
 	user, err := uc.repo.GetByEmail(ctx, email)
+	if errors.Is(err, pkgValidator.ErrUserNotFound) || (err == nil && user.PasswordHash == "") {
+		_ = bcrypt.CompareHashAndPassword(_dummyPasswordHash(), []byte(password))
+		return nil, uc.failLogin(ctx, email, pkgValidator.ErrInvalidCredentials)
+	}
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return "", pkgValidator.ErrInvalidCredentials
+		return nil, uc.failLogin(ctx, email, pkgValidator.ErrInvalidCredentials)
 	}
-	return uc.jwtManager.GenerateToken(user)
+	return uc.IssueLogin(ctx, user)
+}
+
+// IssueLogin завершает вход пользователя, подтвердившего первый фактор (пароль
+// или SSO): выдает токен либо MFAChallenge, если нужен второй фактор.
+func (uc *AuthUseCase) IssueLogin(ctx context.Context, user *entity.User) (*LoginResult, error) {
+	totp, err := uc.totp.Get(ctx, user.ID)
+	if err != nil && !errors.Is(err, pkgValidator.ErrTOTPNotEnrolled) {
+		return nil, err
+	}
+	if totp.Enabled() || uc.mfa.requires(user.Role) {
+		// Счетчик неудач не сбрасывается до проверки второго фактора: иначе код
+		// можно было бы перебирать, чередуя его с верным паролем
+		challenge, expiresAt, err := uc.jwtManager.GenerateChallengeToken(user.ID, uc.mfa.ChallengeTTL)
+		if err != nil {
+			return nil, err
+		}
+		uc.log.DebugContext(ctx, "First factor accepted, second factor required", slog.String("user_id", user.ID.String()))
+		return &LoginResult{Challenge: &MFAChallenge{
+			Token:              challenge,
+			ExpiresAt:          expiresAt,
+			EnrollmentRequired: !totp.Enabled(),
+		}}, nil
+	}
+
+	if err := uc.attempts.Reset(ctx, lockoutKey(user.Email)); err != nil {
+		return nil, err
+	}
+	token, err := uc.jwtManager.GenerateToken(user)
+	if err != nil {
+		return nil, err
+	}
+	uc.log.InfoContext(ctx, "User logged in", slog.String("user_id", user.ID.String()))
+	return &LoginResult{Token: token}, nil
+}
+
+// failLogin засчитывает неудачную попытку входа и возвращает cause.
+func (uc *AuthUseCase) failLogin(ctx context.Context, email string, cause error) error {
+	uc.log.WarnContext(ctx, "Login failed", slog.String("email", email), slog.String("reason", cause.Error()))
+	if err := uc.attempts.Fail(ctx, lockoutKey(email)); err != nil {
+		return errors.Join(cause, err)
+	}
+	return cause
+}
+
+// Me возвращает профиль пользователя из токена.
+func (uc *AuthUseCase) Me(ctx context.Context, userID string, tokenExpiresAt time.Time) (_ *entity.Profile, err error) {
+	ctx, span := _tracer.Start(ctx, "AuthUseCase.Me")
+	defer func() { pkgTracing.End(span, err) }()
+
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, pkgValidator.ErrUserNotFound
+	}
+
+	user, err := uc.repo.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	pvzIDs, err := uc.repo.GetPVZAssignments(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.Profile{User: user, PvzIDs: pvzIDs, TokenExpiresAt: tokenExpiresAt}, nil
+}
+
+// SetRegions задает города пользователя: "*" — все города, пустой список — ни
+// одного. Действует для токенов, выданных после изменения. Свои регионы менять
+// нельзя, а редактор, ограниченный scope, назначает и меняет только города из
+// своего scope.
+func (uc *AuthUseCase) SetRegions(ctx context.Context, scope pvzEntity.Scope, editorID, userID string, regions []string) (_ *entity.User, err error) {
+	ctx, span := _tracer.Start(ctx, "AuthUseCase.SetRegions")
+	defer func() { pkgTracing.End(span, err) }()
+
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, pkgValidator.ErrUserNotFound
+	}
+	if userID == editorID {
+		return nil, pkgValidator.ErrOwnRegions
+	}
+
+	if scope.Restricted() {
+		user, err := uc.repo.GetById(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if !coversRegions(scope, user.Regions) || !coversRegions(scope, regions) {
+			uc.log.WarnContext(ctx, "User regions change rejected: regions are outside of editor scope",
+				slog.String("user_id", userID), slog.String("editor_id", editorID), slog.Any("regions", regions))
+			return nil, pkgValidator.ErrRegionNotHeld
+		}
+	}
+
+	user, err := uc.repo.UpdateRegions(ctx, id, regions)
+	if err != nil {
+		return nil, err
+	}
+	uc.log.InfoContext(ctx, "User regions changed", slog.String("user_id", userID), slog.String("editor_id", editorID), slog.Any("regions", regions))
+	return user, nil
+}
+
+// coversRegions сообщает, входят ли все регионы в scope.
+func coversRegions(scope pvzEntity.Scope, regions []string) bool {
+	regionsScope := pvzEntity.NewScope(regions)
+	if !regionsScope.Restricted() {
+		return !scope.Restricted()
+	}
+	for _, city := range regionsScope.Cities {
+		if !scope.Allows(city) {
+			return false
+		}
+	}
+	return true
+}
+
+// ChangePassword меняет пароль пользователя после проверки текущего.
+func (uc *AuthUseCase) ChangePassword(ctx context.Context, userID, oldPassword, newPassword string) (err error) {
+	ctx, span := _tracer.Start(ctx, "AuthUseCase.ChangePassword")
+	defer func() { pkgTracing.End(span, err) }()
+
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return pkgValidator.ErrUserNotFound
+	}
+
+	user, err := uc.repo.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// Текущий пароль проверяется под той же блокировкой, что и вход: иначе
+	// украденным токеном можно было бы перебирать пароль в обход /login
+	lockedFor, err := uc.attempts.LockedFor(ctx, lockoutKey(user.Email))
+	if err != nil {
+		return err
+	}
+	if lockedFor > 0 {
+		uc.log.WarnContext(ctx, "Password change rejected: account is locked", slog.String("user_id", userID), slog.Duration("retry_after", lockedFor))
+		return &LockedError{RetryAfter: lockedFor}
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(oldPassword)); err != nil {
+		uc.log.WarnContext(ctx, "Password change rejected: wrong current password", slog.String("user_id", userID))
+		if err := uc.attempts.Fail(ctx, lockoutKey(user.Email)); err != nil {
+			return errors.Join(pkgValidator.ErrInvalidCredentials, err)
+		}
+		return pkgValidator.ErrInvalidCredentials
+	}
+	if err := uc.attempts.Reset(ctx, lockoutKey(user.Email)); err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := uc.repo.UpdatePasswordHash(ctx, user.ID, string(hash)); err != nil {
+		return err
+	}
+	uc.log.InfoContext(ctx, "Password changed", slog.String("user_id", userID))
+	return nil
+}
+
+// RequestPasswordReset отправляет пользователю одноразовый токен сброса пароля.
+// Для неизвестного email ничего не делает и не возвращает ошибку, чтобы по ответу
+// нельзя было проверить, зарегистрирован ли адрес.
+func (uc *AuthUseCase) RequestPasswordReset(ctx context.Context, email string) (err error) {
+	ctx, span := _tracer.Start(ctx, "AuthUseCase.RequestPasswordReset")
+	defer func() { pkgTracing.End(span, err) }()
+
+	user, err := uc.repo.GetByEmail(ctx, email)
+	if errors.Is(err, pkgValidator.ErrUserNotFound) {
+		uc.log.DebugContext(ctx, "Password reset requested for unknown email", slog.String("email", email))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := newSecretToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	resetToken := &entity.PasswordResetToken{
+		TokenHash: hashSecretToken(token),
+		UserID:    user.ID,
+		ExpiresAt: now.Add(uc.resetTokenTTL),
+		CreatedAt: now,
+	}
+	if err := uc.repo.CreateResetToken(ctx, resetToken); err != nil {
+		return err
+	}
+	uc.log.InfoContext(ctx, "Password reset requested", slog.String("user_id", user.ID.String()))
+
+	return uc.notifier.Notify(ctx, notifier.Message{
+		To:      user.Email,
+		Subject: "Сброс пароля GoPVZ",
+		Body: fmt.Sprintf(
+			"Токен для сброса пароля: %s\nДействует до %s. Если вы не запрашивали сброс, проигнорируйте это письмо.",
+			token, resetToken.ExpiresAt.Format(time.RFC3339),
+		),
+	})
+}
+
+// ResetPassword устанавливает новый пароль по токену из RequestPasswordReset.
+// Токен одноразовый: после успешного сброса он и остальные токены пользователя недействительны.
+func (uc *AuthUseCase) ResetPassword(ctx context.Context, token, newPassword string) (err error) {
+	ctx, span := _tracer.Start(ctx, "AuthUseCase.ResetPassword")
+	defer func() { pkgTracing.End(span, err) }()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	user, err := uc.repo.ResetPassword(ctx, hashSecretToken(token), string(hash))
+	if err != nil {
+		return err
+	}
+	uc.log.InfoContext(ctx, "Password reset", slog.String("user_id", user.ID.String()))
+
+	// Новый пароль снимает блокировку после неудачных попыток входа
+	return uc.attempts.Reset(ctx, lockoutKey(user.Email))
+}
+
+// newSecretToken генерирует токен сброса пароля или API ключ; в базе хранится
+// только hashSecretToken от него.
+func newSecretToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashSecretToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
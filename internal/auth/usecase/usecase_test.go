@@ -3,12 +3,16 @@ package usecase
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"GoPVZ/internal/auth/entity"
+	"GoPVZ/internal/auth/notifier"
 	"GoPVZ/internal/auth/validation"
 	"GoPVZ/internal/dto"
+	pvzEntity "GoPVZ/internal/pvz/entity"
+	"GoPVZ/pkg/pkgLogger"
 	"GoPVZ/pkg/pkgValidator"
 
 	"github.com/google/uuid"
@@ -32,6 +36,56 @@ func (m *MockUserRepo) GetByEmail(ctx context.Context, email string) (*entity.Us
 	return args.Get(0).(*entity.User), args.Error(1)
 }
 
+func (m *MockUserRepo) GetById(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepo) GetPVZAssignments(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockUserRepo) UpdateRegions(ctx context.Context, id uuid.UUID, regions []string) (*entity.User, error) {
+	args := m.Called(ctx, id, regions)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepo) UpsertExternalUser(ctx context.Context, identity entity.ExternalIdentity, role entity.Role) (*entity.User, error) {
+	args := m.Called(ctx, identity, role)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepo) UpdatePasswordHash(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	args := m.Called(ctx, id, passwordHash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) CreateResetToken(ctx context.Context, token *entity.PasswordResetToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (*entity.User, error) {
+	args := m.Called(ctx, tokenHash, passwordHash)
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+type fakeNotifier struct {
+	messages []notifier.Message
+}
+
+func (n *fakeNotifier) Notify(_ context.Context, msg notifier.Message) error {
+	n.messages = append(n.messages, msg)
+	return nil
+}
+
 // Unit-тесты для usecase
 func TestAuthUseCase_Register(t *testing.T) {
 	tests := []struct {
@@ -44,7 +98,7 @@ func TestAuthUseCase_Register(t *testing.T) {
 			name: "success",
 			payload: dto.PostRegisterJSONBody{
 				Email:    "test@example.com",
-				Password: "password123",
+				Password: "pvz-Secret-42",
 				Role:     "employee",
 			},
 			repoError: nil,
@@ -54,7 +108,7 @@ func TestAuthUseCase_Register(t *testing.T) {
 			name: "user exists",
 			payload: dto.PostRegisterJSONBody{
 				Email:    "exists@example.com",
-				Password: "password123",
+				Password: "pvz-Secret-42",
 				Role:     "moderator",
 			},
 			repoError: pkgValidator.ErrUserExists,
@@ -64,7 +118,7 @@ func TestAuthUseCase_Register(t *testing.T) {
 			name: "invalid email",
 			payload: dto.PostRegisterJSONBody{
 				Email:    "invalid-email",
-				Password: "password123",
+				Password: "pvz-Secret-42",
 				Role:     "employee",
 			},
 			wantError: true,
@@ -82,17 +136,35 @@ func TestAuthUseCase_Register(t *testing.T) {
 			name: "invalid role",
 			payload: dto.PostRegisterJSONBody{
 				Email:    "test@example.com",
-				Password: "password123",
+				Password: "pvz-Secret-42",
 				Role:     "invalid",
 			},
 			wantError: true,
 		},
+		{
+			name: "service role",
+			payload: dto.PostRegisterJSONBody{
+				Email:    "test@example.com",
+				Password: "pvz-Secret-42",
+				Role:     "service",
+			},
+			wantError: true,
+		},
+		{
+			name: "regional manager role",
+			payload: dto.PostRegisterJSONBody{
+				Email:    "test@example.com",
+				Password: "pvz-Secret-42",
+				Role:     "regional_manager",
+			},
+			wantError: true,
+		},
 	}
 
 	for _, tCase := range tests {
 		t.Run(tCase.name, func(t *testing.T) {
 			// Валидатор
-			validator := validation.NewRegisterValidator(tCase.payload)
+			validator := validation.NewRegisterValidator(tCase.payload, pkgValidator.DefaultPasswordPolicy())
 			if err := validator.Validate(); err != nil {
 				if !tCase.wantError {
 					t.Errorf("expected success, got validation error: %v", err)
@@ -102,8 +174,8 @@ func TestAuthUseCase_Register(t *testing.T) {
 
 			// Моки
 			mockRepo := new(MockUserRepo)
-			jm := NewJwtManager("secret", 24*time.Hour)
-			uc := NewAuthUseCase(mockRepo, jm)
+			jm := NewJwtManager("secret", 24*time.Hour, "gopvz", "gopvz-api")
+			uc := NewAuthUseCase(mockRepo, newMemoryTOTPRepo(), jm, NewMemoryLoginAttempts(5, time.Minute, time.Minute), &fakeNotifier{}, pkgLogger.Discard(), time.Hour, MFAPolicy{})
 
 			if tCase.repoError != nil {
 				mockRepo.On("GetByEmail", mock.Anything, string(tCase.payload.Email)).Return(&entity.User{}, nil)
@@ -127,9 +199,25 @@ func TestAuthUseCase_Register(t *testing.T) {
 	}
 }
 
+// Роли, выдаваемые администратором, нельзя получить регистрацией или
+// /dummyLogin даже в обход валидатора обработчика
+func TestAuthUseCase_SelfAssignedRoles(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	jm := NewJwtManager("secret", 24*time.Hour, "gopvz", "gopvz-api")
+	uc := NewAuthUseCase(mockRepo, newMemoryTOTPRepo(), jm, NewMemoryLoginAttempts(5, time.Minute, time.Minute), &fakeNotifier{}, pkgLogger.Discard(), time.Hour, MFAPolicy{})
+
+	for _, role := range []entity.Role{entity.RoleService, entity.RoleRegionalManager, entity.RoleAuditor} {
+		_, err := uc.Register(context.Background(), "test@example.com", "pvz-Secret-42", string(role))
+		assert.ErrorIs(t, err, pkgValidator.ErrInvalidRole, role)
+
+		_, err = uc.DummyLogin(context.Background(), string(role), nil)
+		assert.ErrorIs(t, err, pkgValidator.ErrInvalidRole, role)
+	}
+	mockRepo.AssertExpectations(t)
+}
 
 func TestAuthUseCase_Login(t *testing.T) {
-	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("pvz-Secret-42"), bcrypt.DefaultCost)
 	testUser := &entity.User{
 		ID:           uuid.New(),
 		Email:        "test@example.com",
@@ -147,7 +235,7 @@ func TestAuthUseCase_Login(t *testing.T) {
 			name: "success",
 			payload: dto.PostLoginJSONBody{
 				Email:    "test@example.com",
-				Password: "password123",
+				Password: "pvz-Secret-42",
 			},
 			setupUser: testUser,
 			wantError: false,
@@ -165,7 +253,7 @@ func TestAuthUseCase_Login(t *testing.T) {
 			name: "user not found",
 			payload: dto.PostLoginJSONBody{
 				Email:    "nonexistent@example.com",
-				Password: "password123",
+				Password: "pvz-Secret-42",
 			},
 			setupUser: nil,
 			wantError: true,
@@ -174,7 +262,7 @@ func TestAuthUseCase_Login(t *testing.T) {
 			name: "invalid email format",
 			payload: dto.PostLoginJSONBody{
 				Email:    "bad-email",
-				Password: "password123",
+				Password: "pvz-Secret-42",
 			},
 			wantError: true,
 		},
@@ -199,8 +287,8 @@ func TestAuthUseCase_Login(t *testing.T) {
 			}
 
 			mockRepo := new(MockUserRepo)
-			jm := NewJwtManager("secret", 24*time.Hour)
-			uc := NewAuthUseCase(mockRepo, jm)
+			jm := NewJwtManager("secret", 24*time.Hour, "gopvz", "gopvz-api")
+			uc := NewAuthUseCase(mockRepo, newMemoryTOTPRepo(), jm, NewMemoryLoginAttempts(5, time.Minute, time.Minute), &fakeNotifier{}, pkgLogger.Discard(), time.Hour, MFAPolicy{})
 
 			mockRepo.On("GetByEmail", mock.Anything, string(tCase.payload.Email)).Return(tCase.setupUser, func() error {
 				if tCase.setupUser == nil {
@@ -209,14 +297,15 @@ func TestAuthUseCase_Login(t *testing.T) {
 				return nil
 			}())
 
-			token, err := uc.Login(context.Background(), string(tCase.payload.Email), tCase.payload.Password)
+			result, err := uc.Login(context.Background(), string(tCase.payload.Email), tCase.payload.Password)
 
 			if tCase.wantError {
 				assert.Error(t, err)
-				assert.Empty(t, token)
+				assert.Nil(t, result)
 			} else {
 				assert.NoError(t, err)
-				assert.NotEmpty(t, token)
+				assert.NotEmpty(t, result.Token)
+				assert.Nil(t, result.Challenge)
 			}
 		})
 	}
@@ -250,10 +339,10 @@ func TestAuthUseCase_DummyLogin(t *testing.T) {
     for _, tCase := range tests {
         t.Run(tCase.name, func(t *testing.T) {
             mockRepo := new(MockUserRepo)
-            jm := NewJwtManager("secret", 24*time.Hour)
-            uc := NewAuthUseCase(mockRepo, jm)
+            jm := NewJwtManager("secret", 24*time.Hour, "gopvz", "gopvz-api")
+            uc := NewAuthUseCase(mockRepo, newMemoryTOTPRepo(), jm, NewMemoryLoginAttempts(5, time.Minute, time.Minute), &fakeNotifier{}, pkgLogger.Discard(), time.Hour, MFAPolicy{})
 
-            token, err := uc.DummyLogin(context.Background(), string(tCase.role))
+            token, err := uc.DummyLogin(context.Background(), string(tCase.role), nil)
 
             if tCase.wantError {
                 assert.Error(t, err)
@@ -265,8 +354,301 @@ func TestAuthUseCase_DummyLogin(t *testing.T) {
                 // Дополнительная проверка токена
                 claims, err := jm.VerifyToken(token)
                 assert.NoError(t, err)
-                assert.Equal(t, string(tCase.role), (*claims)["role"])
+                assert.Equal(t, string(tCase.role), claims.Role)
             }
         })
     }
-}
\ No newline at end of file
+}
+func TestAuthUseCase_LoginLockout(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("pvz-Secret-42"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	user := &entity.User{ID: uuid.New(), Email: "test@example.com", PasswordHash: string(hash), Role: entity.RoleEmployee}
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+
+	now := time.Now()
+	attempts := NewMemoryLoginAttempts(3, time.Minute, 10*time.Minute)
+	attempts.now = func() time.Time { return now }
+	uc := NewAuthUseCase(mockRepo, newMemoryTOTPRepo(), NewJwtManager("secret", time.Hour, "gopvz", "gopvz-api"), attempts, &fakeNotifier{}, pkgLogger.Discard(), time.Hour, MFAPolicy{})
+
+	for i := 0; i < 3; i++ {
+		_, err := uc.Login(context.Background(), user.Email, "wrongpassword")
+		assert.ErrorIs(t, err, pkgValidator.ErrInvalidCredentials)
+	}
+
+	// Аккаунт заблокирован: даже верный пароль не принимается
+	_, err = uc.Login(context.Background(), user.Email, "pvz-Secret-42")
+	var locked *LockedError
+	assert.ErrorAs(t, err, &locked)
+	assert.ErrorIs(t, err, pkgValidator.ErrAccountLocked)
+	assert.Equal(t, 10*time.Minute, locked.RetryAfter)
+	mockRepo.AssertNumberOfCalls(t, "GetByEmail", 3)
+
+	// После окончания блокировки вход снова возможен
+	now = now.Add(10 * time.Minute)
+	result, err := uc.Login(context.Background(), user.Email, "pvz-Secret-42")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, result.Token)
+}
+
+// Неизвестный email отвечает как неверный пароль и тоже блокируется
+func TestAuthUseCase_LoginLockout_UnknownEmail(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("GetByEmail", mock.Anything, "ghost@example.com").Return((*entity.User)(nil), pkgValidator.ErrUserNotFound)
+	uc := NewAuthUseCase(mockRepo, newMemoryTOTPRepo(), NewJwtManager("secret", time.Hour, "gopvz", "gopvz-api"), NewMemoryLoginAttempts(2, time.Minute, 10*time.Minute), &fakeNotifier{}, pkgLogger.Discard(), time.Hour, MFAPolicy{})
+
+	for i := 0; i < 2; i++ {
+		_, err := uc.Login(context.Background(), "ghost@example.com", "pvz-Secret-42")
+		assert.ErrorIs(t, err, pkgValidator.ErrInvalidCredentials)
+		assert.NotErrorIs(t, err, pkgValidator.ErrUserNotFound)
+	}
+
+	_, err := uc.Login(context.Background(), "ghost@example.com", "pvz-Secret-42")
+	assert.ErrorIs(t, err, pkgValidator.ErrAccountLocked)
+	mockRepo.AssertNumberOfCalls(t, "GetByEmail", 2)
+}
+
+// Варианты написания одного email считаются в общую блокировку
+func TestAuthUseCase_LoginLockout_EmailCase(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("pvz-Secret-42"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	user := &entity.User{ID: uuid.New(), Email: "test@example.com", PasswordHash: string(hash), Role: entity.RoleEmployee}
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("GetByEmail", mock.Anything, mock.Anything).Return(user, nil)
+	uc := NewAuthUseCase(mockRepo, newMemoryTOTPRepo(), NewJwtManager("secret", time.Hour, "gopvz", "gopvz-api"), NewMemoryLoginAttempts(3, time.Minute, 10*time.Minute), &fakeNotifier{}, pkgLogger.Discard(), time.Hour, MFAPolicy{})
+
+	for _, email := range []string{"test@example.com", "TEST@example.com", " Test@Example.com "} {
+		_, err := uc.Login(context.Background(), email, "wrongpassword")
+		assert.ErrorIs(t, err, pkgValidator.ErrInvalidCredentials)
+	}
+
+	_, err = uc.Login(context.Background(), "test@EXAMPLE.com", "pvz-Secret-42")
+	assert.ErrorIs(t, err, pkgValidator.ErrAccountLocked)
+}
+
+func TestMemoryLoginAttempts_WindowAndReset(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	attempts := NewMemoryLoginAttempts(2, time.Minute, time.Hour)
+	attempts.now = func() time.Time { return now }
+
+	// Неудачи за пределами окна не складываются
+	assert.NoError(t, attempts.Fail(ctx, "a@example.com"))
+	now = now.Add(2 * time.Minute)
+	assert.NoError(t, attempts.Fail(ctx, "a@example.com"))
+	lockedFor, err := attempts.LockedFor(ctx, "a@example.com")
+	assert.NoError(t, err)
+	assert.Zero(t, lockedFor)
+
+	// Успешный вход сбрасывает счетчик
+	assert.NoError(t, attempts.Reset(ctx, "a@example.com"))
+	assert.NoError(t, attempts.Fail(ctx, "a@example.com"))
+	lockedFor, err = attempts.LockedFor(ctx, "a@example.com")
+	assert.NoError(t, err)
+	assert.Zero(t, lockedFor)
+
+	assert.NoError(t, attempts.Fail(ctx, "a@example.com"))
+	lockedFor, err = attempts.LockedFor(ctx, "a@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour, lockedFor)
+
+	// Блокировка одного аккаунта не задевает другие
+	lockedFor, err = attempts.LockedFor(ctx, "b@example.com")
+	assert.NoError(t, err)
+	assert.Zero(t, lockedFor)
+}
+
+func TestAuthUseCase_ChangePassword(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("pvz-Secret-42"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	user := &entity.User{ID: uuid.New(), Email: "test@example.com", PasswordHash: string(hash), Role: entity.RoleEmployee}
+
+	tests := []struct {
+		name        string
+		userID      string
+		oldPassword string
+		setup       func(m *MockUserRepo)
+		wantError   error
+	}{
+		{
+			name:        "success",
+			userID:      user.ID.String(),
+			oldPassword: "pvz-Secret-42",
+			setup: func(m *MockUserRepo) {
+				m.On("GetById", mock.Anything, user.ID).Return(user, nil)
+				m.On("UpdatePasswordHash", mock.Anything, user.ID, mock.MatchedBy(func(h string) bool {
+					return bcrypt.CompareHashAndPassword([]byte(h), []byte("pvz-Secret-43")) == nil
+				})).Return(nil)
+			},
+		},
+		{
+			name:        "wrong old password",
+			userID:      user.ID.String(),
+			oldPassword: "wrong-password",
+			setup: func(m *MockUserRepo) {
+				m.On("GetById", mock.Anything, user.ID).Return(user, nil)
+			},
+			wantError: pkgValidator.ErrInvalidCredentials,
+		},
+		{
+			name:        "dummy token user",
+			userID:      "not-a-uuid",
+			oldPassword: "pvz-Secret-42",
+			setup:       func(m *MockUserRepo) {},
+			wantError:   pkgValidator.ErrUserNotFound,
+		},
+	}
+
+	for _, tCase := range tests {
+		t.Run(tCase.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepo)
+			tCase.setup(mockRepo)
+			uc := NewAuthUseCase(mockRepo, newMemoryTOTPRepo(), NewJwtManager("secret", time.Hour, "gopvz", "gopvz-api"), NewMemoryLoginAttempts(5, time.Minute, time.Minute), &fakeNotifier{}, pkgLogger.Discard(), time.Hour, MFAPolicy{})
+
+			err := uc.ChangePassword(context.Background(), tCase.userID, tCase.oldPassword, "pvz-Secret-43")
+			if tCase.wantError != nil {
+				assert.ErrorIs(t, err, tCase.wantError)
+			} else {
+				assert.NoError(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// Неверный текущий пароль считается в общую с входом блокировку
+func TestAuthUseCase_ChangePasswordLockout(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("pvz-Secret-42"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	user := &entity.User{ID: uuid.New(), Email: "test@example.com", PasswordHash: string(hash), Role: entity.RoleEmployee}
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("GetById", mock.Anything, user.ID).Return(user, nil)
+	mockRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	uc := NewAuthUseCase(mockRepo, newMemoryTOTPRepo(), NewJwtManager("secret", time.Hour, "gopvz", "gopvz-api"), NewMemoryLoginAttempts(3, time.Minute, 10*time.Minute), &fakeNotifier{}, pkgLogger.Discard(), time.Hour, MFAPolicy{})
+
+	for i := 0; i < 3; i++ {
+		err := uc.ChangePassword(context.Background(), user.ID.String(), "wrong-password", "pvz-Secret-43")
+		assert.ErrorIs(t, err, pkgValidator.ErrInvalidCredentials)
+	}
+
+	// Заблокирована и смена пароля, и вход
+	err = uc.ChangePassword(context.Background(), user.ID.String(), "pvz-Secret-42", "pvz-Secret-43")
+	var locked *LockedError
+	assert.ErrorAs(t, err, &locked)
+	_, err = uc.Login(context.Background(), user.Email, "pvz-Secret-42")
+	assert.ErrorIs(t, err, pkgValidator.ErrAccountLocked)
+	mockRepo.AssertNotCalled(t, "UpdatePasswordHash", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAuthUseCase_PasswordReset(t *testing.T) {
+	user := &entity.User{ID: uuid.New(), Email: "test@example.com", Role: entity.RoleEmployee}
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("GetByEmail", mock.Anything, "nobody@example.com").Return((*entity.User)(nil), pkgValidator.ErrUserNotFound)
+	mockRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+
+	var stored *entity.PasswordResetToken
+	mockRepo.On("CreateResetToken", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*entity.PasswordResetToken)
+	}).Return(nil)
+
+	notifications := &fakeNotifier{}
+	uc := NewAuthUseCase(mockRepo, newMemoryTOTPRepo(), NewJwtManager("secret", time.Hour, "gopvz", "gopvz-api"), NewMemoryLoginAttempts(5, time.Minute, time.Minute), notifications, pkgLogger.Discard(), 30*time.Minute, MFAPolicy{})
+
+	// Неизвестный email не выдает себя ошибкой
+	assert.NoError(t, uc.RequestPasswordReset(context.Background(), "nobody@example.com"))
+	assert.Empty(t, notifications.messages)
+
+	assert.NoError(t, uc.RequestPasswordReset(context.Background(), user.Email))
+	assert.Len(t, notifications.messages, 1)
+	assert.Equal(t, user.Email, notifications.messages[0].To)
+	assert.Equal(t, user.ID, stored.UserID)
+	assert.WithinDuration(t, time.Now().Add(30*time.Minute), stored.ExpiresAt, time.Minute)
+
+	// В базе только хеш, токен есть лишь в уведомлении
+	token := strings.Fields(notifications.messages[0].Body)[4]
+	assert.NotContains(t, stored.TokenHash, token)
+	assert.Equal(t, hashSecretToken(token), stored.TokenHash)
+
+	mockRepo.On("ResetPassword", mock.Anything, stored.TokenHash, mock.Anything).Return(user, nil)
+	assert.NoError(t, uc.ResetPassword(context.Background(), token, "pvz-Secret-43"))
+
+	mockRepo.On("ResetPassword", mock.Anything, hashSecretToken("bogus"), mock.Anything).Return((*entity.User)(nil), pkgValidator.ErrInvalidResetToken)
+	assert.ErrorIs(t, uc.ResetPassword(context.Background(), "bogus", "pvz-Secret-43"), pkgValidator.ErrInvalidResetToken)
+}
+
+func TestAuthUseCase_Me(t *testing.T) {
+	user := &entity.User{ID: uuid.New(), Email: "test@example.com", Role: entity.RoleEmployee}
+	pvzIDs := []uuid.UUID{uuid.New(), uuid.New()}
+	expiresAt := time.Now().Add(time.Hour)
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("GetById", mock.Anything, user.ID).Return(user, nil)
+	mockRepo.On("GetPVZAssignments", mock.Anything, user.ID).Return(pvzIDs, nil)
+	uc := NewAuthUseCase(mockRepo, newMemoryTOTPRepo(), NewJwtManager("secret", time.Hour, "gopvz", "gopvz-api"), NewMemoryLoginAttempts(5, time.Minute, time.Minute), &fakeNotifier{}, pkgLogger.Discard(), time.Hour, MFAPolicy{})
+
+	profile, err := uc.Me(context.Background(), user.ID.String(), expiresAt)
+	assert.NoError(t, err)
+	assert.Equal(t, user, profile.User)
+	assert.Equal(t, pvzIDs, profile.PvzIDs)
+	assert.Equal(t, expiresAt, profile.TokenExpiresAt)
+
+	_, err = uc.Me(context.Background(), "not-a-uuid", expiresAt)
+	assert.ErrorIs(t, err, pkgValidator.ErrUserNotFound)
+}
+
+func TestAuthUseCase_Regions(t *testing.T) {
+	user := &entity.User{ID: uuid.New(), Email: "test@example.com", Role: entity.RoleModerator, Regions: []string{"Kazan"}}
+	jm := NewJwtManager("secret", time.Hour, "gopvz", "gopvz-api")
+
+	admin := uuid.NewString()
+	all := pvzEntity.NewScope([]string{pvzEntity.AllCities})
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("GetById", mock.Anything, user.ID).Return(user, nil)
+	mockRepo.On("UpdateRegions", mock.Anything, user.ID, []string{"Kazan"}).Return(user, nil)
+	uc := NewAuthUseCase(mockRepo, newMemoryTOTPRepo(), jm, NewMemoryLoginAttempts(5, time.Minute, time.Minute), &fakeNotifier{}, pkgLogger.Discard(), time.Hour, MFAPolicy{})
+
+	updated, err := uc.SetRegions(context.Background(), all, admin, user.ID.String(), []string{"Kazan"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Kazan"}, updated.Regions)
+
+	_, err = uc.SetRegions(context.Background(), all, admin, "not-a-uuid", nil)
+	assert.ErrorIs(t, err, pkgValidator.ErrUserNotFound)
+
+	// Свои регионы не меняются, даже без ограничений
+	_, err = uc.SetRegions(context.Background(), all, admin, admin, []string{})
+	assert.ErrorIs(t, err, pkgValidator.ErrOwnRegions)
+
+	// Ограниченный редактор работает только в своих городах
+	kazan := pvzEntity.NewScope([]string{"Kazan"})
+	updated, err = uc.SetRegions(context.Background(), kazan, admin, user.ID.String(), []string{"Kazan"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Kazan"}, updated.Regions)
+	for _, regions := range [][]string{{"Moscow"}, {"Kazan", "Moscow"}, {pvzEntity.AllCities}} {
+		_, err = uc.SetRegions(context.Background(), kazan, admin, user.ID.String(), regions)
+		assert.ErrorIs(t, err, pkgValidator.ErrRegionNotHeld)
+	}
+	moscowUser := &entity.User{ID: uuid.New(), Role: entity.RoleEmployee, Regions: []string{"Moscow"}}
+	mockRepo.On("GetById", mock.Anything, moscowUser.ID).Return(moscowUser, nil)
+	_, err = uc.SetRegions(context.Background(), kazan, admin, moscowUser.ID.String(), []string{})
+	assert.ErrorIs(t, err, pkgValidator.ErrRegionNotHeld)
+	mockRepo.AssertNumberOfCalls(t, "UpdateRegions", 2)
+
+	// Регионы попадают в токен
+	token, err := uc.DummyLogin(context.Background(), string(entity.RoleModerator), []string{"Moscow"})
+	assert.NoError(t, err)
+	claims, err := jm.VerifyToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Moscow"}, claims.Regions)
+
+	// Администратор не ограничен городами
+	token, err = jm.GenerateToken(&entity.User{ID: uuid.New(), Role: entity.RoleAdmin})
+	assert.NoError(t, err)
+	claims, err = jm.VerifyToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{pvzEntity.AllCities}, claims.Regions)
+}
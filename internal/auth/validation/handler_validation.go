@@ -2,8 +2,14 @@ package validation
 
 import (
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/google/uuid"
+
+	"GoPVZ/internal/auth/entity"
 	"GoPVZ/internal/dto"
+	pvzEntity "GoPVZ/internal/pvz/entity"
 	"GoPVZ/pkg/pkgValidator"
 )
 
@@ -16,19 +22,23 @@ func NewDummyLoginValidator(payload dto.PostDummyLoginJSONBody) *DummyLoginValid
 }
 
 func (v *DummyLoginValidator) Validate() error {
-	if v.Payload.Role != dto.PostDummyLoginJSONBodyRoleEmployee && v.Payload.Role != dto.PostDummyLoginJSONBodyRoleModerator {
+	if !entity.Role(v.Payload.Role).IsSelfAssignable() {
 		return pkgValidator.ErrInvalidRole
 	}
+	if v.Payload.Regions != nil {
+		return NewRegionsValidator(*v.Payload.Regions).Validate()
+	}
 
 	return nil
 }
 
 type RegisterValidator struct {
 	Payload dto.PostRegisterJSONBody
+	Policy  *pkgValidator.PasswordPolicy
 }
 
-func NewRegisterValidator(payload dto.PostRegisterJSONBody) *RegisterValidator {
-	return &RegisterValidator{Payload: payload}
+func NewRegisterValidator(payload dto.PostRegisterJSONBody, policy *pkgValidator.PasswordPolicy) *RegisterValidator {
+	return &RegisterValidator{Payload: payload, Policy: policy}
 }
 
 func (v *RegisterValidator) Validate() error {
@@ -36,10 +46,10 @@ func (v *RegisterValidator) Validate() error {
 	if v.Payload.Email == "" || !strings.Contains(string(v.Payload.Email), "@") {
 		return pkgValidator.ErrInvalidEmail
 	}
-	if len(v.Payload.Password) < 8 {
-		return pkgValidator.ErrPasswordTooWeak
+	if err := v.Policy.Validate(v.Payload.Password); err != nil {
+		return err
 	}
-	if v.Payload.Role != dto.Employee && v.Payload.Role != dto.Moderator {
+	if !entity.Role(v.Payload.Role).IsSelfAssignable() {
 		return pkgValidator.ErrInvalidRole
 	}
 
@@ -65,3 +75,161 @@ func (v *LoginValidator) Validate() error {
 
 	return nil
 }
+
+type TwoFactorLoginValidator struct {
+	Payload dto.PostLogin2faJSONBody
+}
+
+func NewTwoFactorLoginValidator(payload dto.PostLogin2faJSONBody) *TwoFactorLoginValidator {
+	return &TwoFactorLoginValidator{Payload: payload}
+}
+
+func (v *TwoFactorLoginValidator) Validate() error {
+	if v.Payload.ChallengeToken == "" {
+		return pkgValidator.ErrInvalidMFAChallenge
+	}
+	if strings.TrimSpace(v.Payload.Code) == "" {
+		return pkgValidator.ErrInvalidTOTPCode
+	}
+
+	return nil
+}
+
+type ChangePasswordValidator struct {
+	Payload dto.PostMePasswordJSONBody
+	Policy  *pkgValidator.PasswordPolicy
+}
+
+func NewChangePasswordValidator(payload dto.PostMePasswordJSONBody, policy *pkgValidator.PasswordPolicy) *ChangePasswordValidator {
+	return &ChangePasswordValidator{Payload: payload, Policy: policy}
+}
+
+func (v *ChangePasswordValidator) Validate() error {
+	if v.Payload.OldPassword == "" {
+		return pkgValidator.ErrInvalidCredentials
+	}
+
+	return v.Policy.Validate(v.Payload.NewPassword)
+}
+
+type PasswordResetValidator struct {
+	Payload dto.PostPasswordResetJSONBody
+}
+
+func NewPasswordResetValidator(payload dto.PostPasswordResetJSONBody) *PasswordResetValidator {
+	return &PasswordResetValidator{Payload: payload}
+}
+
+func (v *PasswordResetValidator) Validate() error {
+	if v.Payload.Email == "" || !strings.Contains(v.Payload.Email, "@") {
+		return pkgValidator.ErrInvalidEmail
+	}
+
+	return nil
+}
+
+type PasswordResetConfirmValidator struct {
+	Payload dto.PostPasswordResetConfirmJSONBody
+	Policy  *pkgValidator.PasswordPolicy
+}
+
+func NewPasswordResetConfirmValidator(payload dto.PostPasswordResetConfirmJSONBody, policy *pkgValidator.PasswordPolicy) *PasswordResetConfirmValidator {
+	return &PasswordResetConfirmValidator{Payload: payload, Policy: policy}
+}
+
+func (v *PasswordResetConfirmValidator) Validate() error {
+	if v.Payload.Token == "" {
+		return pkgValidator.ErrInvalidResetToken
+	}
+
+	return v.Policy.Validate(v.Payload.NewPassword)
+}
+
+type RegionsValidator struct {
+	Regions []string
+}
+
+func NewRegionsValidator(regions []string) *RegionsValidator {
+	return &RegionsValidator{Regions: regions}
+}
+
+func (v *RegionsValidator) Validate() error {
+	// "*" — все города; вместе с конкретными городами он не имеет смысла
+	if len(v.Regions) == 1 && v.Regions[0] == pvzEntity.AllCities {
+		return nil
+	}
+	for _, region := range v.Regions {
+		if !pvzEntity.City(region).IsValid() {
+			return pkgValidator.ErrInvalidCity
+		}
+	}
+
+	return nil
+}
+
+type UserIDValidator struct {
+	UserID string
+}
+
+func NewUserIDValidator(userId string) *UserIDValidator {
+	return &UserIDValidator{UserID: userId}
+}
+
+func (v *UserIDValidator) Validate() error {
+	if _, err := uuid.Parse(v.UserID); err != nil {
+		return pkgValidator.ErrInvalidUserID
+	}
+
+	return nil
+}
+
+type APIKeyValidator struct {
+	Payload dto.PostApiKeysJSONRequestBody
+}
+
+func NewAPIKeyValidator(payload dto.PostApiKeysJSONRequestBody) *APIKeyValidator {
+	return &APIKeyValidator{Payload: payload}
+}
+
+func (v *APIKeyValidator) Validate() error {
+	name := strings.TrimSpace(v.Payload.Name)
+	if name == "" || utf8.RuneCountInString(name) > 100 {
+		return pkgValidator.ErrInvalidAPIKeyName
+	}
+
+	if len(v.Payload.Permissions) == 0 {
+		return pkgValidator.ErrInvalidPermission
+	}
+	for _, p := range v.Payload.Permissions {
+		permission := entity.Permission(p)
+		if !permission.IsValid() {
+			return pkgValidator.ErrInvalidPermission
+		}
+		// Ключ не может выпускать ключи и раздавать права
+		if permission == entity.PermissionUserManage || permission == entity.PermissionAPIKeyManage {
+			return pkgValidator.ErrPermissionNotGrantable
+		}
+	}
+
+	if v.Payload.ExpiresAt != nil && !v.Payload.ExpiresAt.After(time.Now()) {
+		return pkgValidator.ErrInvalidExpiresAt
+	}
+
+	return nil
+}
+
+type APIKeyIDValidator struct {
+	KeyID string
+}
+
+func NewAPIKeyIDValidator(keyId string) *APIKeyIDValidator {
+	return &APIKeyIDValidator{KeyID: keyId}
+}
+
+func (v *APIKeyIDValidator) Validate() error {
+	if _, err := uuid.Parse(v.KeyID); err != nil {
+		return pkgValidator.ErrInvalidAPIKeyID
+	}
+
+	return nil
+}
@@ -13,6 +13,14 @@ const (
 	BearerAuthScopes = "bearerAuth.Scopes"
 )
 
+// Defines values for LogLevelLevel.
+const (
+	LogLevelLevelDebug LogLevelLevel = "debug"
+	LogLevelLevelError LogLevelLevel = "error"
+	LogLevelLevelInfo  LogLevelLevel = "info"
+	LogLevelLevelWarn  LogLevelLevel = "warn"
+)
+
 // Defines values for PVZCity.
 const (
 	PVZCityKazan           PVZCity = "Kazan"
@@ -27,6 +35,13 @@ const (
 	PVZRequestCitySaintPetersburg PVZRequestCity = "Saint Petersburg"
 )
 
+// Defines values for PVZImportRowStatus.
+const (
+	PVZImportRowStatusCreated PVZImportRowStatus = "created"
+	PVZImportRowStatusExists  PVZImportRowStatus = "exists"
+	PVZImportRowStatusInvalid PVZImportRowStatus = "invalid"
+)
+
 // Defines values for ProductType.
 const (
 	ProductTypeClothes     ProductType = "clothes"
@@ -42,8 +57,19 @@ const (
 
 // Defines values for UserRole.
 const (
-	UserRoleEmployee  UserRole = "employee"
-	UserRoleModerator UserRole = "moderator"
+	UserRoleAdmin           UserRole = "admin"
+	UserRoleAuditor         UserRole = "auditor"
+	UserRoleEmployee        UserRole = "employee"
+	UserRoleModerator       UserRole = "moderator"
+	UserRoleRegionalManager UserRole = "regional_manager"
+	UserRoleService         UserRole = "service"
+)
+
+// Defines values for WebhookDeliveryStatus.
+const (
+	WebhookDeliveryStatusFailed  WebhookDeliveryStatus = "failed"
+	WebhookDeliveryStatusPending WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusSuccess WebhookDeliveryStatus = "success"
 )
 
 // Defines values for PostDummyLoginJSONBodyRole.
@@ -65,11 +91,73 @@ const (
 	Moderator PostRegisterJSONBodyRole = "moderator"
 )
 
+// ApiKey defines model for ApiKey.
+type ApiKey struct {
+	CreatedAt time.Time          `json:"createdAt"`
+	ExpiresAt *time.Time         `json:"expiresAt,omitempty"`
+	Id        openapi_types.UUID `json:"id"`
+
+	// Key Ключ целиком; возвращается только при создании
+	Key        *string    `json:"key,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+
+	// Name Имя сервисного аккаунта
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+
+	// Prefix Начало ключа, чтобы отличать ключи в списке
+	Prefix string `json:"prefix"`
+
+	// Regions Города, доступные ключу; ["*"] — все города, пусто — ни одного
+	Regions   []string   `json:"regions"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+// ApiKeyRequest defines model for ApiKeyRequest.
+type ApiKeyRequest struct {
+	// ExpiresAt Без срока ключ действует до отзыва
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	Name        string     `json:"name"`
+	Permissions []string   `json:"permissions"`
+}
+
 // Error defines model for Error.
 type Error struct {
 	Message string `json:"message"`
 }
 
+// LogLevel defines model for LogLevel.
+type LogLevel struct {
+	Level LogLevelLevel `json:"level"`
+}
+
+// LogLevelLevel defines model for LogLevel.Level.
+type LogLevelLevel string
+
+// Me defines model for Me.
+type Me struct {
+	Email  string               `json:"email"`
+	Id     openapi_types.UUID   `json:"id"`
+	PvzIds []openapi_types.UUID `json:"pvzIds"`
+
+	// Regions Города, доступные пользователю; ["*"] — все города, пустой список — ни одного
+	Regions []string `json:"regions"`
+	Role    UserRole `json:"role"`
+
+	// TokenExpiresAt Время истечения текущего токена
+	TokenExpiresAt time.Time `json:"tokenExpiresAt"`
+}
+
+// MfaChallenge defines model for MfaChallenge.
+type MfaChallenge struct {
+	// ChallengeToken Промежуточный токен для /login/2fa; доступа к API не дает
+	ChallengeToken string `json:"challengeToken"`
+
+	// EnrollmentRequired Второй фактор обязателен, но не настроен: сначала /login/2fa/enroll
+	EnrollmentRequired bool      `json:"enrollmentRequired"`
+	ExpiresAt          time.Time `json:"expiresAt"`
+}
+
 // PVZ defines model for PVZ.
 type PVZ struct {
 	City             PVZCity            `json:"city"`
@@ -80,6 +168,29 @@ type PVZ struct {
 // PVZCity defines model for PVZ.City.
 type PVZCity string
 
+// PVZImportReport defines model for PVZImportReport.
+type PVZImportReport struct {
+	Created  int            `json:"created"`
+	DryRun   bool           `json:"dryRun"`
+	Existing int            `json:"existing"`
+	Invalid  int            `json:"invalid"`
+	Rows     []PVZImportRow `json:"rows"`
+}
+
+// PVZImportRow defines model for PVZImportRow.
+type PVZImportRow struct {
+	Address    string              `json:"address"`
+	City       string              `json:"city"`
+	Error      *string             `json:"error,omitempty"`
+	ExternalId string              `json:"externalId"`
+	Line       int                 `json:"line"`
+	PvzId      *openapi_types.UUID `json:"pvzId,omitempty"`
+	Status     PVZImportRowStatus  `json:"status"`
+}
+
+// PVZImportRowStatus defines model for PVZImportRow.Status.
+type PVZImportRowStatus string
+
 // PVZListResponse defines model for PVZListResponse.
 type PVZListResponse = []PVZWithReceptions
 
@@ -125,35 +236,138 @@ type ReceptionWithProducts struct {
 	Products  []Product `json:"products"`
 }
 
+// RecoveryCodesResponse defines model for RecoveryCodesResponse.
+type RecoveryCodesResponse struct {
+	// RecoveryCodes Одноразовые коды восстановления; показываются один раз
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
 // TokenResponse defines model for TokenResponse.
 type TokenResponse struct {
 	Token string `json:"token"`
 }
 
+// TotpEnrollment defines model for TotpEnrollment.
+type TotpEnrollment struct {
+	// OtpauthUri Ссылка otpauth:// для QR кода
+	OtpauthUri string `json:"otpauthUri"`
+
+	// Secret Секрет в base32 для ручного ввода
+	Secret string `json:"secret"`
+}
+
+// TwoFactorLoginResponse defines model for TwoFactorLoginResponse.
+type TwoFactorLoginResponse struct {
+	// RecoveryCodes Коды восстановления, если второй фактор был включен при этом входе
+	RecoveryCodes *[]string `json:"recoveryCodes,omitempty"`
+	Token         string    `json:"token"`
+}
+
 // User defines model for User.
 type User struct {
-	Email string              `json:"email"`
-	Id    *openapi_types.UUID `json:"id,omitempty"`
-	Role  UserRole            `json:"role"`
+	Email   string              `json:"email"`
+	Id      *openapi_types.UUID `json:"id,omitempty"`
+	Regions *[]string           `json:"regions,omitempty"`
+	Role    UserRole            `json:"role"`
 }
 
 // UserRole defines model for User.Role.
 type UserRole string
 
+// WebhookDelivery defines model for WebhookDelivery.
+type WebhookDelivery struct {
+	Attempts       int                   `json:"attempts"`
+	CreatedAt      time.Time             `json:"createdAt"`
+	DeliveredAt    *time.Time            `json:"deliveredAt,omitempty"`
+	EventId        openapi_types.UUID    `json:"eventId"`
+	EventType      string                `json:"eventType"`
+	Id             openapi_types.UUID    `json:"id"`
+	LastError      *string               `json:"lastError,omitempty"`
+	NextAttemptAt  time.Time             `json:"nextAttemptAt"`
+	ResponseStatus *int                  `json:"responseStatus,omitempty"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	SubscriptionId openapi_types.UUID    `json:"subscriptionId"`
+}
+
+// WebhookDeliveryStatus defines model for WebhookDelivery.Status.
+type WebhookDeliveryStatus string
+
+// WebhookSubscription defines model for WebhookSubscription.
+type WebhookSubscription struct {
+	CreatedAt  time.Time           `json:"createdAt"`
+	EventTypes []string            `json:"eventTypes"`
+	Id         openapi_types.UUID  `json:"id"`
+	PvzId      *openapi_types.UUID `json:"pvzId,omitempty"`
+
+	// Secret Возвращается только при создании подписки
+	Secret *string `json:"secret,omitempty"`
+	Url    string  `json:"url"`
+}
+
+// WebhookSubscriptionRequest defines model for WebhookSubscriptionRequest.
+type WebhookSubscriptionRequest struct {
+	// EventTypes pvz.created, reception.opened, reception.closed, product.added, product.removed
+	EventTypes []string            `json:"eventTypes"`
+	PvzId      *openapi_types.UUID `json:"pvzId,omitempty"`
+
+	// Secret Секрет для HMAC-SHA256 подписи; генерируется, если не указан
+	Secret *string `json:"secret,omitempty"`
+	Url    string  `json:"url"`
+}
+
 // PostDummyLoginJSONBody defines parameters for PostDummyLogin.
 type PostDummyLoginJSONBody struct {
-	Role PostDummyLoginJSONBodyRole `json:"role"`
+	// Regions Города, доступные токену; ["*"] — все города, пустой список — ни одного. Без поля токен не ограничен городами
+	Regions *[]string                  `json:"regions,omitempty"`
+	Role    PostDummyLoginJSONBodyRole `json:"role"`
 }
 
 // PostDummyLoginJSONBodyRole defines parameters for PostDummyLogin.
 type PostDummyLoginJSONBodyRole string
 
+// PostLogin2faJSONBody defines parameters for PostLogin2fa.
+type PostLogin2faJSONBody struct {
+	ChallengeToken string `json:"challengeToken"`
+
+	// Code Код из приложения-аутентификатора или код восстановления
+	Code string `json:"code"`
+}
+
+// PostLogin2faEnrollJSONBody defines parameters for PostLogin2faEnroll.
+type PostLogin2faEnrollJSONBody struct {
+	ChallengeToken string `json:"challengeToken"`
+}
+
 // PostLoginJSONBody defines parameters for PostLogin.
 type PostLoginJSONBody struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
 
+// PostMe2faConfirmJSONBody defines parameters for PostMe2faConfirm.
+type PostMe2faConfirmJSONBody struct {
+	Code string `json:"code"`
+}
+
+// PostMePasswordJSONBody defines parameters for PostMePassword.
+type PostMePasswordJSONBody struct {
+	NewPassword string `json:"newPassword"`
+	OldPassword string `json:"oldPassword"`
+}
+
+// PostPasswordResetJSONBody defines parameters for PostPasswordReset.
+type PostPasswordResetJSONBody struct {
+	Email string `json:"email"`
+}
+
+// PostPasswordResetConfirmJSONBody defines parameters for PostPasswordResetConfirm.
+type PostPasswordResetConfirmJSONBody struct {
+	NewPassword string `json:"newPassword"`
+
+	// Token Токен из уведомления о сбросе пароля
+	Token string `json:"token"`
+}
+
 // PostProductsJSONBody defines parameters for PostProducts.
 type PostProductsJSONBody struct {
 	PvzId openapi_types.UUID       `json:"pvzId"`
@@ -178,11 +392,27 @@ type GetPvzParams struct {
 	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
 }
 
+// PostPvzImportParams defines parameters for PostPvzImport.
+type PostPvzImportParams struct {
+	// DryRun Только проверить файл, ничего не сохраняя
+	DryRun *bool `form:"dryRun,omitempty" json:"dryRun,omitempty"`
+}
+
 // PostReceptionsJSONBody defines parameters for PostReceptions.
 type PostReceptionsJSONBody struct {
 	PvzId openapi_types.UUID `json:"pvzId"`
 }
 
+// GetWebhooksWebhookIdDeliveriesParams defines parameters for GetWebhooksWebhookIdDeliveries.
+type GetWebhooksWebhookIdDeliveriesParams struct {
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// PutUsersUserIdRegionsJSONBody defines parameters for PutUsersUserIdRegions.
+type PutUsersUserIdRegionsJSONBody struct {
+	Regions []string `json:"regions"`
+}
+
 // PostRegisterJSONBody defines parameters for PostRegister.
 type PostRegisterJSONBody struct {
 	Email    string                   `json:"email"`
@@ -193,12 +423,33 @@ type PostRegisterJSONBody struct {
 // PostRegisterJSONBodyRole defines parameters for PostRegister.
 type PostRegisterJSONBodyRole string
 
+// PostApiKeysJSONRequestBody defines body for PostApiKeys for application/json ContentType.
+type PostApiKeysJSONRequestBody = ApiKeyRequest
+
 // PostDummyLoginJSONRequestBody defines body for PostDummyLogin for application/json ContentType.
 type PostDummyLoginJSONRequestBody PostDummyLoginJSONBody
 
+// PostLogin2faJSONRequestBody defines body for PostLogin2fa for application/json ContentType.
+type PostLogin2faJSONRequestBody PostLogin2faJSONBody
+
+// PostLogin2faEnrollJSONRequestBody defines body for PostLogin2faEnroll for application/json ContentType.
+type PostLogin2faEnrollJSONRequestBody PostLogin2faEnrollJSONBody
+
 // PostLoginJSONRequestBody defines body for PostLogin for application/json ContentType.
 type PostLoginJSONRequestBody PostLoginJSONBody
 
+// PostMe2faConfirmJSONRequestBody defines body for PostMe2faConfirm for application/json ContentType.
+type PostMe2faConfirmJSONRequestBody PostMe2faConfirmJSONBody
+
+// PostMePasswordJSONRequestBody defines body for PostMePassword for application/json ContentType.
+type PostMePasswordJSONRequestBody PostMePasswordJSONBody
+
+// PostPasswordResetJSONRequestBody defines body for PostPasswordReset for application/json ContentType.
+type PostPasswordResetJSONRequestBody PostPasswordResetJSONBody
+
+// PostPasswordResetConfirmJSONRequestBody defines body for PostPasswordResetConfirm for application/json ContentType.
+type PostPasswordResetConfirmJSONRequestBody PostPasswordResetConfirmJSONBody
+
 // PostProductsJSONRequestBody defines body for PostProducts for application/json ContentType.
 type PostProductsJSONRequestBody PostProductsJSONBody
 
@@ -210,3 +461,12 @@ type PostReceptionsJSONRequestBody PostReceptionsJSONBody
 
 // PostRegisterJSONRequestBody defines body for PostRegister for application/json ContentType.
 type PostRegisterJSONRequestBody PostRegisterJSONBody
+
+// PutAdminLogLevelJSONRequestBody defines body for PutAdminLogLevel for application/json ContentType.
+type PutAdminLogLevelJSONRequestBody = LogLevel
+
+// PutUsersUserIdRegionsJSONRequestBody defines body for PutUsersUserIdRegions for application/json ContentType.
+type PutUsersUserIdRegionsJSONRequestBody PutUsersUserIdRegionsJSONBody
+
+// PostWebhooksJSONRequestBody defines body for PostWebhooks for application/json ContentType.
+type PostWebhooksJSONRequestBody = WebhookSubscriptionRequest
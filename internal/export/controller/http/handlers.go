@@ -0,0 +1,113 @@
+package http
+
+import (
+	"GoPVZ/internal/dto"
+	"GoPVZ/internal/export/entity"
+	"GoPVZ/internal/export/usecase"
+	"GoPVZ/internal/export/validation"
+	"GoPVZ/internal/export/writer"
+	pvzEntity "GoPVZ/internal/pvz/entity"
+	"GoPVZ/pkg/pkgLogger"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ExportHandler struct {
+	uc  *usecase.ExportUseCase
+	log pkgLogger.Interface
+}
+
+func NewExportHandler(uc *usecase.ExportUseCase, log pkgLogger.Interface) *ExportHandler {
+	return &ExportHandler{uc: uc, log: log}
+}
+
+// ExportReceptions godoc
+// @Summary Выгрузка приемок и товаров в CSV или XLSX (только для модераторов)
+// @Description Отдает файл потоком: строки читаются из курсора Postgres и сразу пишутся в ответ, поэтому размер диапазона не ограничен памятью. Одна строка — один товар; приемки без товаров выгружаются с пустыми полями товара
+// @Tags Domain export
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "Формат файла: csv или xlsx" default(csv)
+// @Param startDate query string false "Начальная дата приемки (RFC3339)"
+// @Param endDate query string false "Конечная дата приемки (RFC3339)"
+// @Param pvzId query string false "Только указанный ПВЗ"
+// @Param columns query string false "Колонки через запятую: pvz_id, pvz_city, pvz_registration_date, reception_id, reception_date, reception_status, product_id, product_date, product_type"
+// @Param timezone query string false "Часовой пояс дат: UTC, local или имя IANA" default(UTC)
+// @Success 200 {file} file "Файл выгрузки"
+// @Failure 400 {object} dto.Error "Неверные параметры запроса"
+// @Failure 401 {object} dto.Error "Ошибка авторизации"
+// @Failure 403 {object} dto.Error "Доступ запрещен"
+// @Failure 500 {object} dto.Error "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /export/receptions [get]
+func (h *ExportHandler) ExportReceptions(c *gin.Context) {
+	format := c.DefaultQuery("format", string(entity.FormatCSV))
+	startDate := c.Query("startDate")
+	endDate := c.Query("endDate")
+	pvzId := c.Query("pvzId")
+	columnsStr := c.Query("columns")
+	timezone := c.DefaultQuery("timezone", "UTC")
+
+	validator := validation.NewExportFilterValidator(format, startDate, endDate, pvzId, columnsStr, timezone)
+	if err := validator.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: err.Error()})
+		return
+	}
+
+	var filter entity.ReceptionFilter
+	if startDate != "" {
+		st, _ := time.Parse(time.RFC3339, startDate)
+		filter.StartDate = &st
+	}
+	if endDate != "" {
+		et, _ := time.Parse(time.RFC3339, endDate)
+		filter.EndDate = &et
+	}
+	if pvzId != "" {
+		id, _ := uuid.Parse(pvzId)
+		filter.PvzID = &id
+	}
+
+	var columns []entity.Column
+	if columnsStr != "" {
+		for _, column := range strings.Split(columnsStr, ",") {
+			columns = append(columns, entity.Column(strings.TrimSpace(column)))
+		}
+	}
+
+	loc, _ := validation.ParseTimezone(timezone)
+
+	// Большая выгрузка пишется дольше WriteTimeout сервера
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	filename := fmt.Sprintf("receptions_%s.%s", time.Now().UTC().Format("20060102_150405"), format)
+	c.Header("Content-Type", writer.ContentType(entity.Format(format)))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	err := h.uc.ExportReceptions(c, pvzEntity.NewScope(c.GetStringSlice("user_regions")), c.Writer, entity.Format(format), filter, columns, loc)
+	if err != nil {
+		if !c.Writer.Written() {
+			c.Header("Content-Disposition", "")
+			respondInternalError(c, h.log, err)
+			return
+		}
+		// Заголовки уже отправлены: обрываем соединение, чтобы клиент
+		// не принял усеченный файл за полный
+		h.log.ErrorContext(c, "Export aborted after response started", slog.String("format", format), pkgLogger.Err(err))
+		_ = c.Error(err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// respondInternalError пишет ошибку в лог и отвечает 500. Маршрут, пользователь
+// и request_id попадают в запись из логгера запроса.
+func respondInternalError(c *gin.Context, log pkgLogger.Interface, err error) {
+	log.ErrorContext(c, "Request failed", pkgLogger.Err(err))
+	c.JSON(http.StatusInternalServerError, dto.Error{Message: err.Error()})
+}
@@ -0,0 +1,23 @@
+package http
+
+import (
+	"GoPVZ/internal/export/usecase"
+	"GoPVZ/pkg/pkgLogger"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NewExportRouter(
+	router *gin.RouterGroup,
+	uc *usecase.ExportUseCase,
+	log pkgLogger.Interface,
+	authMiddleware gin.HandlerFunc,
+	canExport gin.HandlerFunc,
+) {
+	handler := NewExportHandler(uc, log)
+
+	// Routes for users with export:read
+	exportRoutes := router.Group("/export")
+	exportRoutes.Use(authMiddleware, canExport)
+	exportRoutes.GET("/receptions", handler.ExportReceptions)
+}
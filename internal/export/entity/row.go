@@ -0,0 +1,96 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+type Column string
+
+const (
+	ColumnPVZID               Column = "pvz_id"
+	ColumnPVZCity             Column = "pvz_city"
+	ColumnPVZRegistrationDate Column = "pvz_registration_date"
+	ColumnReceptionID         Column = "reception_id"
+	ColumnReceptionDate       Column = "reception_date"
+	ColumnReceptionStatus     Column = "reception_status"
+	ColumnProductID           Column = "product_id"
+	ColumnProductDate         Column = "product_date"
+	ColumnProductType         Column = "product_type"
+)
+
+// AllColumns — колонки выгрузки в порядке по умолчанию.
+var AllColumns = []Column{
+	ColumnPVZID,
+	ColumnPVZCity,
+	ColumnPVZRegistrationDate,
+	ColumnReceptionID,
+	ColumnReceptionDate,
+	ColumnReceptionStatus,
+	ColumnProductID,
+	ColumnProductDate,
+	ColumnProductType,
+}
+
+// ReceptionFilter ограничивает выгрузку по дате приемки, ПВЗ и городам ПВЗ.
+// nil Cities — без ограничения по городам, пустой срез — ни одного города; при ограниченном scope
+// пользователя Cities задает ExportUseCase.
+type ReceptionFilter struct {
+	StartDate *time.Time
+	EndDate   *time.Time
+	PvzID     *uuid.UUID
+	Cities    []string
+}
+
+// ReceptionRow — одна строка выгрузки: товар вместе с приемкой и ПВЗ.
+// Для приемки без товаров поля товара пустые.
+type ReceptionRow struct {
+	PvzID               uuid.UUID
+	PvzCity             string
+	PvzRegistrationDate time.Time
+	ReceptionID         uuid.UUID
+	ReceptionDate       time.Time
+	ReceptionStatus     string
+	ProductID           *uuid.UUID
+	ProductDate         *time.Time
+	ProductType         *string
+}
+
+// Value возвращает значение колонки; даты форматируются в RFC3339 в часовом поясе loc.
+func (r *ReceptionRow) Value(column Column, loc *time.Location) string {
+	switch column {
+	case ColumnPVZID:
+		return r.PvzID.String()
+	case ColumnPVZCity:
+		return r.PvzCity
+	case ColumnPVZRegistrationDate:
+		return r.PvzRegistrationDate.In(loc).Format(time.RFC3339)
+	case ColumnReceptionID:
+		return r.ReceptionID.String()
+	case ColumnReceptionDate:
+		return r.ReceptionDate.In(loc).Format(time.RFC3339)
+	case ColumnReceptionStatus:
+		return r.ReceptionStatus
+	case ColumnProductID:
+		if r.ProductID != nil {
+			return r.ProductID.String()
+		}
+	case ColumnProductDate:
+		if r.ProductDate != nil {
+			return r.ProductDate.In(loc).Format(time.RFC3339)
+		}
+	case ColumnProductType:
+		if r.ProductType != nil {
+			return *r.ProductType
+		}
+	}
+	return ""
+}
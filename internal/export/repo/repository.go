@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"GoPVZ/internal/export/entity"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgPostgres"
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const _fetchSize = 500
+
+type exportRepo struct {
+	db        *pgxpool.Pool
+	log       pkgLogger.Interface
+	fetchSize int
+}
+
+func NewExportRepo(db *pgxpool.Pool, log pkgLogger.Interface) ExportRepository {
+	return &exportRepo{db: db, log: log, fetchSize: _fetchSize}
+}
+
+func (r *exportRepo) StreamReceptions(ctx context.Context, filter entity.ReceptionFilter, fn func(*entity.ReceptionRow) error) error {
+	conn := pkgPostgres.Conn(ctx, r.db)
+
+	_, err := conn.Exec(ctx, `
+		DECLARE export_receptions NO SCROLL CURSOR FOR
+		SELECT
+			p.id, p.city, p.registration_date,
+			r.id, r.date_time, r.status,
+			pr.id, pr.date_time, pr.type
+		FROM receptions r
+		JOIN pvz p ON p.id = r.pvz_id
+		LEFT JOIN products pr ON pr.reception_id = r.id
+		WHERE ($1::timestamptz IS NULL OR r.date_time >= $1)
+			AND ($2::timestamptz IS NULL OR r.date_time <= $2)
+			AND ($3::uuid IS NULL OR r.pvz_id = $3)
+			AND ($4::text[] IS NULL OR p.city = ANY($4))
+		ORDER BY p.id, r.date_time, pr.date_time`,
+		filter.StartDate, filter.EndDate, filter.PvzID, filter.Cities,
+	)
+	if err != nil {
+		return err
+	}
+	defer conn.Exec(context.WithoutCancel(ctx), `CLOSE export_receptions`)
+
+	fetch := fmt.Sprintf(`FETCH FORWARD %d FROM export_receptions`, r.fetchSize)
+	for batches := 1; ; batches++ {
+		rows, err := conn.Query(ctx, fetch)
+		if err != nil {
+			return err
+		}
+
+		fetched := 0
+		for rows.Next() {
+			var row entity.ReceptionRow
+			if err := rows.Scan(
+				&row.PvzID, &row.PvzCity, &row.PvzRegistrationDate,
+				&row.ReceptionID, &row.ReceptionDate, &row.ReceptionStatus,
+				&row.ProductID, &row.ProductDate, &row.ProductType,
+			); err != nil {
+				rows.Close()
+				return err
+			}
+			fetched++
+
+			if err := fn(&row); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if fetched < r.fetchSize {
+			r.log.DebugContext(ctx, "Receptions cursor drained", slog.Int("batches", batches), slog.Int("fetch_size", r.fetchSize))
+			return nil
+		}
+	}
+}
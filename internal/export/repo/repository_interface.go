@@ -0,0 +1,12 @@
+package repo
+
+import (
+	"GoPVZ/internal/export/entity"
+	"context"
+)
+
+type ExportRepository interface {
+	// StreamReceptions читает строки через серверный курсор и передает их в fn по одной.
+	// Должен вызываться внутри транзакции.
+	StreamReceptions(ctx context.Context, filter entity.ReceptionFilter, fn func(*entity.ReceptionRow) error) error
+}
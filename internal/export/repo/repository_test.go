@@ -0,0 +1,152 @@
+package repo
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"GoPVZ/internal/export/entity"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgPostgres"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+var (
+	testConnStr string
+	pgContainer *postgres.PostgresContainer
+)
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+
+	var err error
+	pgContainer, err = postgres.RunContainer(ctx,
+		testcontainers.WithImage("postgres:15-alpine"),
+		postgres.WithDatabase("testdb"),
+		postgres.WithUsername("testuser"),
+		postgres.WithPassword("testpass"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second),
+		),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	testConnStr, err = pgContainer.ConnectionString(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	pg, err := pkgPostgres.New(testConnStr)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = pg.Pool.Exec(ctx, `
+		CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+		CREATE TABLE IF NOT EXISTS pvz (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			registration_date TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			city VARCHAR(50) NOT NULL CHECK (city IN ('Moscow', 'Saint Petersburg', 'Kazan'))
+		);
+
+		CREATE TABLE IF NOT EXISTS receptions (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			pvz_id UUID NOT NULL REFERENCES pvz(id),
+			date_time TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			status VARCHAR(20) NOT NULL CHECK (status IN ('in_progress', 'close'))
+		);
+
+		CREATE TABLE IF NOT EXISTS products (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			reception_id UUID NOT NULL REFERENCES receptions(id),
+			date_time TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			type VARCHAR(20) NOT NULL CHECK (type IN ('electronics', 'clothes', 'shoes'))
+		);
+	`)
+	if err != nil {
+		panic(err)
+	}
+	pg.Close()
+
+	code := m.Run()
+
+	if err := pgContainer.Terminate(ctx); err != nil {
+		panic(err)
+	}
+
+	os.Exit(code)
+}
+
+func TestExportRepository_StreamReceptions(t *testing.T) {
+	pg, err := pkgPostgres.New(testConnStr)
+	require.NoError(t, err)
+	defer pg.Close()
+
+	ctx := context.Background()
+	_, err = pg.Pool.Exec(ctx, `TRUNCATE TABLE products, receptions, pvz CASCADE`)
+	require.NoError(t, err)
+
+	moscow, kazan := uuid.New(), uuid.New()
+	_, err = pg.Pool.Exec(ctx, `INSERT INTO pvz (id, city) VALUES ($1, 'Moscow'), ($2, 'Kazan')`, moscow, kazan)
+	require.NoError(t, err)
+
+	march := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	april := time.Date(2025, 4, 10, 12, 0, 0, 0, time.UTC)
+	marchReception, aprilReception, emptyReception := uuid.New(), uuid.New(), uuid.New()
+	_, err = pg.Pool.Exec(ctx, `
+		INSERT INTO receptions (id, pvz_id, date_time, status) VALUES
+			($1, $4, $5, 'close'),
+			($2, $4, $6, 'close'),
+			($3, $7, $5, 'close')`,
+		marchReception, aprilReception, emptyReception, moscow, march, april, kazan,
+	)
+	require.NoError(t, err)
+	_, err = pg.Pool.Exec(ctx, `
+		INSERT INTO products (reception_id, date_time, type) VALUES
+			($1, $3, 'shoes'), ($1, $3, 'clothes'), ($1, $3, 'electronics'),
+			($2, $4, 'shoes')`,
+		marchReception, aprilReception, march, april,
+	)
+	require.NoError(t, err)
+
+	// Маленькая порция, чтобы проверить несколько FETCH подряд
+	r := &exportRepo{db: pg.Pool, log: pkgLogger.Discard(), fetchSize: 2}
+	tx := pkgPostgres.NewTransactor(pg.Pool)
+
+	marchEnd := march.Add(24 * time.Hour)
+	tests := []struct {
+		name      string
+		filter    entity.ReceptionFilter
+		wantCount int
+	}{
+		{name: "all rows including reception without products", filter: entity.ReceptionFilter{}, wantCount: 5},
+		{name: "date range", filter: entity.ReceptionFilter{EndDate: &marchEnd}, wantCount: 4},
+		{name: "single pvz", filter: entity.ReceptionFilter{PvzID: &moscow}, wantCount: 4},
+		{name: "single pvz and date range", filter: entity.ReceptionFilter{StartDate: &april, PvzID: &moscow}, wantCount: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rows []*entity.ReceptionRow
+			err := tx.WithinTransaction(ctx, func(ctx context.Context) error {
+				return r.StreamReceptions(ctx, tt.filter, func(row *entity.ReceptionRow) error {
+					rows = append(rows, row)
+					return nil
+				})
+			})
+			require.NoError(t, err)
+			require.Len(t, rows, tt.wantCount)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"GoPVZ/internal/export/entity"
+	"GoPVZ/internal/export/repo"
+	"GoPVZ/internal/export/writer"
+	pvzEntity "GoPVZ/internal/pvz/entity"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgPostgres"
+	"context"
+	"io"
+	"log/slog"
+	"time"
+)
+
+type ExportUseCase struct {
+	repo repo.ExportRepository
+	tx   pkgPostgres.Transactor
+	log  pkgLogger.Interface
+}
+
+func NewExportUseCase(r repo.ExportRepository, tx pkgPostgres.Transactor, log pkgLogger.Interface) *ExportUseCase {
+	return &ExportUseCase{repo: r, tx: tx, log: log}
+}
+
+// ExportReceptions пишет приемки и товары в w построчно: первая строка — заголовок
+// с именами колонок. Пустой columns означает все колонки, nil loc — UTC.
+// Ограниченный scope выгружает только приемки ПВЗ своих городов.
+func (uc *ExportUseCase) ExportReceptions(
+	ctx context.Context,
+	scope pvzEntity.Scope,
+	w io.Writer,
+	format entity.Format,
+	filter entity.ReceptionFilter,
+	columns []entity.Column,
+	loc *time.Location,
+) error {
+	if len(columns) == 0 {
+		columns = entity.AllColumns
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	if scope.Restricted() {
+		filter.Cities = scope.CityNames()
+	}
+
+	rw, err := writer.New(format, w)
+	if err != nil {
+		return err
+	}
+
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = string(column)
+	}
+	if err := rw.WriteRow(header); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	rows := 0
+	values := make([]string, len(columns))
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		return uc.repo.StreamReceptions(ctx, filter, func(row *entity.ReceptionRow) error {
+			for i, column := range columns {
+				values[i] = row.Value(column, loc)
+			}
+			rows++
+			return rw.WriteRow(values)
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := rw.Close(); err != nil {
+		return err
+	}
+	uc.log.InfoContext(ctx, "Receptions exported",
+		slog.String("format", string(format)),
+		slog.Int("rows", rows),
+		slog.Duration("duration", time.Since(start)),
+	)
+	return nil
+}
@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"GoPVZ/internal/export/entity"
+	pvzEntity "GoPVZ/internal/pvz/entity"
+	"GoPVZ/pkg/pkgLogger"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockExportRepo struct {
+	mock.Mock
+}
+
+func (m *MockExportRepo) StreamReceptions(ctx context.Context, filter entity.ReceptionFilter, fn func(*entity.ReceptionRow) error) error {
+	args := m.Called(ctx, filter, fn)
+	if rows, ok := args.Get(0).([]*entity.ReceptionRow); ok {
+		for _, row := range rows {
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+// fakeTransactor выполняет функцию без реальной транзакции
+type fakeTransactor struct{}
+
+func (fakeTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func TestExportUseCase_ExportReceptions(t *testing.T) {
+	productID := uuid.New()
+	productType := "shoes"
+	receptionDate := time.Date(2025, 3, 10, 21, 30, 0, 0, time.UTC)
+	rows := []*entity.ReceptionRow{
+		{
+			PvzID:           uuid.New(),
+			PvzCity:         "Moscow",
+			ReceptionID:     uuid.New(),
+			ReceptionDate:   receptionDate,
+			ReceptionStatus: "close",
+			ProductID:       &productID,
+			ProductDate:     &receptionDate,
+			ProductType:     &productType,
+		},
+		{
+			PvzID:           uuid.New(),
+			PvzCity:         "Kazan",
+			ReceptionID:     uuid.New(),
+			ReceptionDate:   receptionDate,
+			ReceptionStatus: "in_progress",
+		},
+	}
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		columns []entity.Column
+		loc     *time.Location
+		repoErr error
+		want    [][]string
+		wantErr bool
+	}{
+		{
+			name:    "selected columns in UTC",
+			columns: []entity.Column{entity.ColumnPVZCity, entity.ColumnReceptionDate, entity.ColumnProductType},
+			want: [][]string{
+				{"pvz_city", "reception_date", "product_type"},
+				{"Moscow", "2025-03-10T21:30:00Z", "shoes"},
+				{"Kazan", "2025-03-10T21:30:00Z", ""},
+			},
+		},
+		{
+			name:    "dates in requested timezone",
+			columns: []entity.Column{entity.ColumnReceptionDate},
+			loc:     moscow,
+			want: [][]string{
+				{"reception_date"},
+				{"2025-03-11T00:30:00+03:00"},
+				{"2025-03-11T00:30:00+03:00"},
+			},
+		},
+		{
+			name:    "repository error",
+			repoErr: errors.New("cursor failed"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockExportRepo)
+			uc := NewExportUseCase(mockRepo, fakeTransactor{}, pkgLogger.Discard())
+			filter := entity.ReceptionFilter{}
+
+			mockRepo.On("StreamReceptions", mock.Anything, filter, mock.Anything).Return(rows, tt.repoErr)
+
+			var buf bytes.Buffer
+			err := uc.ExportReceptions(context.Background(), pvzEntity.Scope{}, &buf, entity.FormatCSV, filter, tt.columns, tt.loc)
+
+			if tt.wantErr {
+				assert.ErrorIs(t, err, tt.repoErr)
+				return
+			}
+			require.NoError(t, err)
+
+			got, err := csv.NewReader(&buf).ReadAll()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestExportUseCase_DefaultColumns(t *testing.T) {
+	mockRepo := new(MockExportRepo)
+	uc := NewExportUseCase(mockRepo, fakeTransactor{}, pkgLogger.Discard())
+	mockRepo.On("StreamReceptions", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+
+	var buf bytes.Buffer
+	require.NoError(t, uc.ExportReceptions(context.Background(), pvzEntity.Scope{}, &buf, entity.FormatCSV, entity.ReceptionFilter{}, nil, nil))
+
+	got, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Len(t, got[0], len(entity.AllColumns))
+}
+
+func TestExportUseCase_Scope(t *testing.T) {
+	mockRepo := new(MockExportRepo)
+	uc := NewExportUseCase(mockRepo, fakeTransactor{}, pkgLogger.Discard())
+	// Города из scope заменяют переданные в фильтре
+	want := entity.ReceptionFilter{Cities: []string{"Kazan"}}
+	mockRepo.On("StreamReceptions", mock.Anything, want, mock.Anything).Return(nil, nil)
+
+	var buf bytes.Buffer
+	filter := entity.ReceptionFilter{Cities: []string{"Moscow"}}
+	require.NoError(t, uc.ExportReceptions(context.Background(), pvzEntity.NewScope([]string{"Kazan"}), &buf, entity.FormatCSV, filter, nil, nil))
+	mockRepo.AssertExpectations(t)
+}
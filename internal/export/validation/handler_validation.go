@@ -0,0 +1,97 @@
+package validation
+
+import (
+	"GoPVZ/internal/export/entity"
+	"GoPVZ/pkg/pkgValidator"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var knownColumns = func() map[entity.Column]bool {
+	m := make(map[entity.Column]bool, len(entity.AllColumns))
+	for _, column := range entity.AllColumns {
+		m[column] = true
+	}
+	return m
+}()
+
+type ExportFilterValidator struct {
+	Format    string
+	StartDate string
+	EndDate   string
+	PvzID     string
+	Columns   string
+	Timezone  string
+}
+
+func NewExportFilterValidator(format, startDate, endDate, pvzId, columns, timezone string) *ExportFilterValidator {
+	return &ExportFilterValidator{
+		Format:    format,
+		StartDate: startDate,
+		EndDate:   endDate,
+		PvzID:     pvzId,
+		Columns:   columns,
+		Timezone:  timezone,
+	}
+}
+
+func (v *ExportFilterValidator) Validate() error {
+	switch entity.Format(v.Format) {
+	case entity.FormatCSV, entity.FormatXLSX:
+	default:
+		return pkgValidator.ErrInvalidExportFormat
+	}
+
+	var start, end time.Time
+	if v.StartDate != "" {
+		t, err := time.Parse(time.RFC3339, v.StartDate)
+		if err != nil {
+			return pkgValidator.ErrInvalidDateFormat
+		}
+		start = t
+	}
+	if v.EndDate != "" {
+		t, err := time.Parse(time.RFC3339, v.EndDate)
+		if err != nil {
+			return pkgValidator.ErrInvalidDateFormat
+		}
+		end = t
+	}
+	if v.StartDate != "" && v.EndDate != "" && end.Before(start) {
+		return pkgValidator.ErrInvalidDateRange
+	}
+
+	if v.PvzID != "" {
+		if _, err := uuid.Parse(v.PvzID); err != nil {
+			return pkgValidator.ErrInvalidPVZID
+		}
+	}
+
+	if v.Columns != "" {
+		for _, column := range strings.Split(v.Columns, ",") {
+			if !knownColumns[entity.Column(strings.TrimSpace(column))] {
+				return pkgValidator.ErrInvalidExportColumn
+			}
+		}
+	}
+
+	if _, err := ParseTimezone(v.Timezone); err != nil {
+		return pkgValidator.ErrInvalidTimezone
+	}
+
+	return nil
+}
+
+// ParseTimezone понимает "UTC", "local" (часовой пояс сервера) и имена IANA, например "Europe/Moscow".
+func ParseTimezone(tz string) (*time.Location, error) {
+	switch tz {
+	case "", "UTC":
+		return time.UTC, nil
+	case "local":
+		return time.Local, nil
+	default:
+		return time.LoadLocation(tz)
+	}
+}
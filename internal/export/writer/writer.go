@@ -0,0 +1,33 @@
+package writer
+
+import (
+	"GoPVZ/internal/export/entity"
+	"fmt"
+	"io"
+)
+
+// RowWriter построчно пишет таблицу в поток, не накапливая ее в памяти.
+type RowWriter interface {
+	WriteRow(values []string) error
+	// Close дописывает хвост файла и сбрасывает буферы; сам поток не закрывает.
+	Close() error
+}
+
+func New(format entity.Format, w io.Writer) (RowWriter, error) {
+	switch format {
+	case entity.FormatCSV:
+		return NewCSV(w), nil
+	case entity.FormatXLSX:
+		return NewXLSX(w, "Receptions")
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// ContentType возвращает MIME-тип формата.
+func ContentType(format entity.Format) string {
+	if format == entity.FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
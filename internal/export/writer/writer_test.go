@@ -0,0 +1,88 @@
+package writer
+
+import (
+	"GoPVZ/internal/export/entity"
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testRows = [][]string{
+	{"pvz_city", "product_type"},
+	{"Saint Petersburg", "shoes"},
+	{"Kazan, \"центр\"", "<clothes> & co"},
+}
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := New(entity.FormatCSV, &buf)
+	require.NoError(t, err)
+
+	for _, row := range testRows {
+		require.NoError(t, w.WriteRow(row))
+	}
+	require.NoError(t, w.Close())
+
+	got, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, testRows, got)
+}
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := New(entity.FormatXLSX, &buf)
+	require.NoError(t, err)
+
+	for _, row := range testRows {
+		require.NoError(t, w.WriteRow(row))
+	}
+	require.NoError(t, w.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels"} {
+		assert.Contains(t, files, name)
+	}
+	require.Contains(t, files, "xl/worksheets/sheet1.xml")
+
+	rc, err := files["xl/worksheets/sheet1.xml"].Open()
+	require.NoError(t, err)
+	defer rc.Close()
+	body, err := io.ReadAll(rc)
+	require.NoError(t, err)
+
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Text string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	require.NoError(t, xml.Unmarshal(body, &sheet))
+
+	got := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		values := make([]string, 0, len(row.Cells))
+		for _, cell := range row.Cells {
+			values = append(values, cell.Text)
+		}
+		got = append(got, values)
+	}
+	assert.Equal(t, testRows, got)
+}
+
+func TestNew_UnknownFormat(t *testing.T) {
+	_, err := New("pdf", io.Discard)
+	assert.Error(t, err)
+}
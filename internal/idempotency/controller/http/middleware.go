@@ -0,0 +1,115 @@
+package http
+
+import (
+	"GoPVZ/internal/dto"
+	"GoPVZ/internal/idempotency/usecase"
+	"GoPVZ/pkg/pkgValidator"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderReplayed выставляется на ответах, взятых из сохраненных
+	HeaderReplayed = "Idempotent-Replayed"
+
+	_maxKeyLength = 255
+	// _maxBodySize ограничивает тело, которое читается в память для хэша
+	_maxBodySize = 1 << 20
+)
+
+// IdempotencyMiddleware повторяет сохраненный ответ для запросов с уже
+// использованным Idempotency-Key. Должен стоять после JWTMiddleware: ключи
+// хранятся отдельно для каждого пользователя. Запросы без заголовка проходят как есть.
+func IdempotencyMiddleware(uc *usecase.IdempotencyUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderIdempotencyKey)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > _maxKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, dto.Error{Message: pkgValidator.ErrInvalidIdempotencyKey.Error()})
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, _maxBodySize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, dto.Error{Message: pkgValidator.ErrRequestTooLarge.Error()})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, dto.Error{Message: pkgValidator.ErrInvalidInput.Error()})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		userID := c.GetString("user_id")
+		hash := usecase.RequestHash(c.Request.Method, c.Request.URL.RequestURI(), body)
+
+		record, err := uc.Begin(c, userID, key, hash)
+		switch {
+		case errors.Is(err, pkgValidator.ErrIdempotencyKeyReused):
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, dto.Error{Message: err.Error()})
+			return
+		case errors.Is(err, pkgValidator.ErrIdempotencyInProgress):
+			c.AbortWithStatusJSON(http.StatusConflict, dto.Error{Message: err.Error()})
+			return
+		case err != nil:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, dto.Error{Message: err.Error()})
+			return
+		case record != nil:
+			c.Header(HeaderReplayed, "true")
+			if record.ETag != "" {
+				c.Header("ETag", record.ETag)
+			}
+			c.Data(record.StatusCode, record.ContentType, record.Body)
+			c.Abort()
+			return
+		}
+
+		// Ответ сохраняется, даже если клиент уже отключился
+		ctx := context.WithoutCancel(c.Request.Context())
+		defer func() {
+			// После паники ключ освобождается, иначе повторы получали бы 409 до
+			// истечения ttl. Панику дальше обрабатывает Recovery сервера
+			if r := recover(); r != nil {
+				_ = uc.Release(ctx, userID, key)
+				panic(r)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			_ = uc.Release(ctx, userID, key)
+			return
+		}
+		header := recorder.Header()
+		_ = uc.Complete(ctx, userID, key, status, header.Get("Content-Type"), header.Get("ETag"), recorder.body.Bytes())
+	}
+}
+
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,209 @@
+package http
+
+import (
+	"GoPVZ/internal/idempotency/entity"
+	"GoPVZ/internal/idempotency/usecase"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgValidator"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRepo — хранилище ключей в памяти для проверки middleware без базы
+type memoryRepo struct {
+	mu      sync.Mutex
+	records map[string]*entity.Record
+}
+
+func newMemoryRepo() *memoryRepo {
+	return &memoryRepo{records: make(map[string]*entity.Record)}
+}
+
+func (r *memoryRepo) Reserve(_ context.Context, record *entity.Record, _ time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := record.UserID + "/" + record.Key
+	if _, ok := r.records[id]; ok {
+		return false, nil
+	}
+	rec := *record
+	r.records[id] = &rec
+	return true, nil
+}
+
+func (r *memoryRepo) Get(_ context.Context, userID, key string) (*entity.Record, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec, ok := r.records[userID+"/"+key]
+	if !ok {
+		return nil, pkgValidator.ErrIdempotencyKeyNotFound
+	}
+	copied := *rec
+	return &copied, nil
+}
+
+func (r *memoryRepo) Complete(_ context.Context, record *entity.Record) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec := r.records[record.UserID+"/"+record.Key]
+	rec.StatusCode, rec.ContentType, rec.ETag, rec.Body = record.StatusCode, record.ContentType, record.ETag, record.Body
+	return nil
+}
+
+func (r *memoryRepo) Delete(_ context.Context, userID, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.records, userID+"/"+key)
+	return nil
+}
+
+func (r *memoryRepo) DeleteExpired(context.Context, time.Duration) (int64, error) {
+	return 0, nil
+}
+
+func setupRouter(handlerStatus *int, calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	uc := usecase.NewIdempotencyUseCase(newMemoryRepo(), pkgLogger.Discard(), time.Hour)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", c.GetHeader("X-Test-User"))
+	})
+	router.POST("/products", IdempotencyMiddleware(uc), func(c *gin.Context) {
+		*calls++
+		if *handlerStatus == _panicStatus {
+			panic("handler failed")
+		}
+		c.Header("ETag", fmt.Sprintf(`"%d"`, *calls))
+		c.JSON(*handlerStatus, gin.H{"call": *calls})
+	})
+	return router
+}
+
+// _panicStatus заставляет тестовый обработчик паниковать.
+const _panicStatus = -1
+
+func doRequest(router *gin.Engine, user, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-User", user)
+	if key != "" {
+		req.Header.Set(HeaderIdempotencyKey, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	t.Run("retry replays stored response", func(t *testing.T) {
+		status, calls := http.StatusCreated, 0
+		router := setupRouter(&status, &calls)
+
+		first := doRequest(router, "u1", "key-1", `{"type":"shoes"}`)
+		second := doRequest(router, "u1", "key-1", `{"type":"shoes"}`)
+
+		require.Equal(t, 1, calls)
+		require.Equal(t, http.StatusCreated, second.Code)
+		require.Equal(t, first.Body.String(), second.Body.String())
+		require.Equal(t, "true", second.Header().Get(HeaderReplayed))
+		require.Empty(t, first.Header().Get(HeaderReplayed))
+		require.Equal(t, `"1"`, second.Header().Get("ETag"))
+	})
+
+	t.Run("reused key with different payload", func(t *testing.T) {
+		status, calls := http.StatusCreated, 0
+		router := setupRouter(&status, &calls)
+
+		doRequest(router, "u1", "key-1", `{"type":"shoes"}`)
+		w := doRequest(router, "u1", "key-1", `{"type":"clothes"}`)
+
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("keys are scoped per user", func(t *testing.T) {
+		status, calls := http.StatusCreated, 0
+		router := setupRouter(&status, &calls)
+
+		doRequest(router, "u1", "key-1", `{"type":"shoes"}`)
+		w := doRequest(router, "u2", "key-1", `{"type":"shoes"}`)
+
+		require.Equal(t, http.StatusCreated, w.Code)
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("server error releases key", func(t *testing.T) {
+		status, calls := http.StatusInternalServerError, 0
+		router := setupRouter(&status, &calls)
+
+		doRequest(router, "u1", "key-1", `{"type":"shoes"}`)
+		status = http.StatusCreated
+		w := doRequest(router, "u1", "key-1", `{"type":"shoes"}`)
+
+		require.Equal(t, http.StatusCreated, w.Code)
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("panic releases key", func(t *testing.T) {
+		status, calls := _panicStatus, 0
+		router := setupRouter(&status, &calls)
+
+		require.Panics(t, func() { doRequest(router, "u1", "key-1", `{"type":"shoes"}`) })
+		status = http.StatusCreated
+		w := doRequest(router, "u1", "key-1", `{"type":"shoes"}`)
+
+		require.Equal(t, http.StatusCreated, w.Code)
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("client errors are stored too", func(t *testing.T) {
+		status, calls := http.StatusBadRequest, 0
+		router := setupRouter(&status, &calls)
+
+		doRequest(router, "u1", "key-1", `{"type":"shoes"}`)
+		w := doRequest(router, "u1", "key-1", `{"type":"shoes"}`)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("no header passes through", func(t *testing.T) {
+		status, calls := http.StatusCreated, 0
+		router := setupRouter(&status, &calls)
+
+		doRequest(router, "u1", "", `{"type":"shoes"}`)
+		doRequest(router, "u1", "", `{"type":"shoes"}`)
+
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("too long key", func(t *testing.T) {
+		status, calls := http.StatusCreated, 0
+		router := setupRouter(&status, &calls)
+
+		w := doRequest(router, "u1", strings.Repeat("k", 256), `{}`)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Equal(t, 0, calls)
+	})
+
+	t.Run("too large body", func(t *testing.T) {
+		status, calls := http.StatusCreated, 0
+		router := setupRouter(&status, &calls)
+
+		w := doRequest(router, "u1", "key-1", strings.Repeat("x", _maxBodySize+1))
+
+		require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		require.Equal(t, 0, calls)
+	})
+}
@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// Record — сохраненный результат запроса с заголовком Idempotency-Key.
+// Ключи уникальны в пределах пользователя.
+type Record struct {
+	UserID      string
+	Key         string
+	RequestHash string
+	// StatusCode равен 0, пока исходный запрос еще выполняется
+	StatusCode  int
+	ContentType string
+	// ETag повторяется вместе с ответом: по нему клиент делает условные запросы
+	ETag      string
+	Body      []byte
+	CreatedAt time.Time
+}
+
+func (r *Record) Completed() bool {
+	return r.StatusCode != 0
+}
@@ -0,0 +1,111 @@
+package repo
+
+import (
+	"GoPVZ/internal/idempotency/entity"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgPostgres"
+	"GoPVZ/pkg/pkgValidator"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type idempotencyRepo struct {
+	db  *pgxpool.Pool
+	log pkgLogger.Interface
+}
+
+func NewIdempotencyRepo(db *pgxpool.Pool, log pkgLogger.Interface) IdempotencyRepository {
+	return &idempotencyRepo{db: db, log: log}
+}
+
+func (r *idempotencyRepo) Reserve(ctx context.Context, record *entity.Record, ttl time.Duration) (bool, error) {
+	var reserved, expired bool
+	// xmax <> 0 — ключ уже был, но истек, и запрос занял его заново
+	err := pkgPostgres.Conn(ctx, r.db).QueryRow(ctx, `
+		INSERT INTO idempotency_keys (user_id, key, request_hash, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+			status_code = NULL,
+			content_type = NULL,
+			etag = NULL,
+			response_body = NULL,
+			created_at = EXCLUDED.created_at
+		WHERE idempotency_keys.created_at < EXCLUDED.created_at - $5 * INTERVAL '1 millisecond'
+		RETURNING true, xmax <> 0`,
+		record.UserID, record.Key, record.RequestHash, record.CreatedAt, ttl.Milliseconds(),
+	).Scan(&reserved, &expired)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if expired {
+		r.log.DebugContext(ctx, "Expired idempotency key reused", slog.String("user_id", record.UserID), slog.String("key", record.Key))
+	}
+	return reserved, nil
+}
+
+func (r *idempotencyRepo) Get(ctx context.Context, userID, key string) (*entity.Record, error) {
+	var (
+		rec         entity.Record
+		statusCode  *int
+		contentType *string
+		etag        *string
+	)
+	err := pkgPostgres.Conn(ctx, r.db).QueryRow(ctx, `
+		SELECT user_id, key, request_hash, status_code, content_type, etag, response_body, created_at
+		FROM idempotency_keys WHERE user_id=$1 AND key=$2`,
+		userID, key,
+	).Scan(&rec.UserID, &rec.Key, &rec.RequestHash, &statusCode, &contentType, &etag, &rec.Body, &rec.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, pkgValidator.ErrIdempotencyKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if statusCode != nil {
+		rec.StatusCode = *statusCode
+	}
+	if contentType != nil {
+		rec.ContentType = *contentType
+	}
+	if etag != nil {
+		rec.ETag = *etag
+	}
+	return &rec, nil
+}
+
+func (r *idempotencyRepo) Complete(ctx context.Context, record *entity.Record) error {
+	_, err := pkgPostgres.Conn(ctx, r.db).Exec(ctx, `
+		UPDATE idempotency_keys
+		SET status_code=$3, content_type=$4, etag=NULLIF($5, ''), response_body=$6
+		WHERE user_id=$1 AND key=$2`,
+		record.UserID, record.Key, record.StatusCode, record.ContentType, record.ETag, record.Body,
+	)
+	return err
+}
+
+func (r *idempotencyRepo) Delete(ctx context.Context, userID, key string) error {
+	_, err := pkgPostgres.Conn(ctx, r.db).Exec(ctx,
+		`DELETE FROM idempotency_keys WHERE user_id=$1 AND key=$2`, userID, key)
+	return err
+}
+
+func (r *idempotencyRepo) DeleteExpired(ctx context.Context, ttl time.Duration) (int64, error) {
+	tag, err := pkgPostgres.Conn(ctx, r.db).Exec(ctx,
+		`DELETE FROM idempotency_keys WHERE created_at < NOW() - $1 * INTERVAL '1 millisecond'`,
+		ttl.Milliseconds(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,17 @@
+package repo
+
+import (
+	"GoPVZ/internal/idempotency/entity"
+	"context"
+	"time"
+)
+
+type IdempotencyRepository interface {
+	// Reserve занимает ключ; false означает, что живая запись с этим ключом уже есть.
+	// Запись старше ttl перезаписывается.
+	Reserve(ctx context.Context, record *entity.Record, ttl time.Duration) (bool, error)
+	Get(ctx context.Context, userID, key string) (*entity.Record, error)
+	Complete(ctx context.Context, record *entity.Record) error
+	Delete(ctx context.Context, userID, key string) error
+	DeleteExpired(ctx context.Context, ttl time.Duration) (int64, error)
+}
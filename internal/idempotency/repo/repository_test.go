@@ -0,0 +1,159 @@
+package repo
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"GoPVZ/internal/idempotency/entity"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgPostgres"
+	"GoPVZ/pkg/pkgValidator"
+
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+var (
+	testConnStr string
+	pgContainer *postgres.PostgresContainer
+)
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+
+	var err error
+	pgContainer, err = postgres.RunContainer(ctx,
+		testcontainers.WithImage("postgres:15-alpine"),
+		postgres.WithDatabase("testdb"),
+		postgres.WithUsername("testuser"),
+		postgres.WithPassword("testpass"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second),
+		),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	testConnStr, err = pgContainer.ConnectionString(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	pg, err := pkgPostgres.New(testConnStr)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = pg.Pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS idempotency_keys (
+			user_id VARCHAR(64) NOT NULL,
+			key VARCHAR(255) NOT NULL,
+			request_hash CHAR(64) NOT NULL,
+			status_code INT,
+			content_type VARCHAR(255),
+			etag VARCHAR(255),
+			response_body BYTEA,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (user_id, key)
+		);
+	`)
+	if err != nil {
+		panic(err)
+	}
+	pg.Close()
+
+	code := m.Run()
+
+	if err := pgContainer.Terminate(ctx); err != nil {
+		panic(err)
+	}
+
+	os.Exit(code)
+}
+
+func setupIdempotencyRepo(t *testing.T) (IdempotencyRepository, func()) {
+	pg, err := pkgPostgres.New(testConnStr)
+	require.NoError(t, err)
+
+	_, err = pg.Pool.Exec(context.Background(), `TRUNCATE TABLE idempotency_keys`)
+	require.NoError(t, err)
+
+	return NewIdempotencyRepo(pg.Pool, pkgLogger.Discard()), func() {
+		pg.Close()
+	}
+}
+
+func TestIdempotencyRepository_Lifecycle(t *testing.T) {
+	repo, cleanup := setupIdempotencyRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	hash := "a3f1c5e9b7d2a3f1c5e9b7d2a3f1c5e9b7d2a3f1c5e9b7d2a3f1c5e9b7d2a3f1"
+	record := &entity.Record{UserID: "user-1", Key: "key-1", RequestHash: hash, CreatedAt: time.Now()}
+
+	reserved, err := repo.Reserve(ctx, record, time.Hour)
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	// Живой ключ повторно не занимается
+	reserved, err = repo.Reserve(ctx, record, time.Hour)
+	require.NoError(t, err)
+	require.False(t, reserved)
+
+	stored, err := repo.Get(ctx, "user-1", "key-1")
+	require.NoError(t, err)
+	require.False(t, stored.Completed())
+
+	record.StatusCode = 201
+	record.ContentType = "application/json"
+	record.ETag = `"3"`
+	record.Body = []byte(`{"id":"1"}`)
+	require.NoError(t, repo.Complete(ctx, record))
+
+	stored, err = repo.Get(ctx, "user-1", "key-1")
+	require.NoError(t, err)
+	require.Equal(t, 201, stored.StatusCode)
+	require.Equal(t, "application/json", stored.ContentType)
+	require.Equal(t, `"3"`, stored.ETag)
+	require.Equal(t, record.Body, stored.Body)
+
+	require.NoError(t, repo.Delete(ctx, "user-1", "key-1"))
+	_, err = repo.Get(ctx, "user-1", "key-1")
+	require.ErrorIs(t, err, pkgValidator.ErrIdempotencyKeyNotFound)
+}
+
+func TestIdempotencyRepository_Expiry(t *testing.T) {
+	repo, cleanup := setupIdempotencyRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	old := &entity.Record{UserID: "user-1", Key: "key-1", RequestHash: "old", CreatedAt: time.Now().Add(-2 * time.Hour)}
+	reserved, err := repo.Reserve(ctx, old, time.Hour)
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	// Истекший ключ занимается заново
+	fresh := &entity.Record{UserID: "user-1", Key: "key-1", RequestHash: "fresh", CreatedAt: time.Now()}
+	reserved, err = repo.Reserve(ctx, fresh, time.Hour)
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	stale := &entity.Record{UserID: "user-2", Key: "key-2", RequestHash: "stale", CreatedAt: time.Now().Add(-2 * time.Hour)}
+	_, err = repo.Reserve(ctx, stale, time.Hour)
+	require.NoError(t, err)
+
+	deleted, err := repo.DeleteExpired(ctx, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+
+	stored, err := repo.Get(ctx, "user-1", "key-1")
+	require.NoError(t, err)
+	require.Equal(t, "fresh", stored.RequestHash[:5])
+}
@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"GoPVZ/internal/idempotency/entity"
+	"GoPVZ/internal/idempotency/repo"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgValidator"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+type IdempotencyUseCase struct {
+	repo repo.IdempotencyRepository
+	log  pkgLogger.Interface
+	ttl  time.Duration
+}
+
+func NewIdempotencyUseCase(r repo.IdempotencyRepository, log pkgLogger.Interface, ttl time.Duration) *IdempotencyUseCase {
+	return &IdempotencyUseCase{repo: r, log: log, ttl: ttl}
+}
+
+// RequestHash — отпечаток запроса, с которым сравниваются повторы с тем же ключом.
+func RequestHash(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{'\n'})
+	h.Write([]byte(path))
+	h.Write([]byte{'\n'})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Begin занимает ключ перед выполнением запроса. Возвращает nil, если запрос нужно
+// выполнить, или сохраненный ответ, если запрос с этим ключом уже был выполнен.
+func (uc *IdempotencyUseCase) Begin(ctx context.Context, userID, key, requestHash string) (*entity.Record, error) {
+	record := &entity.Record{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	// Вторая попытка нужна, если запись удалили между Reserve и Get
+	for attempt := 0; attempt < 2; attempt++ {
+		reserved, err := uc.repo.Reserve(ctx, record, uc.ttl)
+		if err != nil {
+			return nil, err
+		}
+		if reserved {
+			return nil, nil
+		}
+
+		existing, err := uc.repo.Get(ctx, userID, key)
+		if errors.Is(err, pkgValidator.ErrIdempotencyKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if existing.RequestHash != requestHash {
+			uc.log.WarnContext(ctx, "Idempotency key reused with a different request", slog.String("user_id", userID), slog.String("key", key))
+			return nil, pkgValidator.ErrIdempotencyKeyReused
+		}
+		if !existing.Completed() {
+			return nil, pkgValidator.ErrIdempotencyInProgress
+		}
+		return existing, nil
+	}
+
+	return nil, pkgValidator.ErrIdempotencyInProgress
+}
+
+// Complete сохраняет ответ для последующих повторов.
+func (uc *IdempotencyUseCase) Complete(ctx context.Context, userID, key string, statusCode int, contentType, etag string, body []byte) error {
+	return uc.repo.Complete(ctx, &entity.Record{
+		UserID:      userID,
+		Key:         key,
+		StatusCode:  statusCode,
+		ContentType: contentType,
+		ETag:        etag,
+		Body:        body,
+	})
+}
+
+// Release освобождает ключ, чтобы клиент мог повторить запрос, завершившийся ошибкой сервера.
+func (uc *IdempotencyUseCase) Release(ctx context.Context, userID, key string) error {
+	return uc.repo.Delete(ctx, userID, key)
+}
+
+// RunCleanup периодически удаляет ключи старше ttl.
+func (uc *IdempotencyUseCase) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := uc.repo.DeleteExpired(ctx, uc.ttl)
+			if err != nil {
+				uc.log.ErrorContext(ctx, "Failed to delete expired idempotency keys", pkgLogger.Err(err))
+				continue
+			}
+			if deleted > 0 {
+				uc.log.DebugContext(ctx, "Expired idempotency keys deleted", slog.Int64("count", deleted))
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"GoPVZ/internal/idempotency/entity"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgValidator"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockIdempotencyRepo struct {
+	mock.Mock
+}
+
+func (m *MockIdempotencyRepo) Reserve(ctx context.Context, record *entity.Record, ttl time.Duration) (bool, error) {
+	args := m.Called(ctx, record, ttl)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockIdempotencyRepo) Get(ctx context.Context, userID, key string) (*entity.Record, error) {
+	args := m.Called(ctx, userID, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Record), args.Error(1)
+}
+
+func (m *MockIdempotencyRepo) Complete(ctx context.Context, record *entity.Record) error {
+	args := m.Called(ctx, record)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyRepo) Delete(ctx context.Context, userID, key string) error {
+	args := m.Called(ctx, userID, key)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyRepo) DeleteExpired(ctx context.Context, ttl time.Duration) (int64, error) {
+	args := m.Called(ctx, ttl)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestIdempotencyUseCase_Begin(t *testing.T) {
+	hash := RequestHash("POST", "/products", []byte(`{"type":"shoes"}`))
+	completed := &entity.Record{UserID: "u1", Key: "k1", RequestHash: hash, StatusCode: 201, ContentType: "application/json", Body: []byte(`{}`)}
+	inProgress := &entity.Record{UserID: "u1", Key: "k1", RequestHash: hash}
+	otherPayload := &entity.Record{UserID: "u1", Key: "k1", RequestHash: RequestHash("POST", "/products", []byte(`{"type":"clothes"}`)), StatusCode: 201}
+
+	tests := []struct {
+		name       string
+		reserved   bool
+		reserveErr error
+		existing   *entity.Record
+		getErr     error
+		wantRecord *entity.Record
+		wantErr    error
+	}{
+		{name: "new key is reserved", reserved: true},
+		{name: "completed request is replayed", existing: completed, wantRecord: completed},
+		{name: "request still in progress", existing: inProgress, wantErr: pkgValidator.ErrIdempotencyInProgress},
+		{name: "key reused with different payload", existing: otherPayload, wantErr: pkgValidator.ErrIdempotencyKeyReused},
+		{name: "repository error", reserveErr: errors.New("db error"), wantErr: errors.New("db error")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockIdempotencyRepo)
+			uc := NewIdempotencyUseCase(mockRepo, pkgLogger.Discard(), time.Hour)
+
+			mockRepo.On("Reserve", mock.Anything, mock.MatchedBy(func(r *entity.Record) bool {
+				return r.UserID == "u1" && r.Key == "k1" && r.RequestHash == hash
+			}), time.Hour).Return(tt.reserved, tt.reserveErr)
+			if tt.existing != nil {
+				mockRepo.On("Get", mock.Anything, "u1", "k1").Return(tt.existing, tt.getErr)
+			}
+
+			record, err := uc.Begin(context.Background(), "u1", "k1", hash)
+
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+				assert.Nil(t, record)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantRecord, record)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestIdempotencyUseCase_Begin_RecordDeletedConcurrently(t *testing.T) {
+	mockRepo := new(MockIdempotencyRepo)
+	uc := NewIdempotencyUseCase(mockRepo, pkgLogger.Discard(), time.Hour)
+
+	mockRepo.On("Reserve", mock.Anything, mock.Anything, time.Hour).Return(false, nil).Once()
+	mockRepo.On("Get", mock.Anything, "u1", "k1").Return(nil, pkgValidator.ErrIdempotencyKeyNotFound).Once()
+	mockRepo.On("Reserve", mock.Anything, mock.Anything, time.Hour).Return(true, nil).Once()
+
+	record, err := uc.Begin(context.Background(), "u1", "k1", "hash")
+
+	assert.NoError(t, err)
+	assert.Nil(t, record)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRequestHash(t *testing.T) {
+	base := RequestHash("POST", "/products", []byte(`{"type":"shoes"}`))
+
+	assert.Equal(t, base, RequestHash("POST", "/products", []byte(`{"type":"shoes"}`)))
+	assert.NotEqual(t, base, RequestHash("POST", "/receptions", []byte(`{"type":"shoes"}`)))
+	assert.NotEqual(t, base, RequestHash("POST", "/products", []byte(`{"type":"clothes"}`)))
+}
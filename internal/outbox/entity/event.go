@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EventType string
+
+const (
+	EventPVZCreated      EventType = "pvz.created"
+	EventReceptionOpened EventType = "reception.opened"
+	EventReceptionClosed EventType = "reception.closed"
+	EventProductAdded    EventType = "product.added"
+	EventProductRemoved  EventType = "product.removed"
+)
+
+type Event struct {
+	ID          uuid.UUID       `json:"id"          db:"id"           example:"3fa85f64-5717-4562-b3fc-2c963f66afa6"`
+	Type        EventType       `json:"type"        db:"event_type"   example:"reception.closed"`
+	PvzID       uuid.UUID       `json:"pvzId"       db:"pvz_id"       example:"3fa85f64-5717-4562-b3fc-2c963f66afa6"`
+	Payload     json.RawMessage `json:"payload"     db:"payload"`
+	CreatedAt   time.Time       `json:"createdAt"   db:"created_at"   example:"2025-07-17T12:15:49.386Z"`
+	PublishedAt *time.Time      `json:"publishedAt" db:"published_at" example:"2025-07-17T12:15:50.012Z"`
+	Attempts    int             `json:"-"           db:"attempts"`
+}
+
+// NewEvent создает событие, сериализуя payload в JSON.
+func NewEvent(eventType EventType, pvzID uuid.UUID, payload any) (*Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Event{
+		ID:        uuid.New(),
+		Type:      eventType,
+		PvzID:     pvzID,
+		Payload:   data,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
@@ -0,0 +1,45 @@
+package publisher
+
+import (
+	"GoPVZ/internal/outbox/entity"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// LogPublisher пишет события построчно в формате JSON.
+type LogPublisher struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func NewLogPublisher(out io.Writer) *LogPublisher {
+	return &LogPublisher{out: out}
+}
+
+// NewFilePublisher открывает файл на дозапись. Пустой путь означает stdout.
+func NewFilePublisher(path string) (*LogPublisher, error) {
+	if path == "" {
+		return NewLogPublisher(os.Stdout), nil
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return NewLogPublisher(f), nil
+}
+
+func (p *LogPublisher) Publish(_ context.Context, event *entity.Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err = p.out.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,26 @@
+package publisher
+
+import (
+	"GoPVZ/internal/outbox/entity"
+	"context"
+)
+
+// MultiPublisher передает событие всем публикаторам по очереди.
+// При ошибке событие будет отправлено повторно всем, поэтому
+// получатели должны быть идемпотентны по ID события.
+type MultiPublisher struct {
+	publishers []Publisher
+}
+
+func NewMultiPublisher(publishers ...Publisher) *MultiPublisher {
+	return &MultiPublisher{publishers: publishers}
+}
+
+func (p *MultiPublisher) Publish(ctx context.Context, event *entity.Event) error {
+	for _, pub := range p.publishers {
+		if err := pub.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+// Package publisher содержит реализации отправки событий из outbox во внешние системы.
+package publisher
+
+import (
+	"GoPVZ/internal/outbox/entity"
+	"context"
+)
+
+// Publisher доставляет событие получателю. Ошибка означает, что событие
+// останется в outbox и будет отправлено повторно.
+type Publisher interface {
+	Publish(ctx context.Context, event *entity.Event) error
+}
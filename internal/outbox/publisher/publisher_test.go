@@ -0,0 +1,66 @@
+package publisher
+
+import (
+	"GoPVZ/internal/outbox/entity"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEvent(t *testing.T) *entity.Event {
+	event, err := entity.NewEvent(entity.EventProductAdded, uuid.New(), map[string]string{"type": "shoes"})
+	require.NoError(t, err)
+	return event
+}
+
+func TestLogPublisher_Publish(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewLogPublisher(&buf)
+	event := newTestEvent(t)
+
+	require.NoError(t, p.Publish(context.Background(), event))
+
+	var got entity.Event
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	require.Equal(t, event.ID, got.ID)
+	require.Equal(t, entity.EventProductAdded, got.Type)
+}
+
+func TestWebhookPublisher_Publish(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		wantError bool
+	}{
+		{name: "receiver accepts event", status: http.StatusNoContent},
+		{name: "receiver fails", status: http.StatusInternalServerError, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := newTestEvent(t)
+
+			var gotType string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotType = r.Header.Get("X-Event-Type")
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			err := NewWebhookPublisher(srv.URL, srv.Client()).Publish(context.Background(), event)
+
+			require.Equal(t, string(entity.EventProductAdded), gotType)
+			if tt.wantError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
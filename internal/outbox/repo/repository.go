@@ -0,0 +1,78 @@
+package repo
+
+import (
+	"GoPVZ/internal/outbox/entity"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgPostgres"
+	"context"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type outboxRepo struct {
+	db  *pgxpool.Pool
+	log pkgLogger.Interface
+}
+
+func NewOutboxRepo(db *pgxpool.Pool, log pkgLogger.Interface) OutboxRepository {
+	return &outboxRepo{db: db, log: log}
+}
+
+func (r *outboxRepo) Add(ctx context.Context, event *entity.Event) error {
+	_, err := pkgPostgres.Conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO outbox (id, event_type, pvz_id, payload, created_at) VALUES ($1,$2,$3,$4,$5)`,
+		event.ID, event.Type, event.PvzID, event.Payload, event.CreatedAt,
+	)
+	return err
+}
+
+// FetchUnpublished блокирует выбранные строки до конца транзакции,
+// поэтому несколько диспетчеров не отправят одно событие дважды.
+func (r *outboxRepo) FetchUnpublished(ctx context.Context, limit int) ([]*entity.Event, error) {
+	rows, err := pkgPostgres.Conn(ctx, r.db).Query(ctx, `
+        SELECT id, event_type, pvz_id, payload, created_at, attempts
+        FROM outbox
+        WHERE published_at IS NULL
+        ORDER BY created_at
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]*entity.Event, 0, limit)
+	for rows.Next() {
+		var e entity.Event
+		if err := rows.Scan(&e.ID, &e.Type, &e.PvzID, &e.Payload, &e.CreatedAt, &e.Attempts); err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(events) > 0 {
+		r.log.DebugContext(ctx, "Outbox events fetched", slog.Int("count", len(events)))
+	}
+	return events, nil
+}
+
+func (r *outboxRepo) MarkPublished(ctx context.Context, id string) error {
+	_, err := pkgPostgres.Conn(ctx, r.db).Exec(ctx,
+		`UPDATE outbox SET published_at = NOW(), attempts = attempts + 1, last_error = NULL WHERE id = $1`, id,
+	)
+	return err
+}
+
+func (r *outboxRepo) MarkFailed(ctx context.Context, id string, reason string) error {
+	_, err := pkgPostgres.Conn(ctx, r.db).Exec(ctx,
+		`UPDATE outbox SET attempts = attempts + 1, last_error = $2 WHERE id = $1`, id, reason,
+	)
+	if err != nil {
+		return err
+	}
+	r.log.DebugContext(ctx, "Outbox event marked failed", slog.String("event_id", id), slog.String("reason", reason))
+	return nil
+}
@@ -0,0 +1,13 @@
+package repo
+
+import (
+	"GoPVZ/internal/outbox/entity"
+	"context"
+)
+
+type OutboxRepository interface {
+	Add(ctx context.Context, event *entity.Event) error
+	FetchUnpublished(ctx context.Context, limit int) ([]*entity.Event, error)
+	MarkPublished(ctx context.Context, id string) error
+	MarkFailed(ctx context.Context, id string, reason string) error
+}
@@ -0,0 +1,143 @@
+package repo
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"GoPVZ/internal/outbox/entity"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgPostgres"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+var (
+	testConnStr string
+	pgContainer *postgres.PostgresContainer
+)
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+
+	var err error
+	pgContainer, err = postgres.RunContainer(ctx,
+		testcontainers.WithImage("postgres:15-alpine"),
+		postgres.WithDatabase("testdb"),
+		postgres.WithUsername("testuser"),
+		postgres.WithPassword("testpass"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second),
+		),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	testConnStr, err = pgContainer.ConnectionString(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	pg, err := pkgPostgres.New(testConnStr)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = pg.Pool.Exec(ctx, `
+		CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+		CREATE TABLE IF NOT EXISTS outbox (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			event_type VARCHAR(50) NOT NULL,
+			pvz_id UUID NOT NULL,
+			payload JSONB NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			published_at TIMESTAMPTZ,
+			attempts INT NOT NULL DEFAULT 0,
+			last_error TEXT
+		);
+	`)
+	if err != nil {
+		panic(err)
+	}
+	pg.Close()
+
+	code := m.Run()
+
+	if err := pgContainer.Terminate(ctx); err != nil {
+		panic(err)
+	}
+
+	os.Exit(code)
+}
+
+func setupOutboxRepo(t *testing.T) (OutboxRepository, pkgPostgres.Transactor, func()) {
+	pg, err := pkgPostgres.New(testConnStr)
+	require.NoError(t, err)
+
+	_, err = pg.Pool.Exec(context.Background(), `TRUNCATE TABLE outbox`)
+	require.NoError(t, err)
+
+	return NewOutboxRepo(pg.Pool, pkgLogger.Discard()), pkgPostgres.NewTransactor(pg.Pool), func() {
+		pg.Close()
+	}
+}
+
+func TestOutboxRepository_PublishFlow(t *testing.T) {
+	repo, tx, cleanup := setupOutboxRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	first, err := entity.NewEvent(entity.EventPVZCreated, uuid.New(), map[string]string{"city": "Kazan"})
+	require.NoError(t, err)
+	second, err := entity.NewEvent(entity.EventReceptionOpened, first.PvzID, map[string]string{"status": "in_progress"})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Add(ctx, first))
+	require.NoError(t, repo.Add(ctx, second))
+
+	err = tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		events, err := repo.FetchUnpublished(ctx, 10)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		require.Equal(t, first.ID, events[0].ID)
+
+		require.NoError(t, repo.MarkPublished(ctx, first.ID.String()))
+		return repo.MarkFailed(ctx, second.ID.String(), "receiver down")
+	})
+	require.NoError(t, err)
+
+	events, err := repo.FetchUnpublished(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, second.ID, events[0].ID)
+	require.Equal(t, 1, events[0].Attempts)
+}
+
+func TestOutboxRepository_RollbackDiscardsEvent(t *testing.T) {
+	repo, tx, cleanup := setupOutboxRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	event, err := entity.NewEvent(entity.EventProductAdded, uuid.New(), map[string]string{"type": "shoes"})
+	require.NoError(t, err)
+
+	err = tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		require.NoError(t, repo.Add(ctx, event))
+		return context.Canceled
+	})
+	require.ErrorIs(t, err, context.Canceled)
+
+	events, err := repo.FetchUnpublished(ctx, 10)
+	require.NoError(t, err)
+	require.Empty(t, events)
+}
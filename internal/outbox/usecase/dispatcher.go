@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"GoPVZ/internal/outbox/publisher"
+	"GoPVZ/internal/outbox/repo"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgPostgres"
+	"context"
+	"log/slog"
+	"time"
+)
+
+const (
+	_defaultPollInterval = time.Second
+	_defaultBatchSize    = 100
+)
+
+// Dispatcher периодически вычитывает неотправленные события из outbox
+// и передает их в Publisher.
+type Dispatcher struct {
+	repo         repo.OutboxRepository
+	tx           pkgPostgres.Transactor
+	publisher    publisher.Publisher
+	log          pkgLogger.Interface
+	pollInterval time.Duration
+	batchSize    int
+}
+
+func NewDispatcher(
+	r repo.OutboxRepository,
+	tx pkgPostgres.Transactor,
+	p publisher.Publisher,
+	log pkgLogger.Interface,
+	pollInterval time.Duration,
+	batchSize int,
+) *Dispatcher {
+	if pollInterval <= 0 {
+		pollInterval = _defaultPollInterval
+	}
+	if batchSize <= 0 {
+		batchSize = _defaultBatchSize
+	}
+
+	return &Dispatcher{
+		repo:         r,
+		tx:           tx,
+		publisher:    p,
+		log:          log,
+		pollInterval: pollInterval,
+		batchSize:    batchSize,
+	}
+}
+
+// Run блокируется до отмены контекста.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := d.DispatchBatch(ctx); err != nil && ctx.Err() == nil {
+				d.log.Error("Outbox dispatch failed", pkgLogger.Err(err))
+			}
+		}
+	}
+}
+
+// DispatchBatch отправляет одну пачку событий и возвращает число успешно опубликованных.
+func (d *Dispatcher) DispatchBatch(ctx context.Context) (int, error) {
+	published := 0
+
+	err := d.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		events, err := d.repo.FetchUnpublished(ctx, d.batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			if err := d.publisher.Publish(ctx, event); err != nil {
+				d.log.Warn("Outbox event publish failed",
+					slog.String("event_id", event.ID.String()),
+					slog.String("event_type", string(event.Type)),
+					pkgLogger.Err(err),
+				)
+				if err := d.repo.MarkFailed(ctx, event.ID.String(), err.Error()); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := d.repo.MarkPublished(ctx, event.ID.String()); err != nil {
+				return err
+			}
+			published++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return published, nil
+}
@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"GoPVZ/internal/outbox/entity"
+	"GoPVZ/pkg/pkgLogger"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOutboxRepo struct {
+	mock.Mock
+}
+
+func (m *MockOutboxRepo) Add(ctx context.Context, event *entity.Event) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepo) FetchUnpublished(ctx context.Context, limit int) ([]*entity.Event, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).([]*entity.Event), args.Error(1)
+}
+
+func (m *MockOutboxRepo) MarkPublished(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepo) MarkFailed(ctx context.Context, id string, reason string) error {
+	args := m.Called(ctx, id, reason)
+	return args.Error(0)
+}
+
+type MockPublisher struct {
+	mock.Mock
+}
+
+func (m *MockPublisher) Publish(ctx context.Context, event *entity.Event) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+type fakeTransactor struct{}
+
+func (fakeTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func TestDispatcher_DispatchBatch(t *testing.T) {
+	okEvent := &entity.Event{ID: uuid.New(), Type: entity.EventReceptionOpened, PvzID: uuid.New()}
+	badEvent := &entity.Event{ID: uuid.New(), Type: entity.EventReceptionClosed, PvzID: uuid.New()}
+
+	tests := []struct {
+		name          string
+		events        []*entity.Event
+		fetchError    error
+		publishErrors map[uuid.UUID]error
+		wantPublished int
+		wantError     bool
+	}{
+		{
+			name:          "all events published",
+			events:        []*entity.Event{okEvent},
+			wantPublished: 1,
+		},
+		{
+			name:          "failed event is marked and skipped",
+			events:        []*entity.Event{okEvent, badEvent},
+			publishErrors: map[uuid.UUID]error{badEvent.ID: errors.New("receiver down")},
+			wantPublished: 1,
+		},
+		{
+			name:       "fetch error",
+			events:     []*entity.Event{},
+			fetchError: errors.New("db error"),
+			wantError:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockOutboxRepo)
+			mockPublisher := new(MockPublisher)
+			d := NewDispatcher(mockRepo, fakeTransactor{}, mockPublisher, pkgLogger.Discard(), time.Second, 10)
+
+			mockRepo.On("FetchUnpublished", mock.Anything, 10).Return(tt.events, tt.fetchError)
+			for _, e := range tt.events {
+				if tt.fetchError != nil {
+					break
+				}
+				pubErr := tt.publishErrors[e.ID]
+				mockPublisher.On("Publish", mock.Anything, e).Return(pubErr)
+				if pubErr != nil {
+					mockRepo.On("MarkFailed", mock.Anything, e.ID.String(), pubErr.Error()).Return(nil)
+				} else {
+					mockRepo.On("MarkPublished", mock.Anything, e.ID.String()).Return(nil)
+				}
+			}
+
+			published, err := d.DispatchBatch(context.Background())
+
+			if tt.wantError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantPublished, published)
+			}
+			mockRepo.AssertExpectations(t)
+			mockPublisher.AssertExpectations(t)
+		})
+	}
+}
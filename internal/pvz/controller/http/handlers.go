@@ -2,24 +2,37 @@ package http
 
 import (
 	"GoPVZ/internal/dto"
+	"GoPVZ/internal/pvz/entity"
+	"GoPVZ/internal/pvz/importer"
 	"GoPVZ/internal/pvz/usecase"
 	"GoPVZ/internal/pvz/validation"
+	"GoPVZ/pkg/pkgLogger"
 	"GoPVZ/pkg/pkgValidator"
 	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+// Максимальный размер CSV для импорта ПВЗ
+const _maxImportSize = 10 << 20
+
+// Интервал комментариев-пингов, чтобы прокси не закрывали простаивающий SSE поток
+const _sseHeartbeatInterval = 15 * time.Second
+
 type PVZHandler struct {
-	uc *usecase.PVZUseCase
+	uc  *usecase.PVZUseCase
+	log pkgLogger.Interface
 }
 
-func NewPVZHandler(uc *usecase.PVZUseCase) *PVZHandler {
-	return &PVZHandler{uc: uc}
+func NewPVZHandler(uc *usecase.PVZUseCase, log pkgLogger.Interface) *PVZHandler {
+	return &PVZHandler{uc: uc, log: log}
 }
 
 // CreatePVZ godoc
@@ -29,10 +42,13 @@ func NewPVZHandler(uc *usecase.PVZUseCase) *PVZHandler {
 // @Accept json
 // @Produce json
 // @Param input body dto.PostPvzJSONRequestBody true "Данные для создания ПВЗ"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор с тем же ключом вернет сохраненный ответ"
 // @Success 201 {object} dto.PVZ "ПВЗ успешно создан"
 // @Failure 400 {object} dto.Error "Неверный формат запроса или ошибка валидации"
 // @Failure 401 {object} dto.Error "Ошибка авторизации"
 // @Failure 403 {object} dto.Error "Доступ запрещен"
+// @Failure 409 {object} dto.Error "Запрос с этим Idempotency-Key еще выполняется"
+// @Failure 422 {object} dto.Error "Idempotency-Key уже использован с другим запросом"
 // @Failure 500 {object} dto.Error "Внутренняя ошибка сервера"
 // @Security BearerAuth
 // @Router /pvz [post]
@@ -49,14 +65,211 @@ func (h *PVZHandler) CreatePVZ(c *gin.Context) {
 		return
 	}
 
-	pvz, err := h.uc.CreatePVZ(c, string(req.City))
+	pvz, err := h.uc.CreatePVZ(c, scopeFrom(c), string(req.City))
+	if errors.Is(err, pkgValidator.ErrOutOfScope) {
+		c.JSON(http.StatusForbidden, dto.Error{Message: err.Error()})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.Error{Message: err.Error()})
+		respondInternalError(c, h.log, err)
 		return
 	}
+	c.Header("ETag", pvz.VersionTag().String())
 	c.JSON(http.StatusCreated, dto.PVZ{Id: pvz.ID, City: dto.PVZCity(pvz.City), RegistrationDate: pvz.RegistrationDate})
 }
 
+// GetPVZ godoc
+// @Summary Получение ПВЗ по id (для сотрудников ПВЗ или модераторов)
+// @Description Возвращает ПВЗ и его версию в заголовке ETag. С заголовком If-None-Match отвечает 304, если ПВЗ не менялся
+// @Tags Domain pvz
+// @Produce json
+// @Param pvzId path string true "pvzId"
+// @Param If-None-Match header string false "ETag, полученный ранее"
+// @Success 200 {object} dto.PVZ "ПВЗ"
+// @Header 200 {string} ETag "Версия ПВЗ"
+// @Success 304 "ПВЗ не изменился"
+// @Failure 400 {object} dto.Error "Невалидный pvzId"
+// @Failure 401 {object} dto.Error "Ошибка авторизации"
+// @Failure 403 {object} dto.Error "Доступ запрещен"
+// @Failure 404 {object} dto.Error "ПВЗ не найден"
+// @Failure 500 {object} dto.Error "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /pvz/{pvzId} [get]
+func (h *PVZHandler) GetPVZ(c *gin.Context) {
+	pvzId := c.Param("pvzId")
+
+	validator := validation.NewPVZIDValidator(pvzId)
+	if err := validator.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: err.Error()})
+		return
+	}
+
+	pvz, err := h.uc.GetPVZ(c, scopeFrom(c), pvzId)
+	if err != nil {
+		if errors.Is(err, pkgValidator.ErrPVZNotFound) {
+			c.JSON(http.StatusNotFound, dto.Error{Message: err.Error()})
+		} else {
+			respondInternalError(c, h.log, err)
+		}
+		return
+	}
+
+	if notModified(c, pvz.VersionTag()) {
+		return
+	}
+	c.JSON(http.StatusOK, dto.PVZ{Id: pvz.ID, City: dto.PVZCity(pvz.City), RegistrationDate: pvz.RegistrationDate})
+}
+
+// GetReception godoc
+// @Summary Получение приемки по id (для сотрудников ПВЗ или модераторов)
+// @Description Возвращает приемку и ее версию в заголовке ETag. ETag передается в If-Match при закрытии приемки
+// @Tags Domain pvz
+// @Produce json
+// @Param receptionId path string true "receptionId"
+// @Param If-None-Match header string false "ETag, полученный ранее"
+// @Success 200 {object} dto.Reception "Приемка"
+// @Header 200 {string} ETag "Версия приемки"
+// @Success 304 "Приемка не изменилась"
+// @Failure 400 {object} dto.Error "Невалидный receptionId"
+// @Failure 401 {object} dto.Error "Ошибка авторизации"
+// @Failure 403 {object} dto.Error "Доступ запрещен"
+// @Failure 404 {object} dto.Error "Приемка не найдена"
+// @Failure 500 {object} dto.Error "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /receptions/{receptionId} [get]
+func (h *PVZHandler) GetReception(c *gin.Context) {
+	receptionId := c.Param("receptionId")
+
+	validator := validation.NewReceptionIDValidator(receptionId)
+	if err := validator.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: err.Error()})
+		return
+	}
+
+	reception, err := h.uc.GetReception(c, scopeFrom(c), receptionId)
+	if err != nil {
+		if errors.Is(err, pkgValidator.ErrReceptionNotFound) {
+			c.JSON(http.StatusNotFound, dto.Error{Message: err.Error()})
+		} else {
+			respondInternalError(c, h.log, err)
+		}
+		return
+	}
+
+	if notModified(c, reception.VersionTag()) {
+		return
+	}
+	c.JSON(http.StatusOK, dto.Reception{Id: reception.ID, PvzId: reception.PvzID, DateTime: reception.DateTime, Status: dto.ReceptionStatus(reception.Status)})
+}
+
+// scopeFrom возвращает города, которыми ограничен токен пользователя.
+func scopeFrom(c *gin.Context) entity.Scope {
+	return entity.NewScope(c.GetStringSlice("user_regions"))
+}
+
+// parseIfMatch возвращает версию из If-Match; nil — заголовка нет или он равен "*".
+func parseIfMatch(c *gin.Context) (*entity.VersionTag, error) {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" || ifMatch == "*" {
+		return nil, nil
+	}
+	return entity.ParseVersionTag(ifMatch)
+}
+
+// notModified выставляет ETag и отвечает 304, если клиент прислал тот же тег в If-None-Match.
+func notModified(c *gin.Context, tag entity.VersionTag) bool {
+	etag := tag.String()
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// ImportPVZs godoc
+// @Summary Массовый импорт ПВЗ из CSV (только для модераторов)
+// @Description Принимает CSV с колонками city, address, external_id в теле запроса (text/csv) или в поле file формы. Каждая строка проверяется по правилам POST /pvz, валидные строки вставляются одной транзакцией. ПВЗ с уже существующим external_id пропускаются, поэтому повторный импорт идемпотентен
+// @Tags Domain pvz
+// @Accept text/csv
+// @Accept multipart/form-data
+// @Produce json
+// @Param dryRun query bool false "Только проверить файл, ничего не сохраняя"
+// @Param file formData file false "CSV файл"
+// @Success 200 {object} dto.PVZImportReport "Отчет по каждой строке"
+// @Failure 400 {object} dto.Error "Файл не удалось разобрать"
+// @Failure 401 {object} dto.Error "Ошибка авторизации"
+// @Failure 403 {object} dto.Error "Доступ запрещен"
+// @Failure 500 {object} dto.Error "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /pvz/import [post]
+func (h *PVZHandler) ImportPVZs(c *gin.Context) {
+	var params dto.PostPvzImportParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: pkgValidator.ErrInvalidInput.Error()})
+		return
+	}
+	dryRun := params.DryRun != nil && *params.DryRun
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, _maxImportSize)
+
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.Error{Message: pkgValidator.ErrInvalidInput.Error()})
+			return
+		}
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.Error{Message: pkgValidator.ErrInvalidInput.Error()})
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+
+	rows, err := importer.ParseCSV(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: err.Error()})
+		return
+	}
+
+	report, err := h.uc.ImportPVZs(c, scopeFrom(c), rows, dryRun)
+	if err != nil {
+		respondInternalError(c, h.log, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toImportReportDTO(report))
+}
+
+func toImportReportDTO(report *entity.ImportReport) dto.PVZImportReport {
+	resp := dto.PVZImportReport{
+		DryRun:   report.DryRun,
+		Created:  report.Created,
+		Existing: report.Existing,
+		Invalid:  report.Invalid,
+		Rows:     make([]dto.PVZImportRow, 0, len(report.Rows)),
+	}
+	for _, row := range report.Rows {
+		item := dto.PVZImportRow{
+			Line:       row.Line,
+			City:       string(row.City),
+			Address:    row.Address,
+			ExternalId: row.ExternalID,
+			Status:     dto.PVZImportRowStatus(row.Status),
+			PvzId:      row.PvzID,
+		}
+		if row.Error != "" {
+			rowErr := row.Error
+			item.Error = &rowErr
+		}
+		resp.Rows = append(resp.Rows, item)
+	}
+	return resp
+}
+
 // CreateReception godoc
 // @Summary Создание новой приемки товаров (только для сотрудников ПВЗ)
 // @Description Создает новую запись о приеме в ПВЗ (пункте выдачи заказов) с указанным PVZ ID
@@ -64,10 +277,14 @@ func (h *PVZHandler) CreatePVZ(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param input body dto.PostReceptionsJSONBody true "Данные для создания записи приема"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор с тем же ключом вернет сохраненный ответ"
 // @Success 201 {object} dto.Reception "Успешно созданная запись приема"
 // @Failure 400 {object} dto.Error "Невалидные входные данные"
 // @Failure 401 {object} dto.Error "Ошибка авторизации"
 // @Failure 403 {object} dto.Error "Доступ запрещен"
+// @Failure 404 {object} dto.Error "ПВЗ не найден"
+// @Failure 409 {object} dto.Error "Запрос с этим Idempotency-Key еще выполняется"
+// @Failure 422 {object} dto.Error "Idempotency-Key уже использован с другим запросом"
 // @Failure 500 {object} dto.Error "Внутренняя ошибка сервера"
 // @Security BearerAuth
 // @Router /receptions [post]
@@ -84,11 +301,16 @@ func (h *PVZHandler) CreateReception(c *gin.Context) {
 		return
 	}
 
-	reception, err := h.uc.CreateReception(c, uuid.UUID(req.PvzId).String())
+	reception, err := h.uc.CreateReception(c, scopeFrom(c), uuid.UUID(req.PvzId).String())
+	if errors.Is(err, pkgValidator.ErrPVZNotFound) {
+		c.JSON(http.StatusNotFound, dto.Error{Message: err.Error()})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.Error{Message: err.Error()})
+		respondInternalError(c, h.log, err)
 		return
 	}
+	c.Header("ETag", reception.VersionTag().String())
 	c.JSON(http.StatusCreated, dto.Reception{Id: reception.ID, PvzId: reception.PvzID, DateTime: reception.DateTime, Status: dto.ReceptionStatus(reception.Status)})
 }
 
@@ -99,10 +321,14 @@ func (h *PVZHandler) CreateReception(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param input body dto.PostProductsJSONBody true "Данные для создания продукта"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор с тем же ключом вернет сохраненный ответ"
 // @Success 201 {object} dto.Product "Успешно созданный продукт"
 // @Failure 400 {object} dto.Error "Невалидные входные данные"
 // @Failure 401 {object} dto.Error "Ошибка авторизации"
 // @Failure 403 {object} dto.Error "Доступ запрещен"
+// @Failure 404 {object} dto.Error "ПВЗ не найден"
+// @Failure 409 {object} dto.Error "Запрос с этим Idempotency-Key еще выполняется"
+// @Failure 422 {object} dto.Error "Idempotency-Key уже использован с другим запросом"
 // @Failure 500 {object} dto.Error "Внутренняя ошибка сервера"
 // @Security BearerAuth
 // @Router /products [post]
@@ -119,9 +345,13 @@ func (h *PVZHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := h.uc.CreateProduct(c, string(req.Type), uuid.UUID(req.PvzId).String())
+	product, err := h.uc.CreateProduct(c, scopeFrom(c), string(req.Type), uuid.UUID(req.PvzId).String())
+	if errors.Is(err, pkgValidator.ErrPVZNotFound) {
+		c.JSON(http.StatusNotFound, dto.Error{Message: err.Error()})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.Error{Message: err.Error()})
+		respondInternalError(c, h.log, err)
 		return
 	}
 	c.JSON(http.StatusCreated, dto.Product{Id: product.ID, ReceptionId: product.ReceptionID, DateTime: product.DateTime, Type: dto.ProductType(product.Type)})
@@ -134,10 +364,14 @@ func (h *PVZHandler) CreateProduct(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param pvzId path string true "pvzId"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор с тем же ключом вернет сохраненный ответ"
 // @Success 200 "Товар успешно удален"
 // @Failure 400 {object} dto.Error "Нет активной приемки или другие ошибки валидации"
 // @Failure 401 {object} dto.Error "Ошибка авторизации"
 // @Failure 403 {object} dto.Error "Доступ запрещен"
+// @Failure 404 {object} dto.Error "ПВЗ не найден"
+// @Failure 409 {object} dto.Error "Запрос с этим Idempotency-Key еще выполняется"
+// @Failure 422 {object} dto.Error "Idempotency-Key уже использован с другим запросом"
 // @Failure 500 {object} dto.Error "Внутренняя ошибка сервера"
 // @Security BearerAuth
 // @Router /pvz/{pvzId}/delete_last_product [post]
@@ -151,11 +385,13 @@ func (h *PVZHandler) DeleteLastProduct(c *gin.Context) {
         return
     }
 
-    if err := h.uc.DeleteLastProduct(c, pvzId); err != nil {
-        if errors.Is(err, pkgValidator.ErrNoActiveReception) {
+    if err := h.uc.DeleteLastProduct(c, scopeFrom(c), pvzId); err != nil {
+        if errors.Is(err, pkgValidator.ErrNoActiveReception) || errors.Is(err, pkgValidator.ErrNoProductsToDelete) {
             c.JSON(http.StatusBadRequest, dto.Error{Message: err.Error()})
+        } else if errors.Is(err, pkgValidator.ErrPVZNotFound) {
+            c.JSON(http.StatusNotFound, dto.Error{Message: err.Error()})
         } else {
-            c.JSON(http.StatusInternalServerError, dto.Error{Message: err.Error()})
+            respondInternalError(c, h.log, err)
         }
         return
     }
@@ -171,10 +407,16 @@ func (h *PVZHandler) DeleteLastProduct(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param pvzId path string true "pvzId"
+// @Param If-Match header string false "ETag приемки, полученный ранее: закрыть только если она не менялась"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор с тем же ключом вернет сохраненный ответ"
 // @Success 200 {object} dto.Reception "Приёмка успешно закрыта"
 // @Failure 400 {object} dto.Error "Нет активной приемки или другие ошибки валидации"
 // @Failure 401 {object} dto.Error "Ошибка авторизации"
 // @Failure 403 {object} dto.Error "Доступ запрещен"
+// @Failure 404 {object} dto.Error "ПВЗ не найден"
+// @Failure 409 {object} dto.Error "Запрос с этим Idempotency-Key еще выполняется"
+// @Failure 412 {object} dto.Error "Приемка изменилась после получения ETag"
+// @Failure 422 {object} dto.Error "Idempotency-Key уже использован с другим запросом"
 // @Failure 500 {object} dto.Error "Внутренняя ошибка сервера"
 // @Security BearerAuth
 // @Router /pvz/{pvzId}/close_last_reception [post]
@@ -188,16 +430,27 @@ func (h *PVZHandler) CloseReception(c *gin.Context) {
         return
     }
 
-    reception, err := h.uc.CloseReception(c, pvzId)
+    expected, err := parseIfMatch(c)
+    if err != nil {
+        c.JSON(http.StatusPreconditionFailed, dto.Error{Message: pkgValidator.ErrVersionMismatch.Error()})
+        return
+    }
+
+    reception, err := h.uc.CloseReception(c, scopeFrom(c), pvzId, expected)
     if err != nil {
         if errors.Is(err, pkgValidator.ErrNoActiveReception) {
             c.JSON(http.StatusBadRequest, dto.Error{Message: err.Error()})
+        } else if errors.Is(err, pkgValidator.ErrPVZNotFound) {
+            c.JSON(http.StatusNotFound, dto.Error{Message: err.Error()})
+        } else if errors.Is(err, pkgValidator.ErrVersionMismatch) {
+            c.JSON(http.StatusPreconditionFailed, dto.Error{Message: err.Error()})
         } else {
-            c.JSON(http.StatusInternalServerError, dto.Error{Message: err.Error()})
+            respondInternalError(c, h.log, err)
         }
         return
     }
 
+    c.Header("ETag", reception.VersionTag().String())
     c.JSON(http.StatusOK, dto.Reception{
         Id:      reception.ID,
         PvzId:   reception.PvzID,
@@ -252,9 +505,9 @@ func (h *PVZHandler) GetPVZsWithReceptions(c *gin.Context) {
     }
 
     // Получаем данные
-    pvzs, err := h.uc.GetPVZsWithReceptions(c, startTime, endTime, page, limit)
+    pvzs, err := h.uc.GetPVZsWithReceptions(c, scopeFrom(c), startTime, endTime, page, limit)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, dto.Error{Message: err.Error()})
+        respondInternalError(c, h.log, err)
         return
     }
 
@@ -299,4 +552,77 @@ func (h *PVZHandler) GetPVZsWithReceptions(c *gin.Context) {
     }
 
     c.JSON(http.StatusOK, response)
-}
\ No newline at end of file
+}
+
+// StreamEvents godoc
+// @Summary Живая лента событий ПВЗ (Server-Sent Events, для сотрудников ПВЗ или модераторов)
+// @Description Транслирует события reception.opened, reception.closed, product.added, product.removed по мере их возникновения. Имя SSE события совпадает с типом доменного события
+// @Tags Domain pvz
+// @Produce text/event-stream
+// @Param pvzId path string true "pvzId"
+// @Success 200 {string} string "Поток событий"
+// @Failure 400 {object} dto.Error "Невалидный pvzId"
+// @Failure 401 {object} dto.Error "Ошибка авторизации"
+// @Failure 403 {object} dto.Error "Доступ запрещен"
+// @Failure 404 {object} dto.Error "ПВЗ не найден"
+// @Failure 500 {object} dto.Error "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /pvz/{pvzId}/events [get]
+func (h *PVZHandler) StreamEvents(c *gin.Context) {
+    pvzId := c.Param("pvzId")
+
+    validator := validation.NewPVZIDValidator(pvzId)
+    if err := validator.Validate(); err != nil {
+        c.JSON(http.StatusBadRequest, dto.Error{Message: err.Error()})
+        return
+    }
+
+    events, err := h.uc.SubscribeEvents(c.Request.Context(), scopeFrom(c), pvzId)
+    if err != nil {
+        if errors.Is(err, pkgValidator.ErrPVZNotFound) {
+            c.JSON(http.StatusNotFound, dto.Error{Message: err.Error()})
+        } else {
+            respondInternalError(c, h.log, err)
+        }
+        return
+    }
+
+    // Поток живет дольше WriteTimeout сервера
+    _ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+    c.Header("Content-Type", "text/event-stream")
+    c.Header("Cache-Control", "no-cache")
+    c.Header("Connection", "keep-alive")
+    c.Header("X-Accel-Buffering", "no")
+    c.Status(http.StatusOK)
+    _, _ = io.WriteString(c.Writer, ": connected\n\n")
+    c.Writer.Flush()
+
+    heartbeat := time.NewTicker(_sseHeartbeatInterval)
+    defer heartbeat.Stop()
+
+    c.Stream(func(w io.Writer) bool {
+        select {
+        case event, ok := <-events:
+            if !ok {
+                return false
+            }
+            c.Render(-1, sse.Event{
+                Id:    event.ID.String(),
+                Event: string(event.Type),
+                Data:  event,
+            })
+            return true
+        case <-heartbeat.C:
+            _, _ = io.WriteString(w, ": ping\n\n")
+            return true
+        }
+    })
+}
+
+// respondInternalError пишет ошибку в лог и отвечает 500. Маршрут, пользователь
+// и request_id попадают в запись из логгера запроса.
+func respondInternalError(c *gin.Context, log pkgLogger.Interface, err error) {
+	log.ErrorContext(c, "Request failed", pkgLogger.Err(err))
+	c.JSON(http.StatusInternalServerError, dto.Error{Message: err.Error()})
+}
@@ -13,9 +13,13 @@ import (
 	"time"
 
 	"GoPVZ/internal/dto"
+	outboxRepo "GoPVZ/internal/outbox/repo"
+	"GoPVZ/internal/pvz/entity"
 	"GoPVZ/internal/pvz/repo"
 	"GoPVZ/internal/pvz/usecase"
+	"GoPVZ/pkg/pkgLogger"
 	"GoPVZ/pkg/pkgPostgres"
+	"GoPVZ/pkg/pkgPubSub"
 	"GoPVZ/pkg/pkgValidator"
 
 	"github.com/gin-gonic/gin"
@@ -64,14 +68,18 @@ func TestMain(m *testing.M) {
 		CREATE TABLE IF NOT EXISTS pvz (
 			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
 			registration_date TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-			city VARCHAR(50) NOT NULL CHECK (city IN ('Moscow', 'Saint Petersburg', 'Kazan'))
+			city VARCHAR(50) NOT NULL CHECK (city IN ('Moscow', 'Saint Petersburg', 'Kazan')),
+			address TEXT NOT NULL DEFAULT '',
+			external_id VARCHAR(100) UNIQUE,
+			version INT NOT NULL DEFAULT 1
 		);
 		
 		CREATE TABLE IF NOT EXISTS receptions (
 			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
 			pvz_id UUID NOT NULL REFERENCES pvz(id),
 			date_time TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-			status VARCHAR(20) NOT NULL CHECK (status IN ('in_progress', 'close'))
+			status VARCHAR(20) NOT NULL CHECK (status IN ('in_progress', 'close')),
+			version INT NOT NULL DEFAULT 1
 		);
 		
 		CREATE TABLE IF NOT EXISTS products (
@@ -80,6 +88,17 @@ func TestMain(m *testing.M) {
 			date_time TIMESTAMPTZ NOT NULL DEFAULT NOW(),
 			type VARCHAR(20) NOT NULL CHECK (type IN ('electronics', 'clothes', 'shoes'))
 		);
+
+		CREATE TABLE IF NOT EXISTS outbox (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			event_type VARCHAR(50) NOT NULL,
+			pvz_id UUID NOT NULL,
+			payload JSONB NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			published_at TIMESTAMPTZ,
+			attempts INT NOT NULL DEFAULT 0,
+			last_error TEXT
+		);
 	`)
 	if err != nil {
 		panic(err)
@@ -99,23 +118,32 @@ func setupTestPVZHandler(t *testing.T) (*PVZHandler, func()) {
 	pg, err := pkgPostgres.New(testConnStr)
 	require.NoError(t, err)
 
-	_, err = pg.Pool.Exec(context.Background(), "TRUNCATE TABLE products, receptions, pvz CASCADE")
+	_, err = pg.Pool.Exec(context.Background(), "TRUNCATE TABLE products, receptions, pvz, outbox CASCADE")
 	require.NoError(t, err)
 
-	pvzRepo := repo.NewPVZRepo(pg.Pool)
-	uc := usecase.NewPVZUseCase(pvzRepo)
-	handler := NewPVZHandler(uc)
+	pvzRepo := repo.NewPVZRepo(pkgPostgres.NewCluster(pg.Pool), pkgLogger.Discard())
+	eventsRepo := outboxRepo.NewOutboxRepo(pg.Pool, pkgLogger.Discard())
+	uc := usecase.NewPVZUseCase(pvzRepo, eventsRepo, pkgPostgres.NewTransactor(pg.Pool), pkgPubSub.NewMemory(0), pkgLogger.Discard())
+	handler := NewPVZHandler(uc, pkgLogger.Discard())
 
 	return handler, func() {
 		pg.Close()
 	}
 }
 
+// newTestRouter возвращает роутер с токеном без ограничения по городам, как
+// его выставил бы AuthMiddleware.
+func newTestRouter() *gin.Engine {
+	router := gin.Default()
+	router.Use(func(c *gin.Context) { c.Set("user_regions", []string{entity.AllCities}) })
+	return router
+}
+
 func TestCreatePVZHandler(t *testing.T) {
 	handler, cleanup := setupTestPVZHandler(t)
 	defer cleanup()
 
-	router := gin.Default()
+	router := newTestRouter()
 	router.POST("/pvz", handler.CreatePVZ)
 
 	tests := []struct {
@@ -191,7 +219,7 @@ func TestCreateReceptionHandler(t *testing.T) {
 	handler, cleanup := setupTestPVZHandler(t)
 	defer cleanup()
 
-	router := gin.Default()
+	router := newTestRouter()
 	router.POST("/receptions", handler.CreateReception)
 
 	// Создаем тестовый PVZ без приемки
@@ -285,7 +313,7 @@ func TestCreateProductHandler(t *testing.T) {
 	handler, cleanup := setupTestPVZHandler(t)
 	defer cleanup()
 
-	router := gin.Default()
+	router := newTestRouter()
 	router.POST("/products", handler.CreateProduct)
 
 	// Создаем тестовые данные: PVZ -> Reception -> Product
@@ -453,7 +481,7 @@ func TestDeleteLastProductHandler(t *testing.T) {
 	handler, cleanup := setupTestPVZHandler(t)
 	defer cleanup()
 
-	router := gin.Default()
+	router := newTestRouter()
 	router.POST("/pvz/:pvzId/delete_last_product", handler.DeleteLastProduct)
 
 	// Создаем тестовые данные: PVZ -> Reception -> Products
@@ -636,7 +664,7 @@ func TestCloseReceptionHandler(t *testing.T) {
     handler, cleanup := setupTestPVZHandler(t)
     defer cleanup()
 
-    router := gin.Default()
+    router := newTestRouter()
     router.POST("/pvz/:pvzId/close_last_reception", handler.CloseReception)
 
     // Создаем тестовые данные
@@ -762,7 +790,7 @@ func TestGetPVZsWithReceptionsHandler(t *testing.T) {
     handler, cleanup := setupTestPVZHandler(t)
     defer cleanup()
 
-    router := gin.Default()
+    router := newTestRouter()
     router.GET("/pvz", handler.GetPVZsWithReceptions)
 
     // Подготовка тестовых данных
@@ -992,4 +1020,54 @@ func TestGetPVZsWithReceptionsHandler(t *testing.T) {
             }
         })
     }
-}
\ No newline at end of file
+}
+
+func TestImportPVZsHandler(t *testing.T) {
+	handler, cleanup := setupTestPVZHandler(t)
+	defer cleanup()
+
+	router := newTestRouter()
+	router.POST("/pvz/import", handler.ImportPVZs)
+
+	csvBody := "city,address,external_id\n" +
+		"Moscow,ул. Ленина 1,MSK-1\n" +
+		"Kazan,ул. Баумана 2,KZN-1\n" +
+		"Berlin,Unter den Linden 1,BER-1\n"
+
+	tests := []struct {
+		name         string
+		query        string
+		body         string
+		wantStatus   int
+		wantCreated  int
+		wantExisting int
+		wantInvalid  int
+	}{
+		{name: "dry run does not save", query: "?dryRun=true", body: csvBody, wantStatus: http.StatusOK, wantCreated: 2, wantInvalid: 1},
+		{name: "valid rows are saved", body: csvBody, wantStatus: http.StatusOK, wantCreated: 2, wantInvalid: 1},
+		{name: "re-import is idempotent", body: csvBody, wantStatus: http.StatusOK, wantExisting: 2, wantInvalid: 1},
+		{name: "invalid header", body: "city\nMoscow\n", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/pvz/import"+tt.query, bytes.NewReader([]byte(tt.body)))
+			req.Header.Set("Content-Type", "text/csv")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp dto.PVZImportReport
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			require.Equal(t, tt.wantCreated, resp.Created)
+			require.Equal(t, tt.wantExisting, resp.Existing)
+			require.Equal(t, tt.wantInvalid, resp.Invalid)
+			require.Len(t, resp.Rows, 3)
+		})
+	}
+}
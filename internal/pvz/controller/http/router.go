@@ -1,7 +1,9 @@
 package http
 
 import (
+	authEntity "GoPVZ/internal/auth/entity"
 	"GoPVZ/internal/pvz/usecase"
+	"GoPVZ/pkg/pkgLogger"
 
 	"github.com/gin-gonic/gin"
 )
@@ -9,32 +11,34 @@ import (
 func NewPVZRouter(
     router *gin.RouterGroup,
     uc *usecase.PVZUseCase,
+    log pkgLogger.Interface,
     authMiddleware gin.HandlerFunc,
-    employeeOnly gin.HandlerFunc,
-    moderatorOnly gin.HandlerFunc,
-    employeeOrModerator gin.HandlerFunc,
+    require func(permission authEntity.Permission) gin.HandlerFunc,
+    idempotency gin.HandlerFunc,
 ) {
-    handler := NewPVZHandler(uc)
+    handler := NewPVZHandler(uc, log)
     
     // Protected routes group
     protected := router.Group("/")
     protected.Use(authMiddleware)
     
-    // Routes for employees only
-    employeeRoutes := protected.Group("/")
-    employeeRoutes.Use(employeeOnly)
-	employeeRoutes.POST("/receptions", handler.CreateReception)
-	employeeRoutes.POST("/products", handler.CreateProduct)
-	employeeRoutes.POST("/pvz/:pvzId/delete_last_product", handler.DeleteLastProduct)
-	employeeRoutes.POST("/pvz/:pvzId/close_last_reception", handler.CloseReception)
+    // Reception and product management
+	protected.POST("/receptions", require(authEntity.PermissionReceptionCreate), idempotency, handler.CreateReception)
+	protected.POST("/products", require(authEntity.PermissionProductCreate), idempotency, handler.CreateProduct)
+	protected.POST("/pvz/:pvzId/delete_last_product", require(authEntity.PermissionProductDelete), idempotency, handler.DeleteLastProduct)
+	protected.POST("/pvz/:pvzId/close_last_reception", require(authEntity.PermissionReceptionClose), idempotency, handler.CloseReception)
     
-    // Routes for moderators only
-    moderatorRoutes := protected.Group("/")
-    moderatorRoutes.Use(moderatorOnly)
-	moderatorRoutes.POST("/pvz", handler.CreatePVZ)
+    // PVZ management
+	protected.POST("/pvz", require(authEntity.PermissionPVZCreate), idempotency, handler.CreatePVZ)
+	// Импорт уже идемпотентен по external_id, а файл может быть больше,
+	// чем middleware держит в памяти
+	protected.POST("/pvz/import", require(authEntity.PermissionPVZImport), handler.ImportPVZs)
     
-    // Routes for both employees and moderators
-    commonRoutes := protected.Group("/")
-    commonRoutes.Use(employeeOrModerator)
-    commonRoutes.GET("/pvz", handler.GetPVZsWithReceptions)
-}
\ No newline at end of file
+    // Read-only routes
+    readRoutes := protected.Group("/")
+    readRoutes.Use(require(authEntity.PermissionPVZRead))
+    readRoutes.GET("/pvz", handler.GetPVZsWithReceptions)
+    readRoutes.GET("/pvz/:pvzId", handler.GetPVZ)
+    readRoutes.GET("/pvz/:pvzId/events", handler.StreamEvents)
+    readRoutes.GET("/receptions/:receptionId", handler.GetReception)
+}
@@ -0,0 +1,30 @@
+package entity
+
+import "github.com/google/uuid"
+
+type ImportRowStatus string
+
+const (
+	ImportRowCreated ImportRowStatus = "created"
+	ImportRowExists  ImportRowStatus = "exists"
+	ImportRowInvalid ImportRowStatus = "invalid"
+)
+
+// ImportRow — строка CSV импорта ПВЗ. Line — номер строки в файле с учетом заголовка.
+type ImportRow struct {
+	Line       int             `json:"line"`
+	City       City            `json:"city"`
+	Address    string          `json:"address"`
+	ExternalID string          `json:"externalId"`
+	Status     ImportRowStatus `json:"status,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	PvzID      *uuid.UUID      `json:"pvzId,omitempty"`
+}
+
+type ImportReport struct {
+	DryRun   bool         `json:"dryRun"`
+	Created  int          `json:"created"`
+	Existing int          `json:"existing"`
+	Invalid  int          `json:"invalid"`
+	Rows     []*ImportRow `json:"rows"`
+}
@@ -14,10 +14,22 @@ const (
 	CityKazan           City = "Kazan"
 )
 
+func (c City) IsValid() bool {
+	switch c {
+	case CityMoscow, CitySaintPetersburg, CityKazan:
+		return true
+	default:
+		return false
+	}
+}
+
 type PVZ struct {
-	ID               uuid.UUID `json:"id"               db:"id"                example:"3fa85f64-5717-4562-b3fc-2c963f66afa6"`
-	RegistrationDate time.Time `json:"registrationDate" db:"registration_date" example:"2025-07-17T12:15:49.386Z"`
-	City             City      `json:"city"             db:"city"              example:"Moscow"`
+	ID               uuid.UUID `json:"id"                   db:"id"                example:"3fa85f64-5717-4562-b3fc-2c963f66afa6"`
+	RegistrationDate time.Time `json:"registrationDate"     db:"registration_date" example:"2025-07-17T12:15:49.386Z"`
+	City             City      `json:"city"                 db:"city"              example:"Moscow"`
+	Address          string    `json:"address,omitempty"    db:"address"           example:"ул. Ленина, 1"`
+	ExternalID       string    `json:"externalId,omitempty" db:"external_id"       example:"MSK-0001"`
+	Version          int       `json:"version"              db:"version"           example:"1"`
 }
 
 type PVZWithReceptions struct {
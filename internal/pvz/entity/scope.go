@@ -0,0 +1,56 @@
+package entity
+
+// AllCities — отметка в списке городов пользователя или ключа: доступ ко всем
+// городам. Пустой список доступа не дает.
+const AllCities = "*"
+
+// Scope — города, в которых пользователь работает с ПВЗ. Нулевой Scope ничем
+// не ограничен; его используют внутренние вызовы, например CLI импорта.
+type Scope struct {
+	Cities []City
+	// restricted — доступ ограничен Cities; ограниченный Scope без городов не
+	// дает доступа ни к одному ПВЗ
+	restricted bool
+}
+
+// NewScope строит Scope по городам из токена. Список с AllCities не ограничен,
+// пустой список не дает доступа.
+func NewScope(cities []string) Scope {
+	scope := Scope{restricted: true}
+	for _, city := range cities {
+		if city == AllCities {
+			return Scope{}
+		}
+		scope.Cities = append(scope.Cities, City(city))
+	}
+	return scope
+}
+
+func (s Scope) Restricted() bool {
+	return s.restricted
+}
+
+func (s Scope) Allows(city City) bool {
+	if !s.Restricted() {
+		return true
+	}
+	for _, allowed := range s.Cities {
+		if allowed == city {
+			return true
+		}
+	}
+	return false
+}
+
+// CityNames возвращает города для фильтра в запросе; nil — без ограничений,
+// пустой срез — ни одного города.
+func (s Scope) CityNames() []string {
+	if !s.Restricted() {
+		return nil
+	}
+	names := make([]string, 0, len(s.Cities))
+	for _, city := range s.Cities {
+		names = append(names, string(city))
+	}
+	return names
+}
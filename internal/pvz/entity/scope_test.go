@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScope(t *testing.T) {
+	unrestricted := NewScope([]string{AllCities})
+	assert.False(t, unrestricted.Restricted())
+	assert.True(t, unrestricted.Allows(CityMoscow))
+	assert.Nil(t, unrestricted.CityNames())
+	assert.False(t, Scope{}.Restricted())
+
+	scope := NewScope([]string{"Kazan", "Moscow"})
+	assert.True(t, scope.Restricted())
+	assert.True(t, scope.Allows(CityKazan))
+	assert.False(t, scope.Allows(CitySaintPetersburg))
+	assert.Equal(t, []string{"Kazan", "Moscow"}, scope.CityNames())
+
+	// Пустой список регионов не означает «все города»
+	none := NewScope(nil)
+	assert.True(t, none.Restricted())
+	assert.False(t, none.Allows(CityMoscow))
+	assert.Equal(t, []string{}, none.CityNames())
+}
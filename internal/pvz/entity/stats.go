@@ -0,0 +1,20 @@
+package entity
+
+// Stats — снимок состояния ПВЗ для метрик.
+type Stats struct {
+	OpenReceptions []OpenReceptionsCount
+	ProductsOnHand []ProductsCount
+}
+
+// OpenReceptionsCount — число незакрытых приемок в городе.
+type OpenReceptionsCount struct {
+	City  City
+	Count int
+}
+
+// ProductsCount — число принятых товаров типа в городе.
+type ProductsCount struct {
+	City  City
+	Type  Type
+	Count int
+}
@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var errInvalidVersionTag = errors.New("invalid version tag")
+
+// VersionTag — версия конкретной записи, передаваемая в ETag/If-Match.
+// Идентификатор входит в тег, чтобы тег закрытой и заново открытой приемки не совпадал.
+type VersionTag struct {
+	ID      uuid.UUID
+	Version int
+}
+
+// String возвращает значение для заголовка ETag, например "3fa85f64-...-2c963f66afa6.2".
+func (t VersionTag) String() string {
+	return fmt.Sprintf(`"%s.%d"`, t.ID, t.Version)
+}
+
+// ParseVersionTag разбирает значение If-Match, сформированное VersionTag.String.
+func ParseVersionTag(s string) (*VersionTag, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return nil, errInvalidVersionTag
+	}
+
+	id, version, ok := strings.Cut(s[1:len(s)-1], ".")
+	if !ok {
+		return nil, errInvalidVersionTag
+	}
+
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errInvalidVersionTag
+	}
+	parsedVersion, err := strconv.Atoi(version)
+	if err != nil || parsedVersion < 1 {
+		return nil, errInvalidVersionTag
+	}
+
+	return &VersionTag{ID: parsedID, Version: parsedVersion}, nil
+}
+
+func (p *PVZ) VersionTag() VersionTag {
+	return VersionTag{ID: p.ID, Version: p.Version}
+}
+
+func (r *Reception) VersionTag() VersionTag {
+	return VersionTag{ID: r.ID, Version: r.Version}
+}
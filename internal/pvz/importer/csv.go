@@ -0,0 +1,79 @@
+package importer
+
+import (
+	"GoPVZ/internal/pvz/entity"
+	"GoPVZ/internal/pvz/validation"
+	"GoPVZ/pkg/pkgValidator"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+)
+
+// MaxRows ограничивает размер одного импорта: все строки вставляются одной транзакцией.
+const MaxRows = 5000
+
+var requiredColumns = []string{"city", "address", "external_id"}
+
+// ParseCSV читает CSV с заголовком city,address,external_id (порядок колонок любой,
+// лишние колонки игнорируются) и проверяет каждую строку. Невалидные строки
+// возвращаются со статусом invalid и текстом ошибки; ошибка функции означает,
+// что файл не удалось разобрать целиком.
+func ParseCSV(r io.Reader) ([]*entity.ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, pkgValidator.ErrInvalidImportHeader
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		// BOM, который добавляет Excel при сохранении в CSV UTF-8
+		name = strings.TrimPrefix(name, "\ufeff")
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range requiredColumns {
+		if _, ok := index[name]; !ok {
+			return nil, pkgValidator.ErrInvalidImportHeader
+		}
+	}
+
+	field := func(record []string, name string) string {
+		if i := index[name]; i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []*entity.ImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == MaxRows {
+			return nil, pkgValidator.ErrImportTooLarge
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := &entity.ImportRow{
+			Line:       line,
+			City:       entity.City(field(record, "city")),
+			Address:    field(record, "address"),
+			ExternalID: field(record, "external_id"),
+		}
+		if err := validation.NewPVZImportRowValidator(string(row.City), row.Address, row.ExternalID).Validate(); err != nil {
+			row.Status = entity.ImportRowInvalid
+			row.Error = err.Error()
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
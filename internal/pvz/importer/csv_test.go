@@ -0,0 +1,85 @@
+package importer
+
+import (
+	"GoPVZ/internal/pvz/entity"
+	"GoPVZ/pkg/pkgValidator"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantErr     error
+		wantRows    int
+		wantInvalid map[int]string
+	}{
+		{
+			name: "valid file with columns in any order",
+			input: "external_id,city,address\n" +
+				"MSK-1,Moscow,\"ул. Ленина, 1\"\n" +
+				"SPB-1,Saint Petersburg,Невский пр., 10\n",
+			wantRows: 2,
+		},
+		{
+			name: "per-row validation errors",
+			input: "\ufeffcity,address,external_id\n" +
+				"Berlin,Unter den Linden 1,BER-1\n" +
+				"Kazan,,KZN-1\n" +
+				"Kazan,ул. Баумана,\n" +
+				"Kazan,ул. Баумана,KZN-2\n",
+			wantRows: 4,
+			wantInvalid: map[int]string{
+				2: pkgValidator.ErrInvalidCity.Error(),
+				3: pkgValidator.ErrInvalidAddress.Error(),
+				4: pkgValidator.ErrInvalidExternalID.Error(),
+			},
+		},
+		{
+			name:    "missing column",
+			input:   "city,address\nMoscow,ул. Ленина\n",
+			wantErr: pkgValidator.ErrInvalidImportHeader,
+		},
+		{
+			name:    "empty file",
+			input:   "",
+			wantErr: pkgValidator.ErrInvalidImportHeader,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ParseCSV(strings.NewReader(tt.input))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, rows, tt.wantRows)
+
+			for _, row := range rows {
+				if msg, ok := tt.wantInvalid[row.Line]; ok {
+					assert.Equal(t, entity.ImportRowInvalid, row.Status, "line %d", row.Line)
+					assert.Equal(t, msg, row.Error, "line %d", row.Line)
+				} else {
+					assert.Empty(t, row.Status, "line %d", row.Line)
+				}
+			}
+		})
+	}
+}
+
+func TestParseCSV_TooManyRows(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("city,address,external_id\n")
+	for i := 0; i <= MaxRows; i++ {
+		b.WriteString("Moscow,ул. Ленина,MSK\n")
+	}
+
+	_, err := ParseCSV(strings.NewReader(b.String()))
+	assert.ErrorIs(t, err, pkgValidator.ErrImportTooLarge)
+}
@@ -2,35 +2,68 @@ package repo
 
 import (
 	"GoPVZ/internal/pvz/entity"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgPostgres"
+	"GoPVZ/pkg/pkgValidator"
 	"context"
+	"errors"
+	"log/slog"
 	"sort"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type pvzRepo struct {
-	db *pgxpool.Pool
+	db      *pgxpool.Pool
+	cluster *pkgPostgres.Cluster
+	log     pkgLogger.Interface
 }
 
-func NewPVZRepo(db *pgxpool.Pool) PVZRepository {
-	return &pvzRepo{db: db}
+// NewPVZRepo -. Запись и чтение внутри операций записи идут в основной пул,
+// списки ПВЗ и статистика читаются из реплик кластера.
+func NewPVZRepo(cluster *pkgPostgres.Cluster, log pkgLogger.Interface) PVZRepository {
+	return &pvzRepo{db: cluster.Primary(), cluster: cluster, log: log}
 }
 
 func (r *pvzRepo) CreatePVZ(ctx context.Context, pvz *entity.PVZ) error {
-	_, err := r.db.Exec(ctx,
-		`INSERT INTO pvz (id, registration_date, city) VALUES ($1,$2,$3)`,
-		pvz.ID, pvz.RegistrationDate, pvz.City,
+	_, err := pkgPostgres.Conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO pvz (id, registration_date, city, address, external_id) VALUES ($1,$2,$3,$4,NULLIF($5,''))`,
+		pvz.ID, pvz.RegistrationDate, pvz.City, pvz.Address, pvz.ExternalID,
 	)
 	return err
 }
 
+// CreatePVZIfNotExists вставляет ПВЗ, если ПВЗ с таким external_id еще нет.
+// Возвращает false, если запись уже существовала.
+func (r *pvzRepo) CreatePVZIfNotExists(ctx context.Context, pvz *entity.PVZ) (bool, error) {
+	var id uuid.UUID
+	err := pkgPostgres.Conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO pvz (id, registration_date, city, address, external_id) VALUES ($1,$2,$3,$4,$5)
+		ON CONFLICT (external_id) DO NOTHING
+		RETURNING id`,
+		pvz.ID, pvz.RegistrationDate, pvz.City, pvz.Address, pvz.ExternalID,
+	).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		r.log.DebugContext(ctx, "PVZ already exists, skipped", slog.String("external_id", pvz.ExternalID))
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (r *pvzRepo) GetById(ctx context.Context, id string) (*entity.PVZ, error) {
 	var u entity.PVZ
-	err := r.db.QueryRow(ctx,
-		`SELECT id, registration_date, city FROM pvz WHERE id=$1`, id,
-	).Scan(&u.ID, &u.RegistrationDate, &u.City)
+	err := pkgPostgres.Conn(ctx, r.db).QueryRow(ctx,
+		`SELECT id, registration_date, city, address, COALESCE(external_id, ''), version FROM pvz WHERE id=$1`, id,
+	).Scan(&u.ID, &u.RegistrationDate, &u.City, &u.Address, &u.ExternalID, &u.Version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, pkgValidator.ErrPVZNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -38,7 +71,7 @@ func (r *pvzRepo) GetById(ctx context.Context, id string) (*entity.PVZ, error) {
 }
 
 func (r *pvzRepo) CreateReception(ctx context.Context, reception *entity.Reception) error {
-	_, err := r.db.Exec(ctx,
+	_, err := pkgPostgres.Conn(ctx, r.db).Exec(ctx,
 		`INSERT INTO receptions (id, pvz_id, date_time, status) VALUES ($1,$2,$3,$4)`,
 		reception.ID, reception.PvzID, reception.DateTime, reception.Status,
 	)
@@ -47,7 +80,7 @@ func (r *pvzRepo) CreateReception(ctx context.Context, reception *entity.Recepti
 
 func (r *pvzRepo) CheckPvzsLastReceptionStatusInProgress(ctx context.Context, pvzId string) (bool, error) {
 	var exists bool
-	err := r.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM receptions WHERE pvz_id=$1 AND status=$2)`, pvzId, entity.StatusInProgress).Scan(&exists)
+	err := pkgPostgres.Conn(ctx, r.db).QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM receptions WHERE pvz_id=$1 AND status=$2)`, pvzId, entity.StatusInProgress).Scan(&exists)
 	if err != nil {
 		return false, err
 	}
@@ -55,7 +88,7 @@ func (r *pvzRepo) CheckPvzsLastReceptionStatusInProgress(ctx context.Context, pv
 }
 
 func (r *pvzRepo) CreateProduct(ctx context.Context, product *entity.Product) error {
-	_, err := r.db.Exec(ctx,
+	_, err := pkgPostgres.Conn(ctx, r.db).Exec(ctx,
 		`INSERT INTO products (id, reception_id, date_time, type) VALUES ($1,$2,$3,$4)`,
 		product.ID, product.ReceptionID, product.DateTime, product.Type,
 	)
@@ -64,65 +97,88 @@ func (r *pvzRepo) CreateProduct(ctx context.Context, product *entity.Product) er
 
 func (r *pvzRepo) GetInProgressReceptionIdByPVZId(ctx context.Context, pvzId string) (string, error) {
 	var receptionId string
-	err := r.db.QueryRow(ctx, `SELECT id FROM receptions WHERE pvz_id=$1 AND status=$2`, pvzId, entity.StatusInProgress).Scan(&receptionId)
+	err := pkgPostgres.Conn(ctx, r.db).QueryRow(ctx, `SELECT id FROM receptions WHERE pvz_id=$1 AND status=$2`, pvzId, entity.StatusInProgress).Scan(&receptionId)
 	if err != nil {
 		return "", err
 	}
 	return receptionId, nil
 }
 
-func (r *pvzRepo) DeleteLastProductFromReception(ctx context.Context, pvzId string) error {
+func (r *pvzRepo) DeleteLastProductFromReception(ctx context.Context, pvzId string) (*entity.Product, error) {
 	// Получаем ID активной приёмки
 	receptionId, err := r.GetInProgressReceptionIdByPVZId(ctx, pvzId)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Удаляем последний добавленный товар для этой приёмки
-	_, err = r.db.Exec(ctx, `
+	var product entity.Product
+	err = pkgPostgres.Conn(ctx, r.db).QueryRow(ctx, `
         DELETE FROM products 
         WHERE id = (
             SELECT id FROM products 
             WHERE reception_id = $1 
             ORDER BY date_time DESC 
             LIMIT 1
-        )`, receptionId)
+        )
+        RETURNING id, reception_id, date_time, type`, receptionId).Scan(
+		&product.ID, &product.ReceptionID, &product.DateTime, &product.Type)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, pkgValidator.ErrNoProductsToDelete
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	return err
+	return &product, nil
 }
 
-func (r *pvzRepo) CloseReception(ctx context.Context, pvzId string) (*entity.Reception, error) {
-	// Получаем ID активной приёмки
-	receptionId, err := r.GetInProgressReceptionIdByPVZId(ctx, pvzId)
-	if err != nil {
-		return nil, err
+// CloseReception закрывает активную приемку ПВЗ. Если передан expected, приемка
+// закрывается только при совпадении id и версии, иначе возвращается ErrVersionMismatch.
+func (r *pvzRepo) CloseReception(ctx context.Context, pvzId string, expected *entity.VersionTag) (*entity.Reception, error) {
+	var expectedID *uuid.UUID
+	var expectedVersion *int
+	if expected != nil {
+		expectedID, expectedVersion = &expected.ID, &expected.Version
 	}
 
-	// Обновляем статус приёмки на "close"
-	_, err = r.db.Exec(ctx, `
-        UPDATE receptions 
-        SET status = $1 
-        WHERE id = $2 AND status = $3`,
-		entity.StatusClose, receptionId, entity.StatusInProgress)
+	var reception entity.Reception
+	err := pkgPostgres.Conn(ctx, r.db).QueryRow(ctx, `
+        UPDATE receptions
+        SET status = $1, version = version + 1
+        WHERE pvz_id = $2 AND status = $3
+            AND ($4::uuid IS NULL OR (id = $4 AND version = $5))
+        RETURNING id, pvz_id, date_time, status, version`,
+		entity.StatusClose, pvzId, entity.StatusInProgress, expectedID, expectedVersion,
+	).Scan(&reception.ID, &reception.PvzID, &reception.DateTime, &reception.Status, &reception.Version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		if expected != nil {
+			return nil, pkgValidator.ErrVersionMismatch
+		}
+		return nil, pkgValidator.ErrNoActiveReception
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	// Получаем обновлённую запись приёмки
+	return &reception, nil
+}
+
+func (r *pvzRepo) GetReceptionById(ctx context.Context, id string) (*entity.Reception, error) {
 	var reception entity.Reception
-	err = r.db.QueryRow(ctx, `
-        SELECT id, pvz_id, date_time, status 
-        FROM receptions 
-        WHERE id = $1`, receptionId).Scan(
-		&reception.ID, &reception.PvzID, &reception.DateTime, &reception.Status)
+	err := pkgPostgres.Conn(ctx, r.db).QueryRow(ctx,
+		`SELECT id, pvz_id, date_time, status, version FROM receptions WHERE id=$1`, id,
+	).Scan(&reception.ID, &reception.PvzID, &reception.DateTime, &reception.Status, &reception.Version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, pkgValidator.ErrReceptionNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
-
 	return &reception, nil
 }
 
-func (r *pvzRepo) GetPVZsWithReceptions(ctx context.Context, startDate, endDate *time.Time, limit, offset int) ([]*entity.PVZWithReceptions, error) {
+func (r *pvzRepo) GetPVZsWithReceptions(ctx context.Context, cities []string, startDate, endDate *time.Time, limit, offset int) ([]*entity.PVZWithReceptions, error) {
     query := `
         SELECT 
             p.id AS pvz_id, 
@@ -139,11 +195,12 @@ func (r *pvzRepo) GetPVZsWithReceptions(ctx context.Context, startDate, endDate
             AND ($1::timestamp IS NULL OR r.date_time >= $1)
             AND ($2::timestamp IS NULL OR r.date_time <= $2)
         LEFT JOIN products pr ON r.id = pr.reception_id
+        WHERE $5::text[] IS NULL OR p.city = ANY($5)
         ORDER BY p.registration_date DESC, r.date_time DESC, pr.date_time DESC
         LIMIT $3 OFFSET $4
     `
 
-    rows, err := r.db.Query(ctx, query, startDate, endDate, limit, offset)
+    rows, err := pkgPostgres.Conn(ctx, r.cluster.Reader(ctx)).Query(ctx, query, startDate, endDate, limit, offset, cities)
     if err != nil {
         return nil, err
     }
@@ -240,4 +297,49 @@ func (r *pvzRepo) GetPVZsWithReceptions(ctx context.Context, startDate, endDate
     })
 
     return result, nil
-}
\ No newline at end of file
+}
+// GetStats считает незакрытые приемки по городам и принятые товары по городам и
+// типам. Выдачи товаров в сервисе нет, поэтому на руках — все принятые товары.
+func (r *pvzRepo) GetStats(ctx context.Context) (*entity.Stats, error) {
+	stats := &entity.Stats{}
+	conn := pkgPostgres.Conn(ctx, r.cluster.Reader(ctx))
+
+	rows, err := conn.Query(ctx,
+		`SELECT p.city, COUNT(*) FROM receptions r JOIN pvz p ON p.id = r.pvz_id WHERE r.status = $1 GROUP BY p.city`,
+		entity.StatusInProgress,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var c entity.OpenReceptionsCount
+		if err := rows.Scan(&c.City, &c.Count); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		stats.OpenReceptions = append(stats.OpenReceptions, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = conn.Query(ctx,
+		`SELECT p.city, pr.type, COUNT(*) FROM products pr
+		JOIN receptions r ON r.id = pr.reception_id
+		JOIN pvz p ON p.id = r.pvz_id
+		GROUP BY p.city, pr.type`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c entity.ProductsCount
+		if err := rows.Scan(&c.City, &c.Type, &c.Count); err != nil {
+			return nil, err
+		}
+		stats.ProductsOnHand = append(stats.ProductsOnHand, c)
+	}
+	return stats, rows.Err()
+}
@@ -8,6 +8,7 @@ import (
 
 type PVZRepository interface {
 	CreatePVZ(ctx context.Context, user *entity.PVZ) error
+	CreatePVZIfNotExists(ctx context.Context, pvz *entity.PVZ) (bool, error)
 	GetById(ctx context.Context, id string) (*entity.PVZ, error)
 
 	CreateReception(ctx context.Context, reception *entity.Reception) error
@@ -15,8 +16,11 @@ type PVZRepository interface {
 
 	CreateProduct(ctx context.Context, product *entity.Product) error
 	GetInProgressReceptionIdByPVZId(ctx context.Context, pvzId string) (string, error)
-	DeleteLastProductFromReception(ctx context.Context, pvzId string) error
-	CloseReception(ctx context.Context, pvzId string) (*entity.Reception, error)
-	GetPVZsWithReceptions(ctx context.Context, startDate, endDate *time.Time, limit, offset int) ([]*entity.PVZWithReceptions, error)
+	DeleteLastProductFromReception(ctx context.Context, pvzId string) (*entity.Product, error)
+	CloseReception(ctx context.Context, pvzId string, expected *entity.VersionTag) (*entity.Reception, error)
+	GetReceptionById(ctx context.Context, id string) (*entity.Reception, error)
+	GetPVZsWithReceptions(ctx context.Context, cities []string, startDate, endDate *time.Time, limit, offset int) ([]*entity.PVZWithReceptions, error)
+
+	GetStats(ctx context.Context) (*entity.Stats, error)
 
 }
@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"GoPVZ/internal/pvz/entity"
+	"GoPVZ/pkg/pkgLogger"
 	"GoPVZ/pkg/pkgPostgres"
+	"GoPVZ/pkg/pkgValidator"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
@@ -56,14 +58,18 @@ func TestMain(m *testing.M) {
 		CREATE TABLE IF NOT EXISTS pvz (
 			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
 			registration_date TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-			city VARCHAR(50) NOT NULL CHECK (city IN ('Moscow', 'Saint Petersburg', 'Kazan'))
+			city VARCHAR(50) NOT NULL CHECK (city IN ('Moscow', 'Saint Petersburg', 'Kazan')),
+			address TEXT NOT NULL DEFAULT '',
+			external_id VARCHAR(100) UNIQUE,
+			version INT NOT NULL DEFAULT 1
 		);
 
 		CREATE TABLE IF NOT EXISTS receptions (
 			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
 			pvz_id UUID NOT NULL REFERENCES pvz(id),
 			date_time TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-			status VARCHAR(20) NOT NULL CHECK (status IN ('in_progress', 'close'))
+			status VARCHAR(20) NOT NULL CHECK (status IN ('in_progress', 'close')),
+			version INT NOT NULL DEFAULT 1
 		);
 
 		CREATE TABLE IF NOT EXISTS products (
@@ -96,7 +102,7 @@ func setupPVZRepo(t *testing.T) (PVZRepository, func()) {
 	`)
 	require.NoError(t, err)
 
-	repo := NewPVZRepo(pg.Pool)
+	repo := NewPVZRepo(pkgPostgres.NewCluster(pg.Pool), pkgLogger.Discard())
 
 	return repo, func() {
 		pg.Close()
@@ -312,7 +318,7 @@ func TestPVZRepository_CloseReception(t *testing.T) {
 			pvzID: uuid.New().String(),
 			setup: func() {},
 			wantError:   true,
-			errorString: "no rows in result set",
+			errorString: pkgValidator.ErrNoActiveReception.Error(),
 		},
 	}
 
@@ -320,7 +326,7 @@ func TestPVZRepository_CloseReception(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setup()
 
-			reception, err := repo.CloseReception(ctx, tt.pvzID)
+			reception, err := repo.CloseReception(ctx, tt.pvzID, nil)
 			if tt.wantError {
 				require.Error(t, err)
 				require.Contains(t, err.Error(), tt.errorString)
@@ -380,12 +386,13 @@ func TestPVZRepository_DeleteLastProductFromReception(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := repo.DeleteLastProductFromReception(ctx, tt.pvzID)
+			deleted, err := repo.DeleteLastProductFromReception(ctx, tt.pvzID)
 			if tt.wantError {
 				require.Error(t, err)
 				require.Contains(t, err.Error(), tt.errorString)
 			} else {
 				require.NoError(t, err)
+				require.Equal(t, tt.wantDeleted, deleted.ID)
 
 				// Проверим, остался только product1
 				products := getProductsForReception(t, repo, reception.ID)
@@ -402,7 +409,7 @@ func getProductsForReception(t *testing.T, repo PVZRepository, receptionID uuid.
 	start := time.Now().Add(-24 * time.Hour)
 	end := time.Now().Add(24 * time.Hour)
 
-	pvzs, err := repo.GetPVZsWithReceptions(ctx, &start, &end, 10, 0)
+	pvzs, err := repo.GetPVZsWithReceptions(ctx, nil, &start, &end, 10, 0)
 	require.NoError(t, err)
 
 	for _, pvz := range pvzs {
@@ -480,6 +487,7 @@ func TestPVZRepository_GetPVZsWithReceptions(t *testing.T) {
 
     tests := []struct {
         name           string
+        cities         []string
         startDate      *time.Time
         endDate        *time.Time
         limit          int
@@ -509,11 +517,20 @@ func TestPVZRepository_GetPVZsWithReceptions(t *testing.T) {
             expectedRecs:   map[uuid.UUID]int{pvz2.ID: 1}, // pvz2 должен быть первым из-за сортировки
             expectedProds:  map[uuid.UUID]int{},
         },
+        {
+            name:           "filter by region",
+            cities:         []string{"Moscow"},
+            limit:          10,
+            offset:         0,
+            expectedPVZs:   1,
+            expectedRecs:   map[uuid.UUID]int{pvz1.ID: 2},
+            expectedProds:  map[uuid.UUID]int{reception1.ID: 2},
+        },
     }
 
     for _, tt := range tests {
         t.Run(tt.name, func(t *testing.T) {
-            result, err := repo.GetPVZsWithReceptions(ctx, tt.startDate, tt.endDate, tt.limit, tt.offset)
+            result, err := repo.GetPVZsWithReceptions(ctx, tt.cities, tt.startDate, tt.endDate, tt.limit, tt.offset)
             require.NoError(t, err)
             require.Len(t, result, tt.expectedPVZs)
 
@@ -568,4 +585,95 @@ func TestPVZRepository_GetPVZsWithReceptions(t *testing.T) {
             }
         })
     }
-}
\ No newline at end of file
+}
+
+func TestPVZRepository_CreatePVZIfNotExists(t *testing.T) {
+	repo, cleanup := setupPVZRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	pvz := &entity.PVZ{ID: uuid.New(), RegistrationDate: time.Now(), City: "Kazan", Address: "ул. Баумана, 1", ExternalID: "KZN-0001"}
+
+	created, err := repo.CreatePVZIfNotExists(ctx, pvz)
+	require.NoError(t, err)
+	require.True(t, created)
+
+	// Повторный импорт с тем же external_id ничего не меняет
+	duplicate := *pvz
+	duplicate.ID = uuid.New()
+	duplicate.Address = "другой адрес"
+	created, err = repo.CreatePVZIfNotExists(ctx, &duplicate)
+	require.NoError(t, err)
+	require.False(t, created)
+
+	stored, err := repo.GetById(ctx, pvz.ID.String())
+	require.NoError(t, err)
+	require.Equal(t, pvz.Address, stored.Address)
+	require.Equal(t, pvz.ExternalID, stored.ExternalID)
+
+	_, err = repo.GetById(ctx, duplicate.ID.String())
+	require.ErrorIs(t, err, pkgValidator.ErrPVZNotFound)
+}
+
+func TestPVZRepository_CloseReceptionIfMatch(t *testing.T) {
+	repo, cleanup := setupPVZRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	pvz := &entity.PVZ{ID: uuid.New(), RegistrationDate: time.Now(), City: "Moscow"}
+	require.NoError(t, repo.CreatePVZ(ctx, pvz))
+
+	first := &entity.Reception{ID: uuid.New(), PvzID: pvz.ID, DateTime: time.Now(), Status: entity.StatusInProgress}
+	require.NoError(t, repo.CreateReception(ctx, first))
+
+	stored, err := repo.GetReceptionById(ctx, first.ID.String())
+	require.NoError(t, err)
+	require.Equal(t, 1, stored.Version)
+	staleTag := stored.VersionTag()
+
+	// Кто-то другой закрывает приемку и открывает новую
+	closed, err := repo.CloseReception(ctx, pvz.ID.String(), &staleTag)
+	require.NoError(t, err)
+	require.Equal(t, 2, closed.Version)
+
+	second := &entity.Reception{ID: uuid.New(), PvzID: pvz.ID, DateTime: time.Now(), Status: entity.StatusInProgress}
+	require.NoError(t, repo.CreateReception(ctx, second))
+
+	// Клиент со старым тегом не закроет новую приемку
+	_, err = repo.CloseReception(ctx, pvz.ID.String(), &staleTag)
+	require.ErrorIs(t, err, pkgValidator.ErrVersionMismatch)
+
+	current, err := repo.GetReceptionById(ctx, second.ID.String())
+	require.NoError(t, err)
+	require.Equal(t, entity.StatusInProgress, current.Status)
+
+	_, err = repo.GetReceptionById(ctx, uuid.New().String())
+	require.ErrorIs(t, err, pkgValidator.ErrReceptionNotFound)
+}
+
+func TestPVZRepository_GetStats(t *testing.T) {
+	repo, cleanup := setupPVZRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	moscow := &entity.PVZ{ID: uuid.New(), RegistrationDate: time.Now(), City: entity.CityMoscow}
+	kazan := &entity.PVZ{ID: uuid.New(), RegistrationDate: time.Now(), City: entity.CityKazan}
+	require.NoError(t, repo.CreatePVZ(ctx, moscow))
+	require.NoError(t, repo.CreatePVZ(ctx, kazan))
+
+	closed := &entity.Reception{ID: uuid.New(), PvzID: moscow.ID, DateTime: time.Now(), Status: entity.StatusInProgress}
+	require.NoError(t, repo.CreateReception(ctx, closed))
+	require.NoError(t, repo.CreateProduct(ctx, &entity.Product{ID: uuid.New(), ReceptionID: closed.ID, DateTime: time.Now(), Type: entity.TypeShoes}))
+	_, err := repo.CloseReception(ctx, moscow.ID.String(), nil)
+	require.NoError(t, err)
+
+	open := &entity.Reception{ID: uuid.New(), PvzID: moscow.ID, DateTime: time.Now(), Status: entity.StatusInProgress}
+	require.NoError(t, repo.CreateReception(ctx, open))
+	require.NoError(t, repo.CreateProduct(ctx, &entity.Product{ID: uuid.New(), ReceptionID: open.ID, DateTime: time.Now(), Type: entity.TypeShoes}))
+
+	stats, err := repo.GetStats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []entity.OpenReceptionsCount{{City: entity.CityMoscow, Count: 1}}, stats.OpenReceptions)
+	// Товары закрытых приемок тоже на руках
+	require.Equal(t, []entity.ProductsCount{{City: entity.CityMoscow, Type: entity.TypeShoes, Count: 2}}, stats.ProductsOnHand)
+}
@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"GoPVZ/internal/pvz/entity"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgMetrics"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// _unknownCity — метка города, если ПВЗ не удалось прочитать.
+const _unknownCity = "unknown"
+
+// cityOf возвращает город ПВЗ для меток метрик. Город ПВЗ не меняется, поэтому
+// он кэшируется и повторные запросы в БД не нужны.
+func (uc *PVZUseCase) cityOf(ctx context.Context, pvzId string) string {
+	city, err := uc.resolveCity(ctx, pvzId)
+	if err != nil {
+		uc.log.WarnContext(ctx, "Failed to resolve PVZ city for metrics", slog.String("pvz_id", pvzId), pkgLogger.Err(err))
+		return _unknownCity
+	}
+	return string(city)
+}
+
+func (uc *PVZUseCase) resolveCity(ctx context.Context, pvzId string) (entity.City, error) {
+	if city, ok := uc.cities.Load(pvzId); ok {
+		return city.(entity.City), nil
+	}
+	pvz, err := uc.repo.GetById(ctx, pvzId)
+	if err != nil {
+		return "", err
+	}
+	uc.rememberCity(pvz)
+	return pvz.City, nil
+}
+
+func (uc *PVZUseCase) rememberCity(pvz *entity.PVZ) {
+	uc.cities.Store(pvz.ID.String(), pvz.City)
+}
+
+// RefreshMetrics обновляет метрики-снимки: незакрытые приемки и принятые товары.
+// Серии, пропавшие из БД, удаляются.
+func (uc *PVZUseCase) RefreshMetrics(ctx context.Context) error {
+	stats, err := uc.repo.GetStats(ctx)
+	if err != nil {
+		return err
+	}
+
+	pkgMetrics.OpenReceptions.Reset()
+	for _, c := range stats.OpenReceptions {
+		pkgMetrics.OpenReceptions.WithLabelValues(string(c.City)).Set(float64(c.Count))
+	}
+	pkgMetrics.ProductsOnHand.Reset()
+	for _, c := range stats.ProductsOnHand {
+		pkgMetrics.ProductsOnHand.WithLabelValues(string(c.City), string(c.Type)).Set(float64(c.Count))
+	}
+	return nil
+}
+
+// RunMetricsRefresh периодически обновляет метрики-снимки до отмены ctx.
+func (uc *PVZUseCase) RunMetricsRefresh(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := uc.RefreshMetrics(ctx); err != nil && ctx.Err() == nil {
+			uc.log.WarnContext(ctx, "Failed to refresh PVZ metrics", pkgLogger.Err(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"GoPVZ/internal/pvz/entity"
+	"GoPVZ/pkg/pkgMetrics"
+	"GoPVZ/pkg/pkgValidator"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPVZUseCase_CityOf(t *testing.T) {
+	mockRepo := new(MockPVZRepo)
+	uc, _ := newTestUseCase(mockRepo)
+
+	pvzId := uuid.New()
+	mockRepo.On("GetById", mock.Anything, pvzId.String()).Return(&entity.PVZ{ID: pvzId, City: entity.CityKazan}, nil).Once()
+	missing := uuid.NewString()
+	mockRepo.On("GetById", mock.Anything, missing).Return((*entity.PVZ)(nil), pkgValidator.ErrPVZNotFound)
+
+	// Второй вызов берет город из кэша
+	assert.Equal(t, "Kazan", uc.cityOf(context.Background(), pvzId.String()))
+	assert.Equal(t, "Kazan", uc.cityOf(context.Background(), pvzId.String()))
+	assert.Equal(t, _unknownCity, uc.cityOf(context.Background(), missing))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPVZUseCase_RefreshMetrics(t *testing.T) {
+	mockRepo := new(MockPVZRepo)
+	uc, _ := newTestUseCase(mockRepo)
+
+	mockRepo.On("GetStats", mock.Anything).Return(&entity.Stats{
+		OpenReceptions: []entity.OpenReceptionsCount{{City: entity.CityMoscow, Count: 3}},
+		ProductsOnHand: []entity.ProductsCount{
+			{City: entity.CityMoscow, Type: entity.TypeShoes, Count: 7},
+			{City: entity.CityKazan, Type: entity.TypeClothes, Count: 2},
+		},
+	}, nil).Once()
+	require.NoError(t, uc.RefreshMetrics(context.Background()))
+
+	assert.Equal(t, 3.0, testutil.ToFloat64(pkgMetrics.OpenReceptions.WithLabelValues("Moscow")))
+	assert.Equal(t, 7.0, testutil.ToFloat64(pkgMetrics.ProductsOnHand.WithLabelValues("Moscow", "shoes")))
+	assert.Equal(t, 2, testutil.CollectAndCount(pkgMetrics.ProductsOnHand))
+
+	// Серии, которых больше нет в БД, пропадают
+	mockRepo.On("GetStats", mock.Anything).Return(&entity.Stats{}, nil).Once()
+	require.NoError(t, uc.RefreshMetrics(context.Background()))
+	assert.Equal(t, 0, testutil.CollectAndCount(pkgMetrics.OpenReceptions))
+	assert.Equal(t, 0, testutil.CollectAndCount(pkgMetrics.ProductsOnHand))
+
+	mockRepo.On("GetStats", mock.Anything).Return((*entity.Stats)(nil), errors.New("db error")).Once()
+	assert.Error(t, uc.RefreshMetrics(context.Background()))
+}
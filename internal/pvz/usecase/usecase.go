@@ -1,54 +1,261 @@
 package usecase
 
 import (
+	outboxEntity "GoPVZ/internal/outbox/entity"
+	outboxRepo "GoPVZ/internal/outbox/repo"
 	"GoPVZ/internal/pvz/entity"
 	"GoPVZ/internal/pvz/repo"
 	"GoPVZ/pkg/pkgValidator"
+	"GoPVZ/pkg/pkgLogger"
 	"GoPVZ/pkg/pkgMetrics"
+	"GoPVZ/pkg/pkgPostgres"
+	"GoPVZ/pkg/pkgPubSub"
+	"GoPVZ/pkg/pkgTracing"
 	"context"
+	"encoding/json"
 	"errors"
+	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
 )
 
+var _tracer = otel.Tracer("GoPVZ/internal/pvz/usecase")
+
 type PVZUseCase struct {
-	repo repo.PVZRepository
+	repo   repo.PVZRepository
+	outbox outboxRepo.OutboxRepository
+	tx     pkgPostgres.Transactor
+	live   pkgPubSub.Broker
+	log    pkgLogger.Interface
+
+	// cities — город ПВЗ по id, для меток метрик
+	cities sync.Map
+}
+
+func NewPVZUseCase(r repo.PVZRepository, outbox outboxRepo.OutboxRepository, tx pkgPostgres.Transactor, live pkgPubSub.Broker, log pkgLogger.Interface) *PVZUseCase {
+	return &PVZUseCase{repo: r, outbox: outbox, tx: tx, live: live, log: log}
+}
+
+// emit сохраняет доменное событие в outbox; вызывать внутри транзакции.
+func (uc *PVZUseCase) emit(ctx context.Context, eventType outboxEntity.EventType, pvzID uuid.UUID, payload any) (*outboxEntity.Event, error) {
+	event, err := outboxEntity.NewEvent(eventType, pvzID, payload)
+	if err != nil {
+		return nil, err
+	}
+	return event, uc.outbox.Add(ctx, event)
 }
 
-func NewPVZUseCase(r repo.PVZRepository) *PVZUseCase {
-	return &PVZUseCase{repo: r}
+// broadcast отправляет событие подписчикам живой ленты; вызывать после коммита.
+// Доставка best-effort: надежный канал — outbox.
+func (uc *PVZUseCase) broadcast(ctx context.Context, event *outboxEntity.Event) {
+	data, err := json.Marshal(event)
+	if err == nil {
+		err = uc.live.Publish(ctx, event.PvzID.String(), data)
+	}
+	if err != nil {
+		uc.log.WarnContext(ctx, "Failed to broadcast live event", slog.String("event_id", event.ID.String()), pkgLogger.Err(err))
+	}
 }
 
-func (uc *PVZUseCase) CreatePVZ(ctx context.Context, city string) (*entity.PVZ, error) {
+// SubscribeEvents возвращает живую ленту событий ПВЗ. Канал закрывается
+// при отмене контекста.
+func (uc *PVZUseCase) SubscribeEvents(ctx context.Context, scope entity.Scope, pvzId string) (_ <-chan *outboxEntity.Event, err error) {
+	ctx, span := _tracer.Start(ctx, "PVZUseCase.SubscribeEvents")
+	defer func() { pkgTracing.End(span, err) }()
+
+	if _, err := uc.GetPVZ(ctx, scope, pvzId); err != nil {
+		return nil, err
+	}
+
+	raw, unsubscribe := uc.live.Subscribe(pvzId)
+	events := make(chan *outboxEntity.Event)
+
+	go func() {
+		defer close(events)
+		defer unsubscribe()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case data, ok := <-raw:
+				if !ok {
+					return
+				}
+				var event outboxEntity.Event
+				if err := json.Unmarshal(data, &event); err != nil {
+					continue
+				}
+				select {
+				case events <- &event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+func (uc *PVZUseCase) CreatePVZ(ctx context.Context, scope entity.Scope, city string) (_ *entity.PVZ, err error) {
+	ctx, span := _tracer.Start(ctx, "PVZUseCase.CreatePVZ")
+	defer func() { pkgTracing.End(span, err) }()
+
+	if !scope.Allows(entity.City(city)) {
+		uc.log.WarnContext(ctx, "PVZ creation rejected: city is outside of user regions", slog.String("city", city))
+		return nil, pkgValidator.ErrOutOfScope
+	}
+
 	pvz := &entity.PVZ{
 		ID:               uuid.New(),
 		RegistrationDate: time.Now().UTC(),
 		City:             entity.City(city),
+		Version:          1,
 	}
 
-	if err := uc.repo.CreatePVZ(ctx, pvz); err != nil {
+	var event *outboxEntity.Event
+
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) (err error) {
+		if err = uc.repo.CreatePVZ(ctx, pvz); err != nil {
+			return err
+		}
+		event, err = uc.emit(ctx, outboxEntity.EventPVZCreated, pvz.ID, pvz)
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	uc.broadcast(ctx, event)
+	uc.log.InfoContext(ctx, "PVZ created", slog.String("pvz_id", pvz.ID.String()), slog.String("city", city))
+
 	// Метрика: количество созданных ПВЗ
-	pkgMetrics.PVZCreatedTotal.Inc()
+	uc.rememberCity(pvz)
+	pkgMetrics.PVZCreatedTotal.WithLabelValues(city).Inc()
 	return pvz, nil
 }
 
-func (uc *PVZUseCase) CreateReception(ctx context.Context, pvzId string) (*entity.Reception, error) {
-	pvzUUID, err := uuid.Parse(pvzId)
-	if err != nil {
+// errDryRun откатывает транзакцию импорта в режиме dry-run.
+var errDryRun = errors.New("dry run")
+
+// ImportPVZs вставляет валидные строки одной транзакцией. Строки с уже известным
+// external_id пропускаются, поэтому повторный импорт того же файла ничего не меняет.
+// В режиме dryRun все выполняется так же, но транзакция откатывается.
+// Строки с городом вне scope помечаются невалидными.
+func (uc *PVZUseCase) ImportPVZs(ctx context.Context, scope entity.Scope, rows []*entity.ImportRow, dryRun bool) (_ *entity.ImportReport, err error) {
+	ctx, span := _tracer.Start(ctx, "PVZUseCase.ImportPVZs")
+	defer func() { pkgTracing.End(span, err) }()
+
+	now := time.Now().UTC()
+
+	for _, row := range rows {
+		if row.Status != entity.ImportRowInvalid && !scope.Allows(row.City) {
+			row.Status = entity.ImportRowInvalid
+			row.Error = pkgValidator.ErrOutOfScope.Error()
+		}
+	}
+
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		for _, row := range rows {
+			if row.Status == entity.ImportRowInvalid {
+				continue
+			}
+
+			pvz := &entity.PVZ{
+				ID:               uuid.New(),
+				RegistrationDate: now,
+				City:             row.City,
+				Address:          row.Address,
+				ExternalID:       row.ExternalID,
+				Version:          1,
+			}
+			created, err := uc.repo.CreatePVZIfNotExists(ctx, pvz)
+			if err != nil {
+				return err
+			}
+			if !created {
+				row.Status = entity.ImportRowExists
+				continue
+			}
+
+			if _, err := uc.emit(ctx, outboxEntity.EventPVZCreated, pvz.ID, pvz); err != nil {
+				return err
+			}
+			row.Status = entity.ImportRowCreated
+			if !dryRun {
+				row.PvzID = &pvz.ID
+			}
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
 		return nil, err
 	}
 
-	isInProgress, err := uc.repo.CheckPvzsLastReceptionStatusInProgress(ctx, pvzId)
+	report := &entity.ImportReport{DryRun: dryRun, Rows: rows}
+	for _, row := range rows {
+		switch row.Status {
+		case entity.ImportRowCreated:
+			report.Created++
+		case entity.ImportRowExists:
+			report.Existing++
+		case entity.ImportRowInvalid:
+			report.Invalid++
+		}
+	}
+
+	uc.log.InfoContext(ctx, "PVZ import finished",
+		slog.Bool("dry_run", dryRun),
+		slog.Int("created", report.Created),
+		slog.Int("existing", report.Existing),
+		slog.Int("invalid", report.Invalid),
+	)
+	if !dryRun {
+		// Метрика: количество созданных ПВЗ
+		for _, row := range rows {
+			if row.Status == entity.ImportRowCreated {
+				pkgMetrics.PVZCreatedTotal.WithLabelValues(string(row.City)).Inc()
+			}
+		}
+	}
+	return report, nil
+}
+
+// checkScope проверяет, что ПВЗ входит в scope. Как и в GetPVZ, ПВЗ вне scope
+// для пользователя не существует.
+func (uc *PVZUseCase) checkScope(ctx context.Context, scope entity.Scope, pvzId string) error {
+	if !scope.Restricted() {
+		return nil
+	}
+	city, err := uc.resolveCity(ctx, pvzId)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if !scope.Allows(city) {
+		uc.log.WarnContext(ctx, "PVZ access rejected: city is outside of user regions", slog.String("pvz_id", pvzId), slog.String("city", string(city)))
+		return pkgValidator.ErrPVZNotFound
 	}
+	return nil
+}
 
-	if isInProgress {
-		return nil, pkgValidator.ErrInvalidReceptionCreation
+func (uc *PVZUseCase) CreateReception(ctx context.Context, scope entity.Scope, pvzId string) (_ *entity.Reception, err error) {
+	ctx, span := _tracer.Start(ctx, "PVZUseCase.CreateReception")
+	defer func() { pkgTracing.End(span, err) }()
+
+	pvzUUID, err := uuid.Parse(pvzId)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.checkScope(ctx, scope, pvzId); err != nil {
+		return nil, err
 	}
 
 	reception := &entity.Reception{
@@ -56,69 +263,212 @@ func (uc *PVZUseCase) CreateReception(ctx context.Context, pvzId string) (*entit
 		PvzID:    pvzUUID,
 		DateTime: time.Now().UTC(),
 		Status:   entity.StatusInProgress,
+		Version:  1,
 	}
 
-	if err := uc.repo.CreateReception(ctx, reception); err != nil {
+	var event *outboxEntity.Event
+
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		isInProgress, err := uc.repo.CheckPvzsLastReceptionStatusInProgress(ctx, pvzId)
+		if err != nil {
+			return err
+		}
+
+		if isInProgress {
+			return pkgValidator.ErrInvalidReceptionCreation
+		}
+
+		if err := uc.repo.CreateReception(ctx, reception); err != nil {
+			return err
+		}
+		event, err = uc.emit(ctx, outboxEntity.EventReceptionOpened, reception.PvzID, reception)
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	uc.broadcast(ctx, event)
+	uc.log.InfoContext(ctx, "Reception opened", slog.String("reception_id", reception.ID.String()), slog.String("pvz_id", pvzId))
+
 	// Метрика: количество созданных приемок
-	pkgMetrics.ReceptionsCreatedTotal.Inc()
+	pkgMetrics.ReceptionsCreatedTotal.WithLabelValues(uc.cityOf(ctx, pvzId)).Inc()
 	return reception, nil
 }
 
-func (uc *PVZUseCase) CreateProduct(ctx context.Context, productType, pvzId string) (*entity.Product, error) {
-	receptionId, err := uc.repo.GetInProgressReceptionIdByPVZId(ctx, pvzId)
+func (uc *PVZUseCase) CreateProduct(ctx context.Context, scope entity.Scope, productType, pvzId string) (_ *entity.Product, err error) {
+	ctx, span := _tracer.Start(ctx, "PVZUseCase.CreateProduct")
+	defer func() { pkgTracing.End(span, err) }()
+
+	pvzUUID, err := uuid.Parse(pvzId)
 	if err != nil {
 		return nil, err
 	}
-
-	receptionUUID, err := uuid.Parse(receptionId)
-	if err != nil {
+	if err := uc.checkScope(ctx, scope, pvzId); err != nil {
 		return nil, err
 	}
 
-	product := &entity.Product{
-		ID:          uuid.New(),
-		ReceptionID: receptionUUID,
-		DateTime:    time.Now().UTC(),
-		Type:        entity.Type(productType),
-	}
+	var (
+		product *entity.Product
+		event   *outboxEntity.Event
+	)
+
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		receptionId, err := uc.repo.GetInProgressReceptionIdByPVZId(ctx, pvzId)
+		if err != nil {
+			return err
+		}
+
+		receptionUUID, err := uuid.Parse(receptionId)
+		if err != nil {
+			return err
+		}
+
+		product = &entity.Product{
+			ID:          uuid.New(),
+			ReceptionID: receptionUUID,
+			DateTime:    time.Now().UTC(),
+			Type:        entity.Type(productType),
+		}
 
-	if err := uc.repo.CreateProduct(ctx, product); err != nil {
+		if err := uc.repo.CreateProduct(ctx, product); err != nil {
+			return err
+		}
+		event, err = uc.emit(ctx, outboxEntity.EventProductAdded, pvzUUID, product)
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	uc.broadcast(ctx, event)
+	uc.log.DebugContext(ctx, "Product added", slog.String("product_id", product.ID.String()), slog.String("pvz_id", pvzId))
+
 	// Метрика: количество добавленных товаров
-	pkgMetrics.ProductsAddedTotal.Inc()
+	pkgMetrics.ProductsAddedTotal.WithLabelValues(uc.cityOf(ctx, pvzId), productType).Inc()
 	return product, nil
 }
 
-func (uc *PVZUseCase) DeleteLastProduct(ctx context.Context, pvzId string) error {
-	isInProgress, err := uc.repo.CheckPvzsLastReceptionStatusInProgress(ctx, pvzId)
+func (uc *PVZUseCase) DeleteLastProduct(ctx context.Context, scope entity.Scope, pvzId string) (err error) {
+	ctx, span := _tracer.Start(ctx, "PVZUseCase.DeleteLastProduct")
+	defer func() { pkgTracing.End(span, err) }()
+
+	pvzUUID, err := uuid.Parse(pvzId)
+	if err != nil {
+		return err
+	}
+	if err := uc.checkScope(ctx, scope, pvzId); err != nil {
+		return err
+	}
+
+	var event *outboxEntity.Event
+
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		isInProgress, err := uc.repo.CheckPvzsLastReceptionStatusInProgress(ctx, pvzId)
+		if err != nil {
+			return err
+		}
+		if !isInProgress {
+			return pkgValidator.ErrNoActiveReception
+		}
+
+		product, err := uc.repo.DeleteLastProductFromReception(ctx, pvzId)
+		if err != nil {
+			return err
+		}
+		event, err = uc.emit(ctx, outboxEntity.EventProductRemoved, pvzUUID, product)
+		return err
+	})
 	if err != nil {
 		return err
 	}
-	if !isInProgress {
-		return pkgValidator.ErrNoActiveReception
+
+	uc.broadcast(ctx, event)
+	uc.log.InfoContext(ctx, "Last product removed", slog.String("pvz_id", pvzId))
+	return nil
+}
+
+// CloseReception закрывает активную приемку. Непустой expected — версия приемки из
+// If-Match: если приемку успели изменить, возвращается ErrVersionMismatch.
+func (uc *PVZUseCase) CloseReception(ctx context.Context, scope entity.Scope, pvzId string, expected *entity.VersionTag) (_ *entity.Reception, err error) {
+	ctx, span := _tracer.Start(ctx, "PVZUseCase.CloseReception")
+	defer func() { pkgTracing.End(span, err) }()
+
+	if err := uc.checkScope(ctx, scope, pvzId); err != nil {
+		return nil, err
+	}
+
+	var (
+		reception *entity.Reception
+		event     *outboxEntity.Event
+	)
+
+	err = uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		isInProgress, err := uc.repo.CheckPvzsLastReceptionStatusInProgress(ctx, pvzId)
+		if err != nil {
+			return err
+		}
+		if !isInProgress {
+			return pkgValidator.ErrNoActiveReception
+		}
+
+		reception, err = uc.repo.CloseReception(ctx, pvzId, expected)
+		if err != nil {
+			return err
+		}
+		event, err = uc.emit(ctx, outboxEntity.EventReceptionClosed, reception.PvzID, reception)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return uc.repo.DeleteLastProductFromReception(ctx, pvzId)
+	uc.broadcast(ctx, event)
+	uc.log.InfoContext(ctx, "Reception closed", slog.String("reception_id", reception.ID.String()), slog.String("pvz_id", pvzId))
+
+	// Метрика: количество закрытых приемок
+	pkgMetrics.ReceptionsClosedTotal.WithLabelValues(uc.cityOf(ctx, pvzId)).Inc()
+	return reception, nil
 }
 
-func (uc *PVZUseCase) CloseReception(ctx context.Context, pvzId string) (*entity.Reception, error) {
-	isInProgress, err := uc.repo.CheckPvzsLastReceptionStatusInProgress(ctx, pvzId)
+// GetPVZ не раскрывает ПВЗ вне scope: для пользователя его нет.
+func (uc *PVZUseCase) GetPVZ(ctx context.Context, scope entity.Scope, pvzId string) (_ *entity.PVZ, err error) {
+	ctx, span := _tracer.Start(ctx, "PVZUseCase.GetPVZ")
+	defer func() { pkgTracing.End(span, err) }()
+
+	pvz, err := uc.repo.GetById(ctx, pvzId)
 	if err != nil {
 		return nil, err
 	}
-	if !isInProgress {
-		return nil, pkgValidator.ErrNoActiveReception
+	uc.rememberCity(pvz)
+	if !scope.Allows(pvz.City) {
+		return nil, pkgValidator.ErrPVZNotFound
 	}
+	return pvz, nil
+}
 
-	return uc.repo.CloseReception(ctx, pvzId)
+// GetReception не раскрывает приемки ПВЗ вне scope.
+func (uc *PVZUseCase) GetReception(ctx context.Context, scope entity.Scope, receptionId string) (_ *entity.Reception, err error) {
+	ctx, span := _tracer.Start(ctx, "PVZUseCase.GetReception")
+	defer func() { pkgTracing.End(span, err) }()
+
+	reception, err := uc.repo.GetReceptionById(ctx, receptionId)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.checkScope(ctx, scope, reception.PvzID.String()); err != nil {
+		if errors.Is(err, pkgValidator.ErrPVZNotFound) {
+			return nil, pkgValidator.ErrReceptionNotFound
+		}
+		return nil, err
+	}
+	return reception, nil
 }
 
-func (uc *PVZUseCase) GetPVZsWithReceptions(ctx context.Context, startDate, endDate *time.Time, page, limit int) ([]*entity.PVZWithReceptions, error) {
+func (uc *PVZUseCase) GetPVZsWithReceptions(ctx context.Context, scope entity.Scope, startDate, endDate *time.Time, page, limit int) (_ []*entity.PVZWithReceptions, err error) {
+	ctx, span := _tracer.Start(ctx, "PVZUseCase.GetPVZsWithReceptions")
+	defer func() { pkgTracing.End(span, err) }()
+
 	if page < 1 {
 		page = 1
 	}
@@ -131,5 +481,5 @@ func (uc *PVZUseCase) GetPVZsWithReceptions(ctx context.Context, startDate, endD
 		return nil, errors.New("startDate cannot be after endDate")
 	}
 
-	return uc.repo.GetPVZsWithReceptions(ctx, startDate, endDate, limit, (page-1)*limit)
-}
\ No newline at end of file
+	return uc.repo.GetPVZsWithReceptions(ctx, scope.CityNames(), startDate, endDate, limit, (page-1)*limit)
+}
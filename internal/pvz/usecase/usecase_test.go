@@ -1,7 +1,10 @@
 package usecase
 
 import (
+	outboxEntity "GoPVZ/internal/outbox/entity"
 	"GoPVZ/internal/pvz/entity"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgPubSub"
 	"GoPVZ/pkg/pkgValidator"
 	"context"
 	"errors"
@@ -22,6 +25,11 @@ func (m *MockPVZRepo) CreatePVZ(ctx context.Context, pvz *entity.PVZ) error {
     return args.Error(0)
 }
 
+func (m *MockPVZRepo) CreatePVZIfNotExists(ctx context.Context, pvz *entity.PVZ) (bool, error) {
+    args := m.Called(ctx, pvz)
+    return args.Bool(0), args.Error(1)
+}
+
 func (m *MockPVZRepo) GetById(ctx context.Context, id string) (*entity.PVZ, error) {
     args := m.Called(ctx, id)
     return args.Get(0).(*entity.PVZ), args.Error(1)
@@ -47,56 +55,115 @@ func (m *MockPVZRepo) GetInProgressReceptionIdByPVZId(ctx context.Context, pvzId
     return args.String(0), args.Error(1)
 }
 
-func (m *MockPVZRepo) DeleteLastProductFromReception(ctx context.Context, pvzId string) error {
+func (m *MockPVZRepo) DeleteLastProductFromReception(ctx context.Context, pvzId string) (*entity.Product, error) {
     args := m.Called(ctx, pvzId)
-    return args.Error(0)
+    return args.Get(0).(*entity.Product), args.Error(1)
 }
 
-func (m *MockPVZRepo) CloseReception(ctx context.Context, pvzId string) (*entity.Reception, error) {
-    args := m.Called(ctx, pvzId)
+func (m *MockPVZRepo) CloseReception(ctx context.Context, pvzId string, expected *entity.VersionTag) (*entity.Reception, error) {
+    args := m.Called(ctx, pvzId, expected)
+    return args.Get(0).(*entity.Reception), args.Error(1)
+}
+
+func (m *MockPVZRepo) GetReceptionById(ctx context.Context, id string) (*entity.Reception, error) {
+    args := m.Called(ctx, id)
     return args.Get(0).(*entity.Reception), args.Error(1)
 }
 
-func (m *MockPVZRepo) GetPVZsWithReceptions(ctx context.Context, startDate, endDate *time.Time, limit, offset int) ([]*entity.PVZWithReceptions, error) {
-    args := m.Called(ctx, startDate, endDate, limit, offset)
+func (m *MockPVZRepo) GetPVZsWithReceptions(ctx context.Context, cities []string, startDate, endDate *time.Time, limit, offset int) ([]*entity.PVZWithReceptions, error) {
+    args := m.Called(ctx, cities, startDate, endDate, limit, offset)
     return args.Get(0).([]*entity.PVZWithReceptions), args.Error(1)
 }
 
+func (m *MockPVZRepo) GetStats(ctx context.Context) (*entity.Stats, error) {
+    args := m.Called(ctx)
+    return args.Get(0).(*entity.Stats), args.Error(1)
+}
+
+
+type MockOutboxRepo struct {
+    mock.Mock
+}
+
+func (m *MockOutboxRepo) Add(ctx context.Context, event *outboxEntity.Event) error {
+    args := m.Called(ctx, event)
+    return args.Error(0)
+}
+
+func (m *MockOutboxRepo) FetchUnpublished(ctx context.Context, limit int) ([]*outboxEntity.Event, error) {
+    args := m.Called(ctx, limit)
+    return args.Get(0).([]*outboxEntity.Event), args.Error(1)
+}
+
+func (m *MockOutboxRepo) MarkPublished(ctx context.Context, id string) error {
+    args := m.Called(ctx, id)
+    return args.Error(0)
+}
+
+func (m *MockOutboxRepo) MarkFailed(ctx context.Context, id string, reason string) error {
+    args := m.Called(ctx, id, reason)
+    return args.Error(0)
+}
+
+// fakeTransactor выполняет функцию без реальной транзакции
+type fakeTransactor struct{}
+
+func (fakeTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+    return fn(ctx)
+}
+
+// newTestUseCase создает usecase с outbox, принимающим любые события
+func newTestUseCase(mockRepo *MockPVZRepo) (*PVZUseCase, *MockOutboxRepo) {
+    mockOutbox := new(MockOutboxRepo)
+    mockOutbox.On("Add", mock.Anything, mock.Anything).Return(nil).Maybe()
+    return NewPVZUseCase(mockRepo, mockOutbox, fakeTransactor{}, pkgPubSub.NewMemory(0), pkgLogger.Discard()), mockOutbox
+}
 
 func TestPVZUseCase_CreatePVZ(t *testing.T) {
 	tests := []struct {
 		name      string
+		scope     entity.Scope
 		city      string
 		repoError error
-		wantError bool
+		wantError error
 	}{
 		{
 			name:      "success",
 			city:      "Kazan",
 			repoError: nil,
-			wantError: false,
+		},
+		{
+			name:  "city inside region",
+			scope: entity.NewScope([]string{"Kazan"}),
+			city:  "Kazan",
+		},
+		{
+			name:      "city outside region",
+			scope:     entity.NewScope([]string{"Kazan"}),
+			city:      "Moscow",
+			wantError: pkgValidator.ErrOutOfScope,
 		},
 		{
 			name:      "repository error",
 			city:      "Moscow",
 			repoError: errors.New("db error"),
-			wantError: true,
+			wantError: errors.New("db error"),
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := new(MockPVZRepo)
-			uc := NewPVZUseCase(mockRepo)
+			uc, _ := newTestUseCase(mockRepo)
 
 			mockRepo.On("CreatePVZ", mock.Anything, mock.MatchedBy(func(pvz *entity.PVZ) bool {
 				return pvz.City == entity.City(tt.city)
-			})).Return(tt.repoError)
+			})).Return(tt.repoError).Maybe()
 
-			result, err := uc.CreatePVZ(context.Background(), tt.city)
+			result, err := uc.CreatePVZ(context.Background(), tt.scope, tt.city)
 
-			if tt.wantError {
-				assert.Error(t, err)
+			if tt.wantError != nil {
+				assert.EqualError(t, err, tt.wantError.Error())
 				assert.Nil(t, result)
 			} else {
 				assert.NoError(t, err)
@@ -147,8 +214,9 @@ func TestPVZUseCase_CreateReception(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := new(MockPVZRepo)
-			uc := NewPVZUseCase(mockRepo)
+			uc, _ := newTestUseCase(mockRepo)
 
+			mockRepo.On("GetById", mock.Anything, tt.pvzId).Return(&entity.PVZ{City: entity.CityKazan}, nil).Maybe()
 			mockRepo.On("CheckPvzsLastReceptionStatusInProgress", mock.Anything, tt.pvzId).
 				Return(tt.isInProgress, tt.repoError)
 
@@ -158,7 +226,7 @@ func TestPVZUseCase_CreateReception(t *testing.T) {
 				})).Return(tt.receptionError)
 			}
 
-			result, err := uc.CreateReception(context.Background(), tt.pvzId)
+			result, err := uc.CreateReception(context.Background(), entity.Scope{}, tt.pvzId)
 
 			if tt.wantError {
 				assert.Error(t, err)
@@ -207,8 +275,9 @@ func TestPVZUseCase_CreateProduct(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := new(MockPVZRepo)
-			uc := NewPVZUseCase(mockRepo)
+			uc, _ := newTestUseCase(mockRepo)
 
+			mockRepo.On("GetById", mock.Anything, tt.pvzId).Return(&entity.PVZ{City: entity.CityKazan}, nil).Maybe()
 			mockRepo.On("GetInProgressReceptionIdByPVZId", mock.Anything, tt.pvzId).
 				Return(tt.receptionId, tt.repoError)
 
@@ -218,7 +287,7 @@ func TestPVZUseCase_CreateProduct(t *testing.T) {
 				})).Return(nil)
 			}
 
-			result, err := uc.CreateProduct(context.Background(), tt.productType, tt.pvzId)
+			result, err := uc.CreateProduct(context.Background(), entity.Scope{}, tt.productType, tt.pvzId)
 
 			if tt.wantError {
 				assert.Error(t, err)
@@ -271,17 +340,17 @@ func TestPVZUseCase_DeleteLastProduct(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := new(MockPVZRepo)
-			uc := NewPVZUseCase(mockRepo)
+			uc, _ := newTestUseCase(mockRepo)
 
 			mockRepo.On("CheckPvzsLastReceptionStatusInProgress", mock.Anything, tt.pvzId).
 				Return(tt.isInProgress, tt.repoError)
 
 			if tt.isInProgress && tt.repoError == nil {
 				mockRepo.On("DeleteLastProductFromReception", mock.Anything, tt.pvzId).
-					Return(tt.deleteError)
+					Return(&entity.Product{ID: uuid.New()}, tt.deleteError)
 			}
 
-			err := uc.DeleteLastProduct(context.Background(), tt.pvzId)
+			err := uc.DeleteLastProduct(context.Background(), entity.Scope{}, tt.pvzId)
 
 			if tt.wantError {
 				assert.Error(t, err)
@@ -336,22 +405,31 @@ func TestPVZUseCase_CloseReception(t *testing.T) {
 			closeError:    nil,
 			wantError:      true,
 		},
+		{
+			name:           "version mismatch",
+			pvzId:          uuid.New().String(),
+			isInProgress:   true,
+			repoError:      nil,
+			closeError:     pkgValidator.ErrVersionMismatch,
+			wantError:      true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := new(MockPVZRepo)
-			uc := NewPVZUseCase(mockRepo)
+			uc, _ := newTestUseCase(mockRepo)
 
+			mockRepo.On("GetById", mock.Anything, tt.pvzId).Return(&entity.PVZ{City: entity.CityKazan}, nil).Maybe()
 			mockRepo.On("CheckPvzsLastReceptionStatusInProgress", mock.Anything, tt.pvzId).
 				Return(tt.isInProgress, tt.repoError)
 
 			if tt.isInProgress && tt.repoError == nil {
-				mockRepo.On("CloseReception", mock.Anything, tt.pvzId).
+				mockRepo.On("CloseReception", mock.Anything, tt.pvzId, (*entity.VersionTag)(nil)).
 					Return(testReception, tt.closeError)
 			}
 
-			result, err := uc.CloseReception(context.Background(), tt.pvzId)
+			result, err := uc.CloseReception(context.Background(), entity.Scope{}, tt.pvzId, nil)
 
 			if tt.wantError {
 				assert.Error(t, err)
@@ -447,7 +525,7 @@ func TestPVZUseCase_GetPVZsWithReceptions(t *testing.T) {
     for _, tt := range tests {
         t.Run(tt.name, func(t *testing.T) {
             mockRepo := new(MockPVZRepo)
-            uc := NewPVZUseCase(mockRepo)
+            uc, _ := newTestUseCase(mockRepo)
 
             expectedLimit := tt.limit
             if expectedLimit < 1 || expectedLimit > 30 {
@@ -458,10 +536,10 @@ func TestPVZUseCase_GetPVZsWithReceptions(t *testing.T) {
                 expectedOffset = 0
             }
 
-            mockRepo.On("GetPVZsWithReceptions", mock.Anything, tt.startDate, tt.endDate, expectedLimit, expectedOffset).
+            mockRepo.On("GetPVZsWithReceptions", mock.Anything, []string(nil), tt.startDate, tt.endDate, expectedLimit, expectedOffset).
                 Return(tt.repoResult, tt.repoError)
 
-            result, err := uc.GetPVZsWithReceptions(context.Background(), tt.startDate, tt.endDate, tt.page, tt.limit)
+            result, err := uc.GetPVZsWithReceptions(context.Background(), entity.Scope{}, tt.startDate, tt.endDate, tt.page, tt.limit)
 
             if tt.wantError {
                 assert.Error(t, err)
@@ -481,4 +559,304 @@ func TestPVZUseCase_GetPVZsWithReceptions(t *testing.T) {
             mockRepo.AssertExpectations(t)
         })
     }
-}
\ No newline at end of file
+}
+func TestPVZUseCase_OutboxEvents(t *testing.T) {
+	pvzId := uuid.New()
+	reception := &entity.Reception{ID: uuid.New(), PvzID: pvzId, DateTime: time.Now().UTC(), Status: entity.StatusClose}
+
+	tests := []struct {
+		name      string
+		outboxErr error
+		wantError bool
+	}{
+		{
+			name:      "reception closed event is stored",
+			outboxErr: nil,
+			wantError: false,
+		},
+		{
+			name:      "outbox error fails the operation",
+			outboxErr: errors.New("outbox unavailable"),
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockPVZRepo)
+			mockOutbox := new(MockOutboxRepo)
+			uc := NewPVZUseCase(mockRepo, mockOutbox, fakeTransactor{}, pkgPubSub.NewMemory(0), pkgLogger.Discard())
+
+			mockRepo.On("GetById", mock.Anything, pvzId.String()).Return(&entity.PVZ{ID: pvzId}, nil).Maybe()
+			mockRepo.On("CheckPvzsLastReceptionStatusInProgress", mock.Anything, pvzId.String()).Return(true, nil)
+			mockRepo.On("CloseReception", mock.Anything, pvzId.String(), (*entity.VersionTag)(nil)).Return(reception, nil)
+			mockOutbox.On("Add", mock.Anything, mock.MatchedBy(func(e *outboxEntity.Event) bool {
+				return e.Type == outboxEntity.EventReceptionClosed && e.PvzID == pvzId
+			})).Return(tt.outboxErr)
+
+			result, err := uc.CloseReception(context.Background(), entity.Scope{}, pvzId.String(), nil)
+
+			if tt.wantError {
+				assert.ErrorIs(t, err, tt.outboxErr)
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, reception.ID, result.ID)
+			}
+			mockRepo.AssertExpectations(t)
+			mockOutbox.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPVZUseCase_SubscribeEvents(t *testing.T) {
+	pvzId := uuid.New()
+	reception := &entity.Reception{ID: uuid.New(), PvzID: pvzId, DateTime: time.Now().UTC(), Status: entity.StatusClose}
+
+	t.Run("unknown pvz", func(t *testing.T) {
+		mockRepo := new(MockPVZRepo)
+		uc, _ := newTestUseCase(mockRepo)
+
+		mockRepo.On("GetById", mock.Anything, pvzId.String()).Return((*entity.PVZ)(nil), pkgValidator.ErrPVZNotFound)
+
+		events, err := uc.SubscribeEvents(context.Background(), entity.Scope{}, pvzId.String())
+
+		assert.ErrorIs(t, err, pkgValidator.ErrPVZNotFound)
+		assert.Nil(t, events)
+	})
+
+	t.Run("pvz outside region", func(t *testing.T) {
+		mockRepo := new(MockPVZRepo)
+		uc, _ := newTestUseCase(mockRepo)
+
+		mockRepo.On("GetById", mock.Anything, pvzId.String()).Return(&entity.PVZ{ID: pvzId, City: entity.CityMoscow}, nil)
+
+		events, err := uc.SubscribeEvents(context.Background(), entity.NewScope([]string{"Kazan"}), pvzId.String())
+
+		assert.ErrorIs(t, err, pkgValidator.ErrPVZNotFound)
+		assert.Nil(t, events)
+	})
+
+	t.Run("committed change is streamed", func(t *testing.T) {
+		mockRepo := new(MockPVZRepo)
+		uc, _ := newTestUseCase(mockRepo)
+
+		mockRepo.On("GetById", mock.Anything, pvzId.String()).Return(&entity.PVZ{ID: pvzId}, nil)
+		mockRepo.On("CheckPvzsLastReceptionStatusInProgress", mock.Anything, pvzId.String()).Return(true, nil)
+		mockRepo.On("CloseReception", mock.Anything, pvzId.String(), (*entity.VersionTag)(nil)).Return(reception, nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		events, err := uc.SubscribeEvents(ctx, entity.Scope{}, pvzId.String())
+		assert.NoError(t, err)
+
+		_, err = uc.CloseReception(context.Background(), entity.Scope{}, pvzId.String(), nil)
+		assert.NoError(t, err)
+
+		select {
+		case event := <-events:
+			assert.Equal(t, outboxEntity.EventReceptionClosed, event.Type)
+			assert.Equal(t, pvzId, event.PvzID)
+		case <-time.After(time.Second):
+			t.Fatal("event was not streamed")
+		}
+
+		cancel()
+		_, ok := <-events
+		assert.False(t, ok)
+	})
+
+	t.Run("failed change is not streamed", func(t *testing.T) {
+		mockRepo := new(MockPVZRepo)
+		mockOutbox := new(MockOutboxRepo)
+		uc := NewPVZUseCase(mockRepo, mockOutbox, fakeTransactor{}, pkgPubSub.NewMemory(0), pkgLogger.Discard())
+
+		mockRepo.On("GetById", mock.Anything, pvzId.String()).Return(&entity.PVZ{ID: pvzId}, nil)
+		mockRepo.On("CheckPvzsLastReceptionStatusInProgress", mock.Anything, pvzId.String()).Return(true, nil)
+		mockRepo.On("CloseReception", mock.Anything, pvzId.String(), (*entity.VersionTag)(nil)).Return(reception, nil)
+		mockOutbox.On("Add", mock.Anything, mock.Anything).Return(errors.New("outbox unavailable"))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events, err := uc.SubscribeEvents(ctx, entity.Scope{}, pvzId.String())
+		assert.NoError(t, err)
+
+		_, err = uc.CloseReception(context.Background(), entity.Scope{}, pvzId.String(), nil)
+		assert.Error(t, err)
+
+		select {
+		case event := <-events:
+			t.Fatalf("unexpected event %s", event.Type)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
+
+// Операции с ПВЗ вне регионов пользователя отклоняются до изменения данных,
+// как будто ПВЗ не существует
+func TestPVZUseCase_OutsideRegion(t *testing.T) {
+	pvzId := uuid.New()
+	reception := &entity.Reception{ID: uuid.New(), PvzID: pvzId, Status: entity.StatusInProgress}
+	scope := entity.NewScope([]string{"Kazan"})
+
+	tests := []struct {
+		name    string
+		call    func(uc *PVZUseCase) error
+		wantErr error
+	}{
+		{
+			name: "create reception",
+			call: func(uc *PVZUseCase) error {
+				_, err := uc.CreateReception(context.Background(), scope, pvzId.String())
+				return err
+			},
+			wantErr: pkgValidator.ErrPVZNotFound,
+		},
+		{
+			name: "create product",
+			call: func(uc *PVZUseCase) error {
+				_, err := uc.CreateProduct(context.Background(), scope, string(entity.TypeElectronics), pvzId.String())
+				return err
+			},
+			wantErr: pkgValidator.ErrPVZNotFound,
+		},
+		{
+			name: "delete last product",
+			call: func(uc *PVZUseCase) error {
+				return uc.DeleteLastProduct(context.Background(), scope, pvzId.String())
+			},
+			wantErr: pkgValidator.ErrPVZNotFound,
+		},
+		{
+			name: "close reception",
+			call: func(uc *PVZUseCase) error {
+				_, err := uc.CloseReception(context.Background(), scope, pvzId.String(), nil)
+				return err
+			},
+			wantErr: pkgValidator.ErrPVZNotFound,
+		},
+		{
+			name: "get reception",
+			call: func(uc *PVZUseCase) error {
+				_, err := uc.GetReception(context.Background(), scope, reception.ID.String())
+				return err
+			},
+			wantErr: pkgValidator.ErrReceptionNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockPVZRepo)
+			uc, mockOutbox := newTestUseCase(mockRepo)
+
+			mockRepo.On("GetById", mock.Anything, pvzId.String()).Return(&entity.PVZ{ID: pvzId, City: entity.CityMoscow}, nil)
+			mockRepo.On("GetReceptionById", mock.Anything, reception.ID.String()).Return(reception, nil).Maybe()
+
+			err := tt.call(uc)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			mockRepo.AssertExpectations(t)
+			mockRepo.AssertNotCalled(t, "CheckPvzsLastReceptionStatusInProgress", mock.Anything, mock.Anything)
+			mockOutbox.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("pvz in region", func(t *testing.T) {
+		mockRepo := new(MockPVZRepo)
+		uc, _ := newTestUseCase(mockRepo)
+
+		mockRepo.On("GetById", mock.Anything, pvzId.String()).Return(&entity.PVZ{ID: pvzId, City: entity.CityKazan}, nil).Once()
+		mockRepo.On("GetReceptionById", mock.Anything, reception.ID.String()).Return(reception, nil)
+
+		// Город берется из кэша, повторного запроса ПВЗ нет
+		for range 2 {
+			result, err := uc.GetReception(context.Background(), scope, reception.ID.String())
+			assert.NoError(t, err)
+			assert.Equal(t, reception.ID, result.ID)
+		}
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestPVZUseCase_ImportPVZs(t *testing.T) {
+	newRows := func() []*entity.ImportRow {
+		return []*entity.ImportRow{
+			{Line: 2, City: entity.CityMoscow, Address: "ул. Ленина, 1", ExternalID: "MSK-1"},
+			{Line: 3, City: entity.CityKazan, Address: "ул. Баумана, 2", ExternalID: "KZN-1"},
+			{Line: 4, City: "Berlin", Address: "Unter den Linden 1", ExternalID: "BER-1", Status: entity.ImportRowInvalid, Error: pkgValidator.ErrInvalidCity.Error()},
+		}
+	}
+	isExternalID := func(id string) interface{} {
+		return mock.MatchedBy(func(p *entity.PVZ) bool { return p.ExternalID == id })
+	}
+
+	tests := []struct {
+		name         string
+		dryRun       bool
+		repoErr      error
+		wantCreated  int
+		wantExisting int
+		wantError    bool
+	}{
+		{name: "valid rows are inserted, existing skipped", wantCreated: 1, wantExisting: 1},
+		{name: "dry run reports the same result", dryRun: true, wantCreated: 1, wantExisting: 1},
+		{name: "repository error aborts import", repoErr: errors.New("db error"), wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockPVZRepo)
+			mockOutbox := new(MockOutboxRepo)
+			uc := NewPVZUseCase(mockRepo, mockOutbox, fakeTransactor{}, pkgPubSub.NewMemory(0), pkgLogger.Discard())
+
+			mockRepo.On("CreatePVZIfNotExists", mock.Anything, isExternalID("MSK-1")).Return(true, tt.repoErr)
+			mockRepo.On("CreatePVZIfNotExists", mock.Anything, isExternalID("KZN-1")).Return(false, nil).Maybe()
+			mockOutbox.On("Add", mock.Anything, mock.MatchedBy(func(e *outboxEntity.Event) bool {
+				return e.Type == outboxEntity.EventPVZCreated
+			})).Return(nil).Maybe()
+
+			rows := newRows()
+			report, err := uc.ImportPVZs(context.Background(), entity.Scope{}, rows, tt.dryRun)
+
+			if tt.wantError {
+				assert.ErrorIs(t, err, tt.repoErr)
+				assert.Nil(t, report)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.dryRun, report.DryRun)
+			assert.Equal(t, tt.wantCreated, report.Created)
+			assert.Equal(t, tt.wantExisting, report.Existing)
+			assert.Equal(t, 1, report.Invalid)
+			assert.Equal(t, entity.ImportRowCreated, rows[0].Status)
+			assert.Equal(t, entity.ImportRowExists, rows[1].Status)
+			assert.Equal(t, tt.dryRun, rows[0].PvzID == nil)
+			mockRepo.AssertNotCalled(t, "CreatePVZIfNotExists", mock.Anything, isExternalID("BER-1"))
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPVZUseCase_ImportPVZsOutsideRegion(t *testing.T) {
+	mockRepo := new(MockPVZRepo)
+	mockOutbox := new(MockOutboxRepo)
+	uc := NewPVZUseCase(mockRepo, mockOutbox, fakeTransactor{}, pkgPubSub.NewMemory(0), pkgLogger.Discard())
+
+	mockRepo.On("CreatePVZIfNotExists", mock.Anything, mock.MatchedBy(func(p *entity.PVZ) bool {
+		return p.City == entity.CityKazan
+	})).Return(true, nil)
+	mockOutbox.On("Add", mock.Anything, mock.Anything).Return(nil)
+
+	rows := []*entity.ImportRow{
+		{Line: 2, City: entity.CityMoscow, Address: "ул. Ленина, 1", ExternalID: "MSK-1"},
+		{Line: 3, City: entity.CityKazan, Address: "ул. Баумана, 2", ExternalID: "KZN-1"},
+	}
+	report, err := uc.ImportPVZs(context.Background(), entity.NewScope([]string{"Kazan"}), rows, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Invalid)
+	assert.Equal(t, entity.ImportRowInvalid, rows[0].Status)
+	assert.Equal(t, pkgValidator.ErrOutOfScope.Error(), rows[0].Error)
+	mockRepo.AssertExpectations(t)
+}
@@ -5,6 +5,7 @@ import (
 	"GoPVZ/pkg/pkgValidator"
 	"strconv"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 )
@@ -25,6 +26,33 @@ func (v *PVZValidator) Validate() error {
 	return nil
 }
 
+type PVZImportRowValidator struct {
+	City       string
+	Address    string
+	ExternalID string
+}
+
+func NewPVZImportRowValidator(city, address, externalId string) *PVZImportRowValidator {
+	return &PVZImportRowValidator{City: city, Address: address, ExternalID: externalId}
+}
+
+func (v *PVZImportRowValidator) Validate() error {
+	// Город проверяется по тем же правилам, что и в POST /pvz
+	if err := NewPVZValidator(dto.PostPvzJSONRequestBody{City: dto.PVZRequestCity(v.City)}).Validate(); err != nil {
+		return err
+	}
+
+	if v.Address == "" || utf8.RuneCountInString(v.Address) > 255 {
+		return pkgValidator.ErrInvalidAddress
+	}
+
+	if v.ExternalID == "" || utf8.RuneCountInString(v.ExternalID) > 100 {
+		return pkgValidator.ErrInvalidExternalID
+	}
+
+	return nil
+}
+
 type ReceptionsValidator struct {
 	Payload dto.PostReceptionsJSONBody
 }
@@ -115,6 +143,38 @@ func (v *CloseReceptionValidator) Validate() error {
 	return nil
 }
 
+type PVZIDValidator struct {
+	PVZID string
+}
+
+func NewPVZIDValidator(pvzId string) *PVZIDValidator {
+	return &PVZIDValidator{PVZID: pvzId}
+}
+
+func (v *PVZIDValidator) Validate() error {
+	if _, err := uuid.Parse(v.PVZID); err != nil {
+		return pkgValidator.ErrInvalidPVZID
+	}
+
+	return nil
+}
+
+type ReceptionIDValidator struct {
+	ReceptionID string
+}
+
+func NewReceptionIDValidator(receptionId string) *ReceptionIDValidator {
+	return &ReceptionIDValidator{ReceptionID: receptionId}
+}
+
+func (v *ReceptionIDValidator) Validate() error {
+	if _, err := uuid.Parse(v.ReceptionID); err != nil {
+		return pkgValidator.ErrInvalidReceptionID
+	}
+
+	return nil
+}
+
 type PVZsFilterValidator struct {
 	StartDate string
 	EndDate   string
@@ -0,0 +1,200 @@
+package http
+
+import (
+	"GoPVZ/internal/dto"
+	"GoPVZ/internal/webhook/entity"
+	"GoPVZ/internal/webhook/usecase"
+	"GoPVZ/internal/webhook/validation"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgValidator"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type WebhookHandler struct {
+	uc  *usecase.WebhookUseCase
+	log pkgLogger.Interface
+}
+
+func NewWebhookHandler(uc *usecase.WebhookUseCase, log pkgLogger.Interface) *WebhookHandler {
+	return &WebhookHandler{uc: uc, log: log}
+}
+
+// CreateSubscription godoc
+// @Summary Регистрация подписки на события (только для модераторов)
+// @Description Создает подписку на события ПВЗ. Доставки подписываются HMAC-SHA256 в заголовке X-Webhook-Signature
+// @Tags Domain webhook
+// @Accept json
+// @Produce json
+// @Param input body dto.PostWebhooksJSONRequestBody true "Параметры подписки"
+// @Success 201 {object} dto.WebhookSubscription "Подписка создана, секрет возвращается только в этом ответе"
+// @Failure 400 {object} dto.Error "Неверный формат запроса или ошибка валидации"
+// @Failure 401 {object} dto.Error "Ошибка авторизации"
+// @Failure 403 {object} dto.Error "Доступ запрещен"
+// @Failure 500 {object} dto.Error "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /webhooks [post]
+func (h *WebhookHandler) CreateSubscription(c *gin.Context) {
+	var req dto.PostWebhooksJSONRequestBody
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: pkgValidator.ErrInvalidInput.Error()})
+		return
+	}
+
+	validator := validation.NewSubscriptionValidator(req)
+	if err := validator.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: err.Error()})
+		return
+	}
+
+	var secret string
+	if req.Secret != nil {
+		secret = *req.Secret
+	}
+
+	sub, err := h.uc.CreateSubscription(c, req.Url, req.EventTypes, (*uuid.UUID)(req.PvzId), secret)
+	if err != nil {
+		respondInternalError(c, h.log, err)
+		return
+	}
+
+	resp := toSubscriptionDTO(sub)
+	resp.Secret = &sub.Secret
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ListSubscriptions godoc
+// @Summary Список подписок (только для модераторов)
+// @Description Возвращает все зарегистрированные подписки без секретов
+// @Tags Domain webhook
+// @Produce json
+// @Success 200 {array} dto.WebhookSubscription "Список подписок"
+// @Failure 401 {object} dto.Error "Ошибка авторизации"
+// @Failure 403 {object} dto.Error "Доступ запрещен"
+// @Failure 500 {object} dto.Error "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /webhooks [get]
+func (h *WebhookHandler) ListSubscriptions(c *gin.Context) {
+	subs, err := h.uc.ListSubscriptions(c)
+	if err != nil {
+		respondInternalError(c, h.log, err)
+		return
+	}
+
+	response := make([]dto.WebhookSubscription, 0, len(subs))
+	for _, sub := range subs {
+		response = append(response, toSubscriptionDTO(sub))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteSubscription godoc
+// @Summary Удаление подписки (только для модераторов)
+// @Description Удаляет подписку вместе с журналом доставок
+// @Tags Domain webhook
+// @Produce json
+// @Param webhookId path string true "webhookId"
+// @Success 204 "Подписка удалена"
+// @Failure 400 {object} dto.Error "Невалидный идентификатор"
+// @Failure 401 {object} dto.Error "Ошибка авторизации"
+// @Failure 403 {object} dto.Error "Доступ запрещен"
+// @Failure 404 {object} dto.Error "Подписка не найдена"
+// @Failure 500 {object} dto.Error "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /webhooks/{webhookId} [delete]
+func (h *WebhookHandler) DeleteSubscription(c *gin.Context) {
+	webhookId := c.Param("webhookId")
+
+	validator := validation.NewWebhookIDValidator(webhookId)
+	if err := validator.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: err.Error()})
+		return
+	}
+
+	if err := h.uc.DeleteSubscription(c, webhookId); err != nil {
+		if errors.Is(err, pkgValidator.ErrWebhookNotFound) {
+			c.JSON(http.StatusNotFound, dto.Error{Message: err.Error()})
+		} else {
+			respondInternalError(c, h.log, err)
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries godoc
+// @Summary Журнал доставок подписки (только для модераторов)
+// @Description Возвращает последние попытки доставки, от новых к старым
+// @Tags Domain webhook
+// @Produce json
+// @Param webhookId path string true "webhookId"
+// @Param limit query int false "Количество записей" default(50)
+// @Success 200 {array} dto.WebhookDelivery "Журнал доставок"
+// @Failure 400 {object} dto.Error "Неверные параметры запроса"
+// @Failure 401 {object} dto.Error "Ошибка авторизации"
+// @Failure 403 {object} dto.Error "Доступ запрещен"
+// @Failure 404 {object} dto.Error "Подписка не найдена"
+// @Failure 500 {object} dto.Error "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /webhooks/{webhookId}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	webhookId := c.Param("webhookId")
+	limitStr := c.DefaultQuery("limit", "50")
+
+	validator := validation.NewDeliveriesFilterValidator(webhookId, limitStr)
+	if err := validator.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Error{Message: err.Error()})
+		return
+	}
+	limit, _ := strconv.Atoi(limitStr)
+
+	deliveries, err := h.uc.ListDeliveries(c, webhookId, limit)
+	if err != nil {
+		if errors.Is(err, pkgValidator.ErrWebhookNotFound) {
+			c.JSON(http.StatusNotFound, dto.Error{Message: err.Error()})
+		} else {
+			respondInternalError(c, h.log, err)
+		}
+		return
+	}
+
+	response := make([]dto.WebhookDelivery, 0, len(deliveries))
+	for _, d := range deliveries {
+		response = append(response, dto.WebhookDelivery{
+			Id:             d.ID,
+			SubscriptionId: d.SubscriptionID,
+			EventId:        d.EventID,
+			EventType:      d.EventType,
+			Status:         dto.WebhookDeliveryStatus(d.Status),
+			Attempts:       d.Attempts,
+			ResponseStatus: d.ResponseStatus,
+			LastError:      d.LastError,
+			NextAttemptAt:  d.NextAttemptAt.UTC(),
+			CreatedAt:      d.CreatedAt.UTC(),
+			DeliveredAt:    d.DeliveredAt,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func toSubscriptionDTO(sub *entity.Subscription) dto.WebhookSubscription {
+	return dto.WebhookSubscription{
+		Id:         sub.ID,
+		Url:        sub.URL,
+		EventTypes: sub.EventTypes,
+		PvzId:      sub.PvzID,
+		CreatedAt:  sub.CreatedAt.UTC(),
+	}
+}
+
+// respondInternalError пишет ошибку в лог и отвечает 500. Маршрут, пользователь
+// и request_id попадают в запись из логгера запроса.
+func respondInternalError(c *gin.Context, log pkgLogger.Interface, err error) {
+	log.ErrorContext(c, "Request failed", pkgLogger.Err(err))
+	c.JSON(http.StatusInternalServerError, dto.Error{Message: err.Error()})
+}
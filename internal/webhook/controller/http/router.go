@@ -0,0 +1,26 @@
+package http
+
+import (
+	"GoPVZ/internal/webhook/usecase"
+	"GoPVZ/pkg/pkgLogger"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NewWebhookRouter(
+	router *gin.RouterGroup,
+	uc *usecase.WebhookUseCase,
+	log pkgLogger.Interface,
+	authMiddleware gin.HandlerFunc,
+	canManage gin.HandlerFunc,
+) {
+	handler := NewWebhookHandler(uc, log)
+
+	// Routes for users with webhook:manage
+	manageRoutes := router.Group("/webhooks")
+	manageRoutes.Use(authMiddleware, canManage)
+	manageRoutes.POST("", handler.CreateSubscription)
+	manageRoutes.GET("", handler.ListSubscriptions)
+	manageRoutes.DELETE("/:webhookId", handler.DeleteSubscription)
+	manageRoutes.GET("/:webhookId/deliveries", handler.ListDeliveries)
+}
@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending DeliveryStatus = "pending"
+	DeliverySuccess DeliveryStatus = "success"
+	DeliveryFailed  DeliveryStatus = "failed"
+)
+
+type Delivery struct {
+	ID             uuid.UUID       `json:"id"             db:"id"              example:"3fa85f64-5717-4562-b3fc-2c963f66afa6"`
+	SubscriptionID uuid.UUID       `json:"subscriptionId" db:"subscription_id" example:"3fa85f64-5717-4562-b3fc-2c963f66afa6"`
+	EventID        uuid.UUID       `json:"eventId"        db:"event_id"        example:"3fa85f64-5717-4562-b3fc-2c963f66afa6"`
+	EventType      string          `json:"eventType"      db:"event_type"      example:"reception.closed"`
+	Payload        json.RawMessage `json:"-"              db:"payload"`
+	Status         DeliveryStatus  `json:"status"         db:"status"          example:"success"`
+	Attempts       int             `json:"attempts"       db:"attempts"        example:"1"`
+	ResponseStatus *int            `json:"responseStatus" db:"response_status" example:"200"`
+	LastError      *string         `json:"lastError"      db:"last_error"`
+	NextAttemptAt  time.Time       `json:"nextAttemptAt"  db:"next_attempt_at" example:"2025-07-17T12:15:49.386Z"`
+	CreatedAt      time.Time       `json:"createdAt"      db:"created_at"      example:"2025-07-17T12:15:49.386Z"`
+	DeliveredAt    *time.Time      `json:"deliveredAt"    db:"delivered_at"    example:"2025-07-17T12:15:50.012Z"`
+}
+
+// DeliveryTask — доставка вместе с подпиской, по которой ее нужно отправить.
+type DeliveryTask struct {
+	Delivery     *Delivery
+	Subscription *Subscription
+}
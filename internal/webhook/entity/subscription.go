@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Subscription struct {
+	ID         uuid.UUID  `json:"id"         db:"id"          example:"3fa85f64-5717-4562-b3fc-2c963f66afa6"`
+	URL        string     `json:"url"        db:"url"         example:"https://partner.example.com/hooks/pvz"`
+	EventTypes []string   `json:"eventTypes" db:"event_types" example:"reception.closed"`
+	PvzID      *uuid.UUID `json:"pvzId"      db:"pvz_id"      example:"3fa85f64-5717-4562-b3fc-2c963f66afa6"`
+	Secret     string     `json:"-"          db:"secret"`
+	CreatedAt  time.Time  `json:"createdAt"  db:"created_at"  example:"2025-07-17T12:15:49.386Z"`
+}
+
+// Matches проверяет, подписан ли получатель на событие данного типа и ПВЗ.
+func (s *Subscription) Matches(eventType string, pvzID uuid.UUID) bool {
+	if s.PvzID != nil && *s.PvzID != pvzID {
+		return false
+	}
+	return slices.Contains(s.EventTypes, eventType)
+}
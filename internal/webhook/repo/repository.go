@@ -0,0 +1,201 @@
+package repo
+
+import (
+	"GoPVZ/internal/webhook/entity"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgPostgres"
+	"GoPVZ/pkg/pkgValidator"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type webhookRepo struct {
+	db  *pgxpool.Pool
+	log pkgLogger.Interface
+}
+
+func NewWebhookRepo(db *pgxpool.Pool, log pkgLogger.Interface) WebhookRepository {
+	return &webhookRepo{db: db, log: log}
+}
+
+const subscriptionColumns = `id, url, event_types, pvz_id, secret, created_at`
+
+func scanSubscription(row pgx.Row) (*entity.Subscription, error) {
+	var s entity.Subscription
+	if err := row.Scan(&s.ID, &s.URL, &s.EventTypes, &s.PvzID, &s.Secret, &s.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *webhookRepo) CreateSubscription(ctx context.Context, sub *entity.Subscription) error {
+	_, err := pkgPostgres.Conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO webhook_subscriptions (id, url, event_types, pvz_id, secret, created_at) VALUES ($1,$2,$3,$4,$5,$6)`,
+		sub.ID, sub.URL, sub.EventTypes, sub.PvzID, sub.Secret, sub.CreatedAt,
+	)
+	return err
+}
+
+func (r *webhookRepo) GetSubscription(ctx context.Context, id string) (*entity.Subscription, error) {
+	sub, err := scanSubscription(pkgPostgres.Conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+subscriptionColumns+` FROM webhook_subscriptions WHERE id=$1`, id,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, pkgValidator.ErrWebhookNotFound
+	}
+	return sub, err
+}
+
+func (r *webhookRepo) ListSubscriptions(ctx context.Context) ([]*entity.Subscription, error) {
+	return r.querySubscriptions(ctx,
+		`SELECT `+subscriptionColumns+` FROM webhook_subscriptions ORDER BY created_at DESC`)
+}
+
+func (r *webhookRepo) DeleteSubscription(ctx context.Context, id string) error {
+	tag, err := pkgPostgres.Conn(ctx, r.db).Exec(ctx, `DELETE FROM webhook_subscriptions WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pkgValidator.ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (r *webhookRepo) FindMatchingSubscriptions(ctx context.Context, eventType string, pvzID uuid.UUID) ([]*entity.Subscription, error) {
+	return r.querySubscriptions(ctx, `
+        SELECT `+subscriptionColumns+`
+        FROM webhook_subscriptions
+        WHERE $1 = ANY(event_types) AND (pvz_id IS NULL OR pvz_id = $2)`,
+		eventType, pvzID)
+}
+
+func (r *webhookRepo) querySubscriptions(ctx context.Context, query string, args ...any) ([]*entity.Subscription, error) {
+	rows, err := pkgPostgres.Conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []*entity.Subscription{}
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// CreateDelivery игнорирует повторную постановку того же события для той же подписки,
+// так как outbox гарантирует доставку "хотя бы один раз".
+func (r *webhookRepo) CreateDelivery(ctx context.Context, d *entity.Delivery) error {
+	tag, err := pkgPostgres.Conn(ctx, r.db).Exec(ctx, `
+        INSERT INTO webhook_deliveries (id, subscription_id, event_id, event_type, payload, status, next_attempt_at, created_at)
+        VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+        ON CONFLICT (subscription_id, event_id) DO NOTHING`,
+		d.ID, d.SubscriptionID, d.EventID, d.EventType, d.Payload, d.Status, d.NextAttemptAt, d.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		r.log.DebugContext(ctx, "Webhook delivery already enqueued",
+			slog.String("subscription_id", d.SubscriptionID.String()),
+			slog.String("event_id", d.EventID.String()),
+		)
+	}
+	return nil
+}
+
+// ClaimDueDeliveries сдвигает next_attempt_at выбранных доставок на lease,
+// чтобы другие воркеры не взяли их, пока идет отправка.
+func (r *webhookRepo) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*entity.DeliveryTask, error) {
+	rows, err := pkgPostgres.Conn(ctx, r.db).Query(ctx, `
+        WITH due AS (
+            SELECT id FROM webhook_deliveries
+            WHERE status = $1 AND next_attempt_at <= NOW()
+            ORDER BY next_attempt_at
+            LIMIT $2
+            FOR UPDATE SKIP LOCKED
+        )
+        UPDATE webhook_deliveries d
+        SET next_attempt_at = NOW() + $3 * INTERVAL '1 millisecond'
+        FROM due, webhook_subscriptions s
+        WHERE d.id = due.id AND s.id = d.subscription_id
+        RETURNING d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts, d.created_at,
+                  s.id, s.url, s.event_types, s.pvz_id, s.secret, s.created_at`,
+		entity.DeliveryPending, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []*entity.DeliveryTask{}
+	for rows.Next() {
+		var (
+			d entity.Delivery
+			s entity.Subscription
+		)
+		err := rows.Scan(
+			&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts, &d.CreatedAt,
+			&s.ID, &s.URL, &s.EventTypes, &s.PvzID, &s.Secret, &s.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, &entity.DeliveryTask{Delivery: &d, Subscription: &s})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(tasks) > 0 {
+		r.log.DebugContext(ctx, "Webhook deliveries claimed", slog.Int("count", len(tasks)))
+	}
+	return tasks, nil
+}
+
+func (r *webhookRepo) UpdateDelivery(ctx context.Context, d *entity.Delivery) error {
+	_, err := pkgPostgres.Conn(ctx, r.db).Exec(ctx, `
+        UPDATE webhook_deliveries
+        SET status = $2, attempts = $3, response_status = $4, last_error = $5, next_attempt_at = $6, delivered_at = $7
+        WHERE id = $1`,
+		d.ID, d.Status, d.Attempts, d.ResponseStatus, d.LastError, d.NextAttemptAt, d.DeliveredAt,
+	)
+	return err
+}
+
+func (r *webhookRepo) ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]*entity.Delivery, error) {
+	rows, err := pkgPostgres.Conn(ctx, r.db).Query(ctx, `
+        SELECT id, subscription_id, event_id, event_type, status, attempts, response_status, last_error,
+               next_attempt_at, created_at, delivered_at
+        FROM webhook_deliveries
+        WHERE subscription_id = $1
+        ORDER BY created_at DESC
+        LIMIT $2`, subscriptionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*entity.Delivery{}
+	for rows.Next() {
+		var d entity.Delivery
+		err := rows.Scan(
+			&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Status, &d.Attempts, &d.ResponseStatus, &d.LastError,
+			&d.NextAttemptAt, &d.CreatedAt, &d.DeliveredAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, &d)
+	}
+	return deliveries, rows.Err()
+}
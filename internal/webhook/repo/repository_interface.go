@@ -0,0 +1,22 @@
+package repo
+
+import (
+	"GoPVZ/internal/webhook/entity"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub *entity.Subscription) error
+	GetSubscription(ctx context.Context, id string) (*entity.Subscription, error)
+	ListSubscriptions(ctx context.Context) ([]*entity.Subscription, error)
+	DeleteSubscription(ctx context.Context, id string) error
+	FindMatchingSubscriptions(ctx context.Context, eventType string, pvzID uuid.UUID) ([]*entity.Subscription, error)
+
+	CreateDelivery(ctx context.Context, delivery *entity.Delivery) error
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*entity.DeliveryTask, error)
+	UpdateDelivery(ctx context.Context, delivery *entity.Delivery) error
+	ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]*entity.Delivery, error)
+}
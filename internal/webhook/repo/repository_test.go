@@ -0,0 +1,195 @@
+package repo
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"GoPVZ/internal/webhook/entity"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgPostgres"
+	"GoPVZ/pkg/pkgValidator"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+var (
+	testConnStr string
+	pgContainer *postgres.PostgresContainer
+)
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+
+	var err error
+	pgContainer, err = postgres.RunContainer(ctx,
+		testcontainers.WithImage("postgres:15-alpine"),
+		postgres.WithDatabase("testdb"),
+		postgres.WithUsername("testuser"),
+		postgres.WithPassword("testpass"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second),
+		),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	testConnStr, err = pgContainer.ConnectionString(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	pg, err := pkgPostgres.New(testConnStr)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = pg.Pool.Exec(ctx, `
+		CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+		CREATE TABLE IF NOT EXISTS pvz (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			registration_date TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			city VARCHAR(50) NOT NULL CHECK (city IN ('Moscow', 'Saint Petersburg', 'Kazan'))
+		);
+
+		CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			url TEXT NOT NULL,
+			event_types TEXT[] NOT NULL,
+			pvz_id UUID REFERENCES pvz(id),
+			secret VARCHAR(128) NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			subscription_id UUID NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+			event_id UUID NOT NULL,
+			event_type VARCHAR(50) NOT NULL,
+			payload JSONB NOT NULL,
+			status VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'success', 'failed')),
+			attempts INT NOT NULL DEFAULT 0,
+			response_status INT,
+			last_error TEXT,
+			next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			delivered_at TIMESTAMPTZ,
+			UNIQUE (subscription_id, event_id)
+		);
+	`)
+	if err != nil {
+		panic(err)
+	}
+	pg.Close()
+
+	code := m.Run()
+
+	if err := pgContainer.Terminate(ctx); err != nil {
+		panic(err)
+	}
+
+	os.Exit(code)
+}
+
+func setupWebhookRepo(t *testing.T) (WebhookRepository, func()) {
+	pg, err := pkgPostgres.New(testConnStr)
+	require.NoError(t, err)
+
+	_, err = pg.Pool.Exec(context.Background(), `TRUNCATE TABLE webhook_deliveries, webhook_subscriptions, pvz CASCADE`)
+	require.NoError(t, err)
+
+	return NewWebhookRepo(pg.Pool, pkgLogger.Discard()), func() {
+		pg.Close()
+	}
+}
+
+func TestWebhookRepository_Subscriptions(t *testing.T) {
+	repo, cleanup := setupWebhookRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	pvzID := uuid.New()
+	otherPvzID := uuid.New()
+
+	all := &entity.Subscription{ID: uuid.New(), URL: "https://a.example.com", EventTypes: []string{"reception.closed"}, Secret: "secret-a-123456789", CreatedAt: time.Now()}
+	require.NoError(t, repo.CreateSubscription(ctx, all))
+
+	tests := []struct {
+		name      string
+		eventType string
+		pvzID     uuid.UUID
+		wantCount int
+	}{
+		{name: "subscription without pvz filter matches", eventType: "reception.closed", pvzID: pvzID, wantCount: 1},
+		{name: "other event type does not match", eventType: "product.added", pvzID: otherPvzID, wantCount: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subs, err := repo.FindMatchingSubscriptions(ctx, tt.eventType, tt.pvzID)
+			require.NoError(t, err)
+			require.Len(t, subs, tt.wantCount)
+		})
+	}
+
+	require.NoError(t, repo.DeleteSubscription(ctx, all.ID.String()))
+	require.ErrorIs(t, repo.DeleteSubscription(ctx, all.ID.String()), pkgValidator.ErrWebhookNotFound)
+}
+
+func TestWebhookRepository_DeliveryLifecycle(t *testing.T) {
+	repo, cleanup := setupWebhookRepo(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	sub := &entity.Subscription{ID: uuid.New(), URL: "https://a.example.com", EventTypes: []string{"reception.closed"}, Secret: "secret-a-123456789", CreatedAt: time.Now()}
+	require.NoError(t, repo.CreateSubscription(ctx, sub))
+
+	delivery := &entity.Delivery{
+		ID:             uuid.New(),
+		SubscriptionID: sub.ID,
+		EventID:        uuid.New(),
+		EventType:      "reception.closed",
+		Payload:        []byte(`{}`),
+		Status:         entity.DeliveryPending,
+		NextAttemptAt:  time.Now().Add(-time.Second),
+		CreatedAt:      time.Now(),
+	}
+	require.NoError(t, repo.CreateDelivery(ctx, delivery))
+	// Повторная постановка того же события игнорируется
+	duplicate := *delivery
+	duplicate.ID = uuid.New()
+	require.NoError(t, repo.CreateDelivery(ctx, &duplicate))
+
+	tasks, err := repo.ClaimDueDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	require.Equal(t, sub.URL, tasks[0].Subscription.URL)
+
+	// Взятая в работу доставка не выдается повторно до истечения lease
+	tasks, err = repo.ClaimDueDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Empty(t, tasks)
+
+	status := 200
+	now := time.Now()
+	delivery.Status = entity.DeliverySuccess
+	delivery.Attempts = 1
+	delivery.ResponseStatus = &status
+	delivery.DeliveredAt = &now
+	require.NoError(t, repo.UpdateDelivery(ctx, delivery))
+
+	log, err := repo.ListDeliveries(ctx, sub.ID.String(), 10)
+	require.NoError(t, err)
+	require.Len(t, log, 1)
+	require.Equal(t, entity.DeliverySuccess, log[0].Status)
+	require.Equal(t, 200, *log[0].ResponseStatus)
+}
@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	outboxEntity "GoPVZ/internal/outbox/entity"
+	"GoPVZ/internal/webhook/entity"
+	"GoPVZ/internal/webhook/repo"
+	"GoPVZ/pkg/pkgLogger"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const _defaultDeliveriesLimit = 50
+
+type WebhookUseCase struct {
+	repo repo.WebhookRepository
+	log  pkgLogger.Interface
+}
+
+func NewWebhookUseCase(r repo.WebhookRepository, log pkgLogger.Interface) *WebhookUseCase {
+	return &WebhookUseCase{repo: r, log: log}
+}
+
+// CreateSubscription регистрирует получателя. Если секрет не передан, он генерируется;
+// в ответе секрет возвращается только при создании.
+func (uc *WebhookUseCase) CreateSubscription(ctx context.Context, url string, eventTypes []string, pvzID *uuid.UUID, secret string) (*entity.Subscription, error) {
+	if secret == "" {
+		generated, err := generateSecret()
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	}
+
+	sub := &entity.Subscription{
+		ID:         uuid.New(),
+		URL:        url,
+		EventTypes: eventTypes,
+		PvzID:      pvzID,
+		Secret:     secret,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	if err := uc.repo.CreateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+	uc.log.InfoContext(ctx, "Webhook subscription created", slog.String("subscription_id", sub.ID.String()), slog.String("url", url))
+	return sub, nil
+}
+
+func (uc *WebhookUseCase) ListSubscriptions(ctx context.Context) ([]*entity.Subscription, error) {
+	return uc.repo.ListSubscriptions(ctx)
+}
+
+func (uc *WebhookUseCase) DeleteSubscription(ctx context.Context, id string) error {
+	if err := uc.repo.DeleteSubscription(ctx, id); err != nil {
+		return err
+	}
+	uc.log.InfoContext(ctx, "Webhook subscription deleted", slog.String("subscription_id", id))
+	return nil
+}
+
+func (uc *WebhookUseCase) ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]*entity.Delivery, error) {
+	if limit < 1 || limit > 100 {
+		limit = _defaultDeliveriesLimit
+	}
+
+	if _, err := uc.repo.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	return uc.repo.ListDeliveries(ctx, subscriptionID, limit)
+}
+
+// Publish реализует publisher.Publisher: ставит событие в очередь доставки
+// для каждой подходящей подписки. Вызывается диспетчером outbox внутри его
+// транзакции, поэтому доставки создаются атомарно с отметкой о публикации.
+func (uc *WebhookUseCase) Publish(ctx context.Context, event *outboxEntity.Event) error {
+	subs, err := uc.repo.FindMatchingSubscriptions(ctx, string(event.Type), event.PvzID)
+	if err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, sub := range subs {
+		delivery := &entity.Delivery{
+			ID:             uuid.New(),
+			SubscriptionID: sub.ID,
+			EventID:        event.ID,
+			EventType:      string(event.Type),
+			Payload:        body,
+			Status:         entity.DeliveryPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		}
+		if err := uc.repo.CreateDelivery(ctx, delivery); err != nil {
+			return err
+		}
+	}
+	uc.log.DebugContext(ctx, "Webhook deliveries queued", slog.String("event_id", event.ID.String()), slog.Int("subscriptions", len(subs)))
+	return nil
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,226 @@
+package usecase
+
+import (
+	outboxEntity "GoPVZ/internal/outbox/entity"
+	"GoPVZ/internal/webhook/entity"
+	"GoPVZ/pkg/pkgLogger"
+	"GoPVZ/pkg/pkgValidator"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockWebhookRepo struct {
+	mock.Mock
+}
+
+func (m *MockWebhookRepo) CreateSubscription(ctx context.Context, sub *entity.Subscription) error {
+	args := m.Called(ctx, sub)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepo) GetSubscription(ctx context.Context, id string) (*entity.Subscription, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*entity.Subscription), args.Error(1)
+}
+
+func (m *MockWebhookRepo) ListSubscriptions(ctx context.Context) ([]*entity.Subscription, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*entity.Subscription), args.Error(1)
+}
+
+func (m *MockWebhookRepo) DeleteSubscription(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepo) FindMatchingSubscriptions(ctx context.Context, eventType string, pvzID uuid.UUID) ([]*entity.Subscription, error) {
+	args := m.Called(ctx, eventType, pvzID)
+	return args.Get(0).([]*entity.Subscription), args.Error(1)
+}
+
+func (m *MockWebhookRepo) CreateDelivery(ctx context.Context, delivery *entity.Delivery) error {
+	args := m.Called(ctx, delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepo) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*entity.DeliveryTask, error) {
+	args := m.Called(ctx, limit, lease)
+	return args.Get(0).([]*entity.DeliveryTask), args.Error(1)
+}
+
+func (m *MockWebhookRepo) UpdateDelivery(ctx context.Context, delivery *entity.Delivery) error {
+	args := m.Called(ctx, delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepo) ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]*entity.Delivery, error) {
+	args := m.Called(ctx, subscriptionID, limit)
+	return args.Get(0).([]*entity.Delivery), args.Error(1)
+}
+
+func TestWebhookUseCase_CreateSubscription(t *testing.T) {
+	tests := []struct {
+		name       string
+		secret     string
+		repoError  error
+		wantError  bool
+		wantSecret string
+	}{
+		{name: "provided secret", secret: "partner-secret-123456"},
+		{name: "generated secret", secret: ""},
+		{name: "repository error", secret: "partner-secret-123456", repoError: errors.New("db error"), wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockWebhookRepo)
+			uc := NewWebhookUseCase(mockRepo, pkgLogger.Discard())
+
+			mockRepo.On("CreateSubscription", mock.Anything, mock.Anything).Return(tt.repoError)
+
+			sub, err := uc.CreateSubscription(context.Background(), "https://partner.example.com/hook",
+				[]string{string(outboxEntity.EventReceptionClosed)}, nil, tt.secret)
+
+			if tt.wantError {
+				assert.Error(t, err)
+				assert.Nil(t, sub)
+				return
+			}
+			assert.NoError(t, err)
+			if tt.secret != "" {
+				assert.Equal(t, tt.secret, sub.Secret)
+			} else {
+				assert.Len(t, sub.Secret, 64)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWebhookUseCase_Publish(t *testing.T) {
+	event, err := outboxEntity.NewEvent(outboxEntity.EventReceptionClosed, uuid.New(), map[string]string{"status": "close"})
+	require.NoError(t, err)
+
+	subs := []*entity.Subscription{
+		{ID: uuid.New(), EventTypes: []string{string(event.Type)}},
+		{ID: uuid.New(), EventTypes: []string{string(event.Type)}, PvzID: &event.PvzID},
+	}
+
+	mockRepo := new(MockWebhookRepo)
+	uc := NewWebhookUseCase(mockRepo, pkgLogger.Discard())
+
+	mockRepo.On("FindMatchingSubscriptions", mock.Anything, string(event.Type), event.PvzID).Return(subs, nil)
+	for _, sub := range subs {
+		mockRepo.On("CreateDelivery", mock.Anything, mock.MatchedBy(func(d *entity.Delivery) bool {
+			return d.SubscriptionID == sub.ID && d.EventID == event.ID && d.Status == entity.DeliveryPending
+		})).Return(nil).Once()
+	}
+
+	require.NoError(t, uc.Publish(context.Background(), event))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWebhookUseCase_ListDeliveries_NotFound(t *testing.T) {
+	mockRepo := new(MockWebhookRepo)
+	uc := NewWebhookUseCase(mockRepo, pkgLogger.Discard())
+
+	id := uuid.New().String()
+	mockRepo.On("GetSubscription", mock.Anything, id).Return((*entity.Subscription)(nil), pkgValidator.ErrWebhookNotFound)
+
+	_, err := uc.ListDeliveries(context.Background(), id, 10)
+	assert.ErrorIs(t, err, pkgValidator.ErrWebhookNotFound)
+}
+
+func TestDeliveryWorker_DeliverDue(t *testing.T) {
+	const secret = "partner-secret-123456"
+
+	tests := []struct {
+		name          string
+		status        int
+		attempts      int
+		maxAttempts   int
+		wantStatus    entity.DeliveryStatus
+		wantDelivered int
+	}{
+		{name: "receiver accepts", status: http.StatusOK, maxAttempts: 3, wantStatus: entity.DeliverySuccess, wantDelivered: 1},
+		{name: "receiver fails, retry scheduled", status: http.StatusBadGateway, maxAttempts: 3, wantStatus: entity.DeliveryPending},
+		{name: "receiver fails, attempts exhausted", status: http.StatusBadGateway, attempts: 2, maxAttempts: 3, wantStatus: entity.DeliveryFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var signatureValid bool
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				ts, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+				signatureValid = VerifySignature(secret, ts, body, r.Header.Get(HeaderSignature))
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			delivery := &entity.Delivery{
+				ID:        uuid.New(),
+				EventType: string(outboxEntity.EventReceptionClosed),
+				Payload:   []byte(`{"type":"reception.closed"}`),
+				Status:    entity.DeliveryPending,
+				Attempts:  tt.attempts,
+			}
+			task := &entity.DeliveryTask{
+				Delivery:     delivery,
+				Subscription: &entity.Subscription{ID: uuid.New(), URL: srv.URL, Secret: secret},
+			}
+
+			mockRepo := new(MockWebhookRepo)
+			mockRepo.On("ClaimDueDeliveries", mock.Anything, 10, mock.Anything).Return([]*entity.DeliveryTask{task}, nil)
+			mockRepo.On("UpdateDelivery", mock.Anything, delivery).Return(nil)
+
+			w := NewDeliveryWorker(mockRepo, srv.Client(), pkgLogger.Discard(), time.Second, 10, tt.maxAttempts, time.Second)
+			before := time.Now()
+
+			delivered, err := w.DeliverDue(context.Background())
+
+			require.NoError(t, err)
+			assert.True(t, signatureValid, "receiver must be able to verify the signature")
+			assert.Equal(t, tt.wantDelivered, delivered)
+			assert.Equal(t, tt.wantStatus, delivery.Status)
+			assert.Equal(t, tt.attempts+1, delivery.Attempts)
+			assert.Equal(t, tt.status, *delivery.ResponseStatus)
+			if tt.wantStatus == entity.DeliveryPending {
+				assert.True(t, delivery.NextAttemptAt.After(before))
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeliveryWorker_Backoff(t *testing.T) {
+	w := NewDeliveryWorker(new(MockWebhookRepo), nil, pkgLogger.Discard(), time.Second, 1, 10, 5*time.Second)
+
+	assert.Equal(t, 5*time.Second, w.backoff(1))
+	assert.Equal(t, 10*time.Second, w.backoff(2))
+	assert.Equal(t, 40*time.Second, w.backoff(4))
+	assert.Equal(t, time.Hour, w.backoff(20))
+}
+
+func TestDeliveryWorker_LeaseCoversBatch(t *testing.T) {
+	// Пачка отправляется последовательно: аренда должна пережить таймауты всех попыток
+	mockRepo := new(MockWebhookRepo)
+	mockRepo.On("ClaimDueDeliveries", mock.Anything, 5, 5*2*time.Second+_deliveryLeaseMargin).Return([]*entity.DeliveryTask{}, nil)
+
+	w := NewDeliveryWorker(mockRepo, &http.Client{Timeout: 2 * time.Second}, pkgLogger.Discard(), time.Second, 5, 3, time.Second)
+	_, err := w.DeliverDue(context.Background())
+
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
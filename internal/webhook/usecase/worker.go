@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"GoPVZ/internal/webhook/entity"
+	"GoPVZ/internal/webhook/repo"
+	"GoPVZ/pkg/pkgEgress"
+	"GoPVZ/pkg/pkgLogger"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	_defaultDeliveryInterval = time.Second
+	_defaultDeliveryBatch    = 50
+	_defaultMaxAttempts      = 8
+	_defaultBaseBackoff      = 5 * time.Second
+	_defaultDeliveryTimeout  = 10 * time.Second
+	_maxBackoff              = time.Hour
+	// _deliveryLeaseMargin — запас аренды сверх времени отправки всей пачки
+	_deliveryLeaseMargin = 30 * time.Second
+)
+
+// DeliveryWorker отправляет поставленные в очередь доставки и повторяет
+// неудачные попытки с экспоненциальной задержкой.
+type DeliveryWorker struct {
+	repo        repo.WebhookRepository
+	client      *http.Client
+	log         pkgLogger.Interface
+	interval    time.Duration
+	batchSize   int
+	maxAttempts int
+	baseBackoff time.Duration
+	// timeout ограничивает одну попытку, lease — время, на которое захватывается пачка
+	timeout time.Duration
+	lease   time.Duration
+	now     func() time.Time
+}
+
+// NewDeliveryWorker -. Без client используется pkgEgress.NewClient: адреса
+// подписчиков задают пользователи, поэтому внутренние адреса недоступны.
+// Пачка отправляется последовательно, и аренда рассчитывается так, чтобы не
+// истечь раньше, чем закончится последняя попытка.
+func NewDeliveryWorker(
+	r repo.WebhookRepository,
+	client *http.Client,
+	log pkgLogger.Interface,
+	interval time.Duration,
+	batchSize int,
+	maxAttempts int,
+	baseBackoff time.Duration,
+) *DeliveryWorker {
+	if client == nil {
+		client = pkgEgress.NewClient(pkgEgress.Timeout(_defaultDeliveryTimeout))
+	}
+	timeout := client.Timeout
+	if timeout <= 0 {
+		timeout = _defaultDeliveryTimeout
+	}
+	if interval <= 0 {
+		interval = _defaultDeliveryInterval
+	}
+	if batchSize <= 0 {
+		batchSize = _defaultDeliveryBatch
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = _defaultMaxAttempts
+	}
+	if baseBackoff <= 0 {
+		baseBackoff = _defaultBaseBackoff
+	}
+
+	return &DeliveryWorker{
+		repo:        r,
+		client:      client,
+		log:         log,
+		interval:    interval,
+		batchSize:   batchSize,
+		maxAttempts: maxAttempts,
+		baseBackoff: baseBackoff,
+		timeout:     timeout,
+		lease:       time.Duration(batchSize)*timeout + _deliveryLeaseMargin,
+		now:         time.Now,
+	}
+}
+
+// Run блокируется до отмены контекста.
+func (w *DeliveryWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := w.DeliverDue(ctx); err != nil && ctx.Err() == nil {
+				w.log.Error("Webhook delivery failed", pkgLogger.Err(err))
+			}
+		}
+	}
+}
+
+// DeliverDue отправляет одну пачку доставок и возвращает число успешных.
+func (w *DeliveryWorker) DeliverDue(ctx context.Context) (int, error) {
+	tasks, err := w.repo.ClaimDueDeliveries(ctx, w.batchSize, w.lease)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, task := range tasks {
+		if w.deliver(ctx, task) {
+			delivered++
+		}
+		if err := w.repo.UpdateDelivery(ctx, task.Delivery); err != nil {
+			return delivered, err
+		}
+	}
+	return delivered, nil
+}
+
+// deliver выполняет одну попытку и записывает ее результат в task.Delivery.
+func (w *DeliveryWorker) deliver(ctx context.Context, task *entity.DeliveryTask) bool {
+	d := task.Delivery
+	d.Attempts++
+
+	status, err := w.send(ctx, task)
+	if status != 0 {
+		d.ResponseStatus = &status
+	}
+
+	now := w.now().UTC()
+	if err == nil {
+		d.Status = entity.DeliverySuccess
+		d.LastError = nil
+		d.DeliveredAt = &now
+		return true
+	}
+
+	msg := err.Error()
+	d.LastError = &msg
+	if d.Attempts >= w.maxAttempts {
+		d.Status = entity.DeliveryFailed
+	} else {
+		d.NextAttemptAt = now.Add(w.backoff(d.Attempts))
+	}
+
+	w.log.Warn("Webhook delivery attempt failed",
+		slog.String("delivery_id", d.ID.String()),
+		slog.Int("attempt", d.Attempts),
+		pkgLogger.Err(err),
+	)
+	return false
+}
+
+func (w *DeliveryWorker) send(ctx context.Context, task *entity.DeliveryTask) (int, error) {
+	d := task.Delivery
+	timestamp := w.now().Unix()
+
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, task.Subscription.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, d.EventType)
+	req.Header.Set(HeaderDelivery, d.ID.String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(task.Subscription.Secret, timestamp, d.Payload))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff: base, 2*base, 4*base, ... но не больше часа.
+func (w *DeliveryWorker) backoff(attempt int) time.Duration {
+	delay := w.baseBackoff
+	for i := 1; i < attempt && delay < _maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, _maxBackoff)
+}
@@ -0,0 +1,87 @@
+package validation
+
+import (
+	"GoPVZ/internal/dto"
+	outboxEntity "GoPVZ/internal/outbox/entity"
+	"GoPVZ/pkg/pkgValidator"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+var knownEventTypes = map[string]bool{
+	string(outboxEntity.EventPVZCreated):      true,
+	string(outboxEntity.EventReceptionOpened): true,
+	string(outboxEntity.EventReceptionClosed): true,
+	string(outboxEntity.EventProductAdded):    true,
+	string(outboxEntity.EventProductRemoved):  true,
+}
+
+type SubscriptionValidator struct {
+	Payload dto.PostWebhooksJSONRequestBody
+}
+
+func NewSubscriptionValidator(payload dto.PostWebhooksJSONRequestBody) *SubscriptionValidator {
+	return &SubscriptionValidator{Payload: payload}
+}
+
+func (v *SubscriptionValidator) Validate() error {
+	u, err := url.Parse(v.Payload.Url)
+	if err != nil || !u.IsAbs() || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return pkgValidator.ErrInvalidWebhookURL
+	}
+
+	if len(v.Payload.EventTypes) == 0 {
+		return pkgValidator.ErrInvalidEventType
+	}
+	for _, eventType := range v.Payload.EventTypes {
+		if !knownEventTypes[eventType] {
+			return pkgValidator.ErrInvalidEventType
+		}
+	}
+
+	if v.Payload.Secret != nil && len(*v.Payload.Secret) < 16 {
+		return pkgValidator.ErrInvalidWebhookSecret
+	}
+
+	return nil
+}
+
+type WebhookIDValidator struct {
+	WebhookID string
+}
+
+func NewWebhookIDValidator(webhookId string) *WebhookIDValidator {
+	return &WebhookIDValidator{WebhookID: webhookId}
+}
+
+func (v *WebhookIDValidator) Validate() error {
+	if _, err := uuid.Parse(v.WebhookID); err != nil {
+		return pkgValidator.ErrInvalidWebhookID
+	}
+
+	return nil
+}
+
+type DeliveriesFilterValidator struct {
+	WebhookID string
+	LimitStr  string
+}
+
+func NewDeliveriesFilterValidator(webhookId, limitStr string) *DeliveriesFilterValidator {
+	return &DeliveriesFilterValidator{WebhookID: webhookId, LimitStr: limitStr}
+}
+
+func (v *DeliveriesFilterValidator) Validate() error {
+	if err := NewWebhookIDValidator(v.WebhookID).Validate(); err != nil {
+		return err
+	}
+
+	limit, err := strconv.Atoi(v.LimitStr)
+	if err != nil || limit < 1 || limit > 100 {
+		return pkgValidator.ErrInvalidDeliveriesLimit
+	}
+
+	return nil
+}
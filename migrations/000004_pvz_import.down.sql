@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS pvz_external_id_key;
+ALTER TABLE pvz DROP COLUMN IF EXISTS external_id;
+ALTER TABLE pvz DROP COLUMN IF EXISTS address;
@@ -0,0 +1,5 @@
+ALTER TABLE pvz ADD COLUMN IF NOT EXISTS address TEXT NOT NULL DEFAULT '';
+ALTER TABLE pvz ADD COLUMN IF NOT EXISTS external_id VARCHAR(100);
+
+-- Ключ идемпотентности импорта; ПВЗ, созданные через API, его не имеют
+CREATE UNIQUE INDEX IF NOT EXISTS pvz_external_id_key ON pvz (external_id);
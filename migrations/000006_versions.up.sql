@@ -0,0 +1,3 @@
+-- Версии для оптимистичной блокировки: увеличиваются при каждом изменении записи
+ALTER TABLE pvz ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+ALTER TABLE receptions ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
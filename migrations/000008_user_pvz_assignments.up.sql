@@ -0,0 +1,7 @@
+-- ПВЗ, за которыми закреплен пользователь
+CREATE TABLE IF NOT EXISTS user_pvz_assignments (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    pvz_id UUID NOT NULL REFERENCES pvz(id) ON DELETE CASCADE,
+    assigned_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, pvz_id)
+);
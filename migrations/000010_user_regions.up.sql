@@ -0,0 +1,2 @@
+-- Города, которыми ограничен пользователь; пустой массив — без ограничений
+ALTER TABLE users ADD COLUMN IF NOT EXISTS regions TEXT[] NOT NULL DEFAULT '{}';
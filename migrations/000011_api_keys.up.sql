@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    -- Имя сервисного аккаунта, например erp
+    name VARCHAR(100) NOT NULL,
+    -- Первые символы ключа, чтобы отличать ключи в списке
+    prefix VARCHAR(16) NOT NULL,
+    -- Хранится только SHA-256 ключа, сам ключ показывается один раз при создании
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    permissions TEXT[] NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+-- Города создателя ключа на момент выпуска; пустой массив — без ограничений
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS regions TEXT[] NOT NULL DEFAULT '{}';
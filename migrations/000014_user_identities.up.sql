@@ -0,0 +1,9 @@
+-- Связь аккаунта с пользователем внешнего OIDC провайдера. Вход через SSO
+-- ищет аккаунт по (issuer, subject), а не по email
+CREATE TABLE IF NOT EXISTS user_identities (
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (issuer, subject)
+);
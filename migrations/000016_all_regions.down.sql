@@ -0,0 +1,2 @@
+UPDATE users SET regions = '{}' WHERE regions = '{*}';
+UPDATE api_keys SET regions = '{}' WHERE regions = '{*}';
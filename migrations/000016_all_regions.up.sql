@@ -0,0 +1,4 @@
+-- Пустой список регионов больше не означает «все города»: доступ ко всем
+-- городам отмечается '*'. Существующие пользователи и ключи сохраняют доступ
+UPDATE users SET regions = '{*}' WHERE regions = '{}';
+UPDATE api_keys SET regions = '{*}' WHERE regions = '{}';
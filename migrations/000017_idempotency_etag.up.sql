@@ -0,0 +1,2 @@
+-- ETag ответа повторяется вместе с ним
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS etag VARCHAR(255);
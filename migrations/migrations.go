@@ -0,0 +1,28 @@
+// Package migrations хранит SQL-миграции и сообщает ожидаемую версию схемы.
+package migrations
+
+import (
+	"embed"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.up.sql
+var files embed.FS
+
+// Latest возвращает номер последней миграции, например 12 для 000012_user_totp.up.sql.
+func Latest() uint {
+	entries, _ := files.ReadDir(".")
+	var latest uint
+	for _, e := range entries {
+		prefix, _, ok := strings.Cut(e.Name(), "_")
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseUint(prefix, 10, 64)
+		if err == nil && uint(v) > latest {
+			latest = uint(v)
+		}
+	}
+	return latest
+}
@@ -0,0 +1,11 @@
+package migrations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatest(t *testing.T) {
+	require.GreaterOrEqual(t, Latest(), uint(17))
+}
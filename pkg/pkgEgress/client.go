@@ -0,0 +1,120 @@
+// Package pkgEgress implements an HTTP client for requests to addresses supplied by
+// users: it refuses to connect to loopback, private and other non-public networks
+// and does not follow redirects.
+package pkgEgress
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+const (
+	_defaultTimeout     = 10 * time.Second
+	_defaultDialTimeout = 5 * time.Second
+)
+
+// ErrForbiddenAddress — адрес назначения не является публичным.
+var ErrForbiddenAddress = errors.New("destination address is not public")
+
+// Диапазоны, которые не покрываются методами netip.Addr.
+var _reserved = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"),   // CGNAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // TEST-NET-1
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // TEST-NET-2
+	netip.MustParsePrefix("203.0.113.0/24"),  // TEST-NET-3
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved и broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64: встраивает IPv4 адрес
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // локальный NAT64
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+}
+
+// Option -.
+type Option func(*config)
+
+type config struct {
+	timeout      time.Duration
+	allowPrivate bool
+}
+
+// Timeout ограничивает весь запрос, включая чтение ответа.
+func Timeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.timeout = timeout
+	}
+}
+
+// AllowPrivateNetworks отключает проверку адреса — для локальной разработки,
+// когда получатель запущен рядом с сервисом.
+func AllowPrivateNetworks(allow bool) Option {
+	return func(c *config) {
+		c.allowPrivate = allow
+	}
+}
+
+// NewClient -. Адрес проверяется при установке соединения, после разрешения имени,
+// поэтому DNS-запись, указывающая на внутренний адрес, тоже отклоняется.
+// Переменные окружения HTTP_PROXY не используются: через прокси проверить
+// конечный адрес нельзя.
+func NewClient(opts ...Option) *http.Client {
+	cfg := &config{timeout: _defaultTimeout}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	dialer := &net.Dialer{Timeout: _defaultDialTimeout}
+	if !cfg.allowPrivate {
+		dialer.Control = control
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   cfg.timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// IsPublic сообщает, можно ли отправлять запросы на адрес.
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() ||
+		addr.IsUnspecified() ||
+		addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range _reserved {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+func control(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	if !IsPublic(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+	}
+	return nil
+}
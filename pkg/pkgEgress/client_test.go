@@ -0,0 +1,56 @@
+package pkgEgress_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"GoPVZ/pkg/pkgEgress"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPublic(t *testing.T) {
+	for _, addr := range []string{"8.8.8.8", "1.1.1.1", "2606:4700:4700::1111"} {
+		require.True(t, pkgEgress.IsPublic(netip.MustParseAddr(addr)), addr)
+	}
+
+	for _, addr := range []string{
+		"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254",
+		"100.64.0.1", "0.0.0.0", "224.0.0.1", "255.255.255.255",
+		"::1", "::", "fd00::1", "fe80::1", "::ffff:127.0.0.1", "64:ff9b::a00:1",
+	} {
+		require.False(t, pkgEgress.IsPublic(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/target", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	get := func(client *http.Client, path string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, srv.URL+path, nil)
+		require.NoError(t, err)
+		return client.Do(req)
+	}
+
+	t.Run("loopback is rejected", func(t *testing.T) {
+		_, err := get(pkgEgress.NewClient(), "/")
+		require.ErrorIs(t, err, pkgEgress.ErrForbiddenAddress)
+	})
+
+	t.Run("redirects are not followed", func(t *testing.T) {
+		resp, err := get(pkgEgress.NewClient(pkgEgress.AllowPrivateNetworks(true)), "/redirect")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,141 @@
+// Package pkgHealth реализует проверки живости и готовности сервиса.
+package pkgHealth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const _defaultTimeout = 2 * time.Second
+
+// Статусы проверок.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// ErrDraining — сервис получил сигнал остановки и больше не принимает трафик.
+var ErrDraining = errors.New("shutting down")
+
+// CheckFunc проверяет одну зависимость; nil — зависимость в порядке.
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult — результат одной проверки.
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report — ответ /healthz и /readyz.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// Health хранит проверки готовности. Проверки выполняются параллельно,
+// каждая со своим таймаутом.
+type Health struct {
+	mu       sync.RWMutex
+	checks   []check
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+// New -.
+func New(opts ...Option) *Health {
+	h := &Health{timeout: _defaultTimeout}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Add регистрирует проверку готовности.
+func (h *Health) Add(name string, fn CheckFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, check{name: name, fn: fn})
+}
+
+// SetDraining переводит готовность в fail. Вызывается при получении сигнала
+// остановки, чтобы балансировщик успел снять трафик до закрытия сервера.
+func (h *Health) SetDraining() {
+	h.draining.Store(true)
+}
+
+// Check выполняет все проверки готовности.
+func (h *Health) Check(ctx context.Context) Report {
+	h.mu.RLock()
+	checks := h.checks
+	h.mu.RUnlock()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(checks)+1)}
+	if h.draining.Load() {
+		report.Status = StatusFail
+		report.Checks["shutdown"] = CheckResult{Status: StatusFail, Error: ErrDraining.Error()}
+	}
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = h.run(ctx, c.fn)
+		}()
+	}
+	wg.Wait()
+
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+func (h *Health) run(ctx context.Context, fn CheckFunc) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := fn(ctx)
+	result := CheckResult{
+		Status:    StatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Liveness отвечает 200, пока процесс способен обслуживать HTTP. Зависимости
+// не проверяются: их сбой не лечится перезапуском.
+func (h *Health) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, Report{Status: StatusOK})
+}
+
+// Readiness отвечает 200, если все проверки прошли, иначе 503.
+func (h *Health) Readiness(c *gin.Context) {
+	report := h.Check(c.Request.Context())
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
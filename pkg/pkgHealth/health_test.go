@@ -0,0 +1,103 @@
+package pkgHealth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, h *Health, path string) (int, Report) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/healthz", h.Liveness)
+	router.GET("/readyz", h.Readiness)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var report Report
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	return w.Code, report
+}
+
+func TestHealth_Readiness(t *testing.T) {
+	h := New(Timeout(50 * time.Millisecond))
+	h.Add("postgres", func(context.Context) error { return nil })
+
+	code, report := serve(t, h, "/readyz")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, StatusOK, report.Status)
+	require.Equal(t, StatusOK, report.Checks["postgres"].Status)
+
+	// Зависшая проверка обрывается по таймауту
+	h.Add("migrations", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	code, report = serve(t, h, "/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, StatusFail, report.Status)
+	require.Equal(t, StatusOK, report.Checks["postgres"].Status)
+	require.Equal(t, StatusFail, report.Checks["migrations"].Status)
+	require.Equal(t, context.DeadlineExceeded.Error(), report.Checks["migrations"].Error)
+	require.GreaterOrEqual(t, report.Checks["migrations"].LatencyMs, 50.0)
+}
+
+func TestHealth_Draining(t *testing.T) {
+	h := New()
+	h.Add("postgres", func(context.Context) error { return nil })
+	h.SetDraining()
+
+	code, report := serve(t, h, "/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, ErrDraining.Error(), report.Checks["shutdown"].Error)
+
+	// Живость от остановки не зависит
+	code, report = serve(t, h, "/healthz")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, StatusOK, report.Status)
+}
+
+func TestWorkers(t *testing.T) {
+	w := NewWorkers()
+
+	broken := make(chan struct{})
+	w.Add("dispatcher", func(ctx context.Context) { <-ctx.Done() })
+	w.Add("broken", func(context.Context) { <-broken })
+	require.NoError(t, w.Start(context.Background()))
+	require.NoError(t, w.Check(context.Background()))
+
+	close(broken)
+	select {
+	case err := <-w.Failed():
+		require.EqualError(t, err, "worker broken stopped")
+	case <-time.After(time.Second):
+		t.Fatal("worker failure was not reported")
+	}
+	require.EqualError(t, w.Check(context.Background()), "workers stopped: broken")
+
+	// Штатная остановка не считается падением
+	require.NoError(t, w.Stop(context.Background()))
+	require.EqualError(t, w.Check(context.Background()), "workers stopped: broken")
+}
+
+func TestWorkers_StopDeadline(t *testing.T) {
+	w := NewWorkers()
+	release := make(chan struct{})
+	defer close(release)
+	w.Add("stuck", func(context.Context) { <-release })
+	require.NoError(t, w.Start(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, w.Stop(ctx), context.DeadlineExceeded)
+	require.NoError(t, w.Check(context.Background()))
+}
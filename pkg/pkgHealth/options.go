@@ -0,0 +1,13 @@
+package pkgHealth
+
+import "time"
+
+// Option -.
+type Option func(*Health)
+
+// Timeout — таймаут одной проверки.
+func Timeout(timeout time.Duration) Option {
+	return func(h *Health) {
+		h.timeout = timeout
+	}
+}
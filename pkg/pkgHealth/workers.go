@@ -0,0 +1,108 @@
+package pkgHealth
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type worker struct {
+	name string
+	run  func(ctx context.Context)
+}
+
+// Workers запускает фоновые воркеры и отслеживает, что они работают. Воркер,
+// завершившийся до Stop, считается упавшим.
+type Workers struct {
+	mu      sync.Mutex
+	workers []worker
+	running map[string]bool
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	failed  chan error
+}
+
+// NewWorkers -.
+func NewWorkers() *Workers {
+	return &Workers{running: make(map[string]bool), failed: make(chan error, 1)}
+}
+
+// Add регистрирует воркер; run должен работать до отмены ctx.
+func (w *Workers) Add(name string, run func(ctx context.Context)) {
+	w.workers = append(w.workers, worker{name: name, run: run})
+}
+
+// Start запускает все воркеры в отдельных горутинах. Воркеры работают до Stop,
+// а не до отмены ctx.
+func (w *Workers) Start(context.Context) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+
+	for _, wk := range w.workers {
+		w.set(wk.name, true)
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			wk.run(ctx)
+			if ctx.Err() == nil {
+				w.set(wk.name, false)
+				select {
+				case w.failed <- fmt.Errorf("worker %s stopped", wk.name):
+				default:
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+// Failed сообщает о первом воркере, остановившемся до Stop.
+func (w *Workers) Failed() <-chan error {
+	return w.failed
+}
+
+// Stop отменяет воркеры и ждет их завершения до дедлайна ctx.
+func (w *Workers) Stop(ctx context.Context) error {
+	if w.cancel == nil {
+		return nil
+	}
+	w.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("workers did not stop: %w", ctx.Err())
+	}
+}
+
+func (w *Workers) set(name string, running bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.running[name] = running
+}
+
+// Check — проверка готовности: ошибка, если какой-либо воркер остановился.
+func (w *Workers) Check(context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var stopped []string
+	for name, running := range w.running {
+		if !running {
+			stopped = append(stopped, name)
+		}
+	}
+	if len(stopped) == 0 {
+		return nil
+	}
+	sort.Strings(stopped)
+	return fmt.Errorf("workers stopped: %s", strings.Join(stopped, ", "))
+}
@@ -0,0 +1,105 @@
+package pkgHttpserver
+
+import (
+	"GoPVZ/pkg/pkgLogger"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery превращает панику обработчика в ответ 500 и пишет ее в лог со стеком.
+// http.ErrAbortHandler пробрасывается дальше: им обработчик намеренно обрывает
+// соединение, и net/http обрабатывает его сам.
+func Recovery(log pkgLogger.Interface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			if err, ok := r.(error); ok && err == http.ErrAbortHandler {
+				panic(r)
+			}
+
+			ctx := c.Request.Context()
+			l := log
+			if scoped := pkgLogger.FromContext(ctx); scoped != nil {
+				l = scoped
+			}
+			l.ErrorContext(ctx, "Panic recovered",
+				slog.String("panic", fmt.Sprint(r)),
+				slog.String("method", c.Request.Method),
+				slog.String("path", c.Request.URL.Path),
+				slog.String("stack", string(debug.Stack())),
+			)
+
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": http.StatusText(http.StatusInternalServerError)})
+		}()
+		c.Next()
+	}
+}
+
+// SecurityHeaders добавляет заголовки, запрещающие угадывание типа и встраивание
+// ответов в чужие страницы. HSTS отправляется только по TLS.
+func SecurityHeaders(hsts bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h := c.Writer.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("X-Frame-Options", "DENY")
+		h.Set("Referrer-Policy", "no-referrer")
+		if hsts {
+			h.Set("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
+		}
+		c.Next()
+	}
+}
+
+const _corsMaxAge = 10 * time.Minute
+
+var (
+	_corsMethods = strings.Join([]string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}, ", ")
+	_corsHeaders = strings.Join([]string{"Authorization", "Content-Type", "If-Match", "Idempotency-Key", "X-API-Key", pkgLogger.RequestIDHeader}, ", ")
+	_corsExpose  = strings.Join([]string{"ETag", "Location", pkgLogger.RequestIDHeader}, ", ")
+)
+
+// CORS разрешает браузерные запросы с перечисленных источников; "*" — с любых.
+// Preflight-запросы отвечаются без вызова обработчиков.
+func CORS(origins []string) gin.HandlerFunc {
+	allowAll := slices.Contains(origins, "*")
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Add("Vary", "Origin")
+		if !allowAll && !slices.Contains(origins, origin) {
+			c.Next()
+			return
+		}
+
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Set("Access-Control-Expose-Headers", _corsExpose)
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", _corsMethods)
+			h.Set("Access-Control-Allow-Headers", _corsHeaders)
+			h.Set("Access-Control-Max-Age", strconv.Itoa(int(_corsMaxAge.Seconds())))
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,147 @@
+package pkgHttpserver
+
+import (
+	"GoPVZ/pkg/pkgLogger"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRouter(mw ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(mw...)
+	return router
+}
+
+func TestRecovery(t *testing.T) {
+	router := newTestRouter(Recovery(pkgLogger.Discard()))
+	router.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+	router.GET("/abort", func(c *gin.Context) {
+		panic(http.ErrAbortHandler)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	require.JSONEq(t, `{"message":"Internal Server Error"}`, w.Body.String())
+
+	// ErrAbortHandler обрабатывает сам net/http
+	require.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/abort", nil))
+	})
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	tests := []struct {
+		name string
+		hsts bool
+	}{
+		{name: "plain HTTP", hsts: false},
+		{name: "TLS", hsts: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(SecurityHeaders(tt.hsts))
+			router.GET("/pvz", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pvz", nil))
+
+			require.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+			require.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+			require.Equal(t, tt.hsts, w.Header().Get("Strict-Transport-Security") != "")
+		})
+	}
+}
+
+func TestCORS(t *testing.T) {
+	router := newTestRouter(CORS([]string{"https://pvz.example.com"}))
+	router.GET("/pvz", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	t.Run("preflight", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/pvz", nil)
+		req.Header.Set("Origin", "https://pvz.example.com")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusNoContent, w.Code)
+		require.Equal(t, "https://pvz.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		require.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+		require.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	})
+
+	t.Run("unknown origin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/pvz", nil)
+		req.Header.Set("Origin", "https://evil.example.com")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "Origin", w.Header().Get("Vary"))
+	})
+}
+
+func TestGzip(t *testing.T) {
+	body := strings.Repeat(`{"city":"Москва"}`, 100)
+	router := newTestRouter(Gzip())
+	router.GET("/json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(body))
+	})
+	router.GET("/events", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/event-stream", []byte("data: {}\n\n"))
+	})
+	router.GET("/export", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/zip", []byte("PK"))
+	})
+
+	serve := func(target, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("compressed", func(t *testing.T) {
+		w := serve("/json", "gzip, deflate")
+		require.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		require.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+
+		zr, err := gzip.NewReader(bytes.NewReader(w.Body.Bytes()))
+		require.NoError(t, err)
+		got, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		require.Equal(t, body, string(got))
+	})
+
+	t.Run("not accepted", func(t *testing.T) {
+		w := serve("/json", "")
+		require.Empty(t, w.Header().Get("Content-Encoding"))
+		require.Equal(t, body, w.Body.String())
+	})
+
+	t.Run("event stream", func(t *testing.T) {
+		w := serve("/events", "gzip")
+		require.Empty(t, w.Header().Get("Content-Encoding"))
+		require.Equal(t, "data: {}\n\n", w.Body.String())
+	})
+
+	t.Run("already compressed type", func(t *testing.T) {
+		w := serve("/export", "gzip")
+		require.Empty(t, w.Header().Get("Content-Encoding"))
+		require.Equal(t, "PK", w.Body.String())
+	})
+}
@@ -1,6 +1,7 @@
 package pkgHttpserver
 
 import (
+	"GoPVZ/pkg/pkgLogger"
 	"net"
 	"time"
 )
@@ -34,4 +35,70 @@ func ShutdownTimeout(timeout time.Duration) Option {
 	return func(s *Server) {
 		s.shutdownTimeout = timeout
 	}
-}
\ No newline at end of file
+}
+// ReadHeaderTimeout -.
+func ReadHeaderTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.readHeaderTimeout = timeout
+	}
+}
+
+// IdleTimeout — сколько держать keep-alive соединение без запросов.
+func IdleTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.idleTimeout = timeout
+	}
+}
+
+// MaxHeaderBytes -.
+func MaxHeaderBytes(n int) Option {
+	return func(s *Server) {
+		s.maxHeaderBytes = n
+	}
+}
+
+// Logger — логгер для паник и перезагрузки сертификата.
+func Logger(log pkgLogger.Interface) Option {
+	return func(s *Server) {
+		s.log = log
+	}
+}
+
+// TLS включает HTTPS. Сертификат и ключ перечитываются по SIGHUP.
+func TLS(certFile, keyFile string) Option {
+	return func(s *Server) {
+		s.certFile = certFile
+		s.keyFile = keyFile
+	}
+}
+
+// H2C включает HTTP/2 без TLS. При включенном TLS не нужен.
+func H2C(enabled bool) Option {
+	return func(s *Server) {
+		s.h2c = enabled
+	}
+}
+
+// Compression включает gzip для ответов; включено по умолчанию.
+func Compression(enabled bool) Option {
+	return func(s *Server) {
+		s.gzip = enabled
+	}
+}
+
+// CORSOrigins — источники, которым разрешены браузерные запросы; "*" — любые.
+// Пусто — CORS выключен.
+func CORSOrigins(origins []string) Option {
+	return func(s *Server) {
+		s.corsOrigins = origins
+	}
+}
+
+// TrustedProxies — адреса и подсети прокси, от которых принимаются
+// X-Forwarded-For и X-Real-IP. Пусто — заголовки игнорируются и адресом
+// клиента считается адрес соединения.
+func TrustedProxies(proxies []string) Option {
+	return func(s *Server) {
+		s.trustedProxies = proxies
+	}
+}
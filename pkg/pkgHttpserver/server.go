@@ -2,42 +2,84 @@
 package pkgHttpserver
 
 import (
+	"GoPVZ/pkg/pkgLogger"
 	"context"
+	"crypto/tls"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 const (
-	_defaultAddr            = ":80"
-	_defaultReadTimeout     = 5 * time.Second
-	_defaultWriteTimeout    = 5 * time.Second
-	_defaultShutdownTimeout = 3 * time.Second
+	_defaultAddr              = ":80"
+	_defaultReadTimeout       = 5 * time.Second
+	_defaultReadHeaderTimeout = 5 * time.Second
+	_defaultWriteTimeout      = 5 * time.Second
+	_defaultIdleTimeout       = 60 * time.Second
+	_defaultShutdownTimeout   = 3 * time.Second
 )
 
 // Server -.
 type Server struct {
-	server          *http.Server
-	router         *gin.Engine
-	notify         chan error
-	address        string
-	readTimeout    time.Duration
-	writeTimeout   time.Duration
-	shutdownTimeout time.Duration
+	server            *http.Server
+	router            *gin.Engine
+	notify            chan error
+	log               pkgLogger.Interface
+	address           string
+	readTimeout       time.Duration
+	readHeaderTimeout time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	maxHeaderBytes    int
+	shutdownTimeout   time.Duration
+
+	certFile string
+	keyFile  string
+	cert     *certReloader
+	h2c      bool
+	stopHUP  chan struct{}
+
+	gzip           bool
+	corsOrigins    []string
+	trustedProxies []string
+
+	// err — ошибка конфигурации из New, возвращается через Notify при Start
+	err error
+
+	// cancelRequests отменяет контексты запросов при Shutdown, чтобы долгие
+	// ответы (SSE, выгрузки) завершились, а не держали сервер до таймаута
+	cancelRequests context.CancelFunc
 }
 
-// New -.
+// New создает сервер с middleware по умолчанию: восстановление после паники,
+// заголовки безопасности, CORS (если заданы источники) и gzip. Middleware,
+// добавленные через GetRouter().Use, выполняются внутри них.
 func New(opts ...Option) *Server {
 	router := gin.New()
+	// *gin.Context передается в usecase как context.Context: значения и отмена
+	// должны браться из контекста запроса
+	router.ContextWithFallback = true
 
 	s := &Server{
-		router:         router,
-		notify:         make(chan error, 1),
-		address:        _defaultAddr,
-		readTimeout:    _defaultReadTimeout,
-		writeTimeout:   _defaultWriteTimeout,
-		shutdownTimeout: _defaultShutdownTimeout,
+		router:            router,
+		notify:            make(chan error, 1),
+		log:               pkgLogger.Discard(),
+		address:           _defaultAddr,
+		readTimeout:       _defaultReadTimeout,
+		readHeaderTimeout: _defaultReadHeaderTimeout,
+		writeTimeout:      _defaultWriteTimeout,
+		idleTimeout:       _defaultIdleTimeout,
+		maxHeaderBytes:    http.DefaultMaxHeaderBytes,
+		shutdownTimeout:   _defaultShutdownTimeout,
+		gzip:              true,
 	}
 
 	// Custom options
@@ -45,20 +87,72 @@ func New(opts ...Option) *Server {
 		opt(s)
 	}
 
+	// gin по умолчанию доверяет X-Forwarded-For от любого клиента; без
+	// настроенных прокси c.ClientIP() — адрес соединения
+	s.err = router.SetTrustedProxies(s.trustedProxies)
+
+	router.Use(Recovery(s.log), SecurityHeaders(s.certFile != ""))
+	if len(s.corsOrigins) > 0 {
+		router.Use(CORS(s.corsOrigins))
+	}
+	if s.gzip {
+		router.Use(Gzip())
+	}
+
+	var handler http.Handler = router
+	if s.h2c && s.certFile == "" {
+		// HTTP/2 без TLS для внутренних клиентов и прокси; с TLS HTTP/2
+		// согласуется через ALPN автоматически
+		handler = h2c.NewHandler(router, &http2.Server{IdleTimeout: s.idleTimeout})
+	}
+
+	baseCtx, cancel := context.WithCancel(context.Background())
+	s.cancelRequests = cancel
+
 	s.server = &http.Server{
-		Addr:         s.address,
-		Handler:      router,
-		ReadTimeout:  s.readTimeout,
-		WriteTimeout: s.writeTimeout,
+		Addr:              s.address,
+		BaseContext:       func(net.Listener) context.Context { return baseCtx },
+		Handler:           handler,
+		ReadTimeout:       s.readTimeout,
+		ReadHeaderTimeout: s.readHeaderTimeout,
+		WriteTimeout:      s.writeTimeout,
+		IdleTimeout:       s.idleTimeout,
+		MaxHeaderBytes:    s.maxHeaderBytes,
 	}
 
 	return s
 }
 
-// Start -.
+// Start -. Ошибка запуска, в том числе загрузки сертификата, приходит в Notify.
 func (s *Server) Start() {
+	if s.err != nil {
+		s.notify <- s.err
+		close(s.notify)
+		return
+	}
+
+	if s.certFile != "" {
+		cert, err := newCertReloader(s.certFile, s.keyFile)
+		if err != nil {
+			s.notify <- err
+			close(s.notify)
+			return
+		}
+		s.cert = cert
+		s.server.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: cert.GetCertificate,
+		}
+		s.watchSIGHUP()
+	}
+
 	go func() {
-		err := s.server.ListenAndServe()
+		var err error
+		if s.server.TLSConfig != nil {
+			err = s.server.ListenAndServeTLS("", "")
+		} else {
+			err = s.server.ListenAndServe()
+		}
 		if err != nil && err != http.ErrServerClosed {
 			s.notify <- err
 		}
@@ -66,14 +160,45 @@ func (s *Server) Start() {
 	}()
 }
 
+// watchSIGHUP перечитывает сертификат и ключ по SIGHUP, например после
+// обновления сертификата certbot'ом. При ошибке остается прежний сертификат.
+func (s *Server) watchSIGHUP() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	s.stopHUP = make(chan struct{})
+
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-s.stopHUP:
+				return
+			case <-hup:
+				if err := s.cert.Reload(); err != nil {
+					s.log.Error("Failed to reload TLS certificate", pkgLogger.Err(err))
+					continue
+				}
+				s.log.Info("TLS certificate reloaded", slog.String("cert", s.certFile))
+			}
+		}
+	}()
+}
+
 // Notify -.
 func (s *Server) Notify() <-chan error {
 	return s.notify
 }
 
-// Shutdown -.
-func (s *Server) Shutdown() error {
-	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+// Shutdown отменяет контексты активных запросов и дожидается их завершения,
+// но не дольше shutdownTimeout и дедлайна ctx.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.stopHUP != nil {
+		close(s.stopHUP)
+		s.stopHUP = nil
+	}
+	s.cancelRequests()
+
+	ctx, cancel := context.WithTimeout(ctx, s.shutdownTimeout)
 	defer cancel()
 	return s.server.Shutdown(ctx)
 }
@@ -81,4 +206,4 @@ func (s *Server) Shutdown() error {
 // GetRouter возвращает экземпляр gin.Engine для настройки маршрутов
 func (s *Server) GetRouter() *gin.Engine {
 	return s.router
-}
\ No newline at end of file
+}
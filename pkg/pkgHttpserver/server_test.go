@@ -0,0 +1,84 @@
+package pkgHttpserver
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_TrustedProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies []string
+		want    string
+	}{
+		{name: "no proxies", proxies: nil, want: "192.0.2.1"},
+		{name: "trusted proxy", proxies: []string{"192.0.2.0/24"}, want: "203.0.113.7"},
+		{name: "other proxy", proxies: []string{"10.0.0.0/8"}, want: "192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(TrustedProxies(tt.proxies))
+			require.NoError(t, s.err)
+			s.GetRouter().GET("/ip", func(c *gin.Context) { c.String(http.StatusOK, c.ClientIP()) })
+
+			req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			w := httptest.NewRecorder()
+			s.GetRouter().ServeHTTP(w, req)
+			require.Equal(t, tt.want, w.Body.String())
+		})
+	}
+}
+
+func TestNew_InvalidTrustedProxy(t *testing.T) {
+	s := New(TrustedProxies([]string{"not-an-ip"}))
+	s.Start()
+	require.Error(t, <-s.Notify())
+}
+
+func TestServer_ShutdownCancelsRequests(t *testing.T) {
+	s := New()
+	cancelled := make(chan struct{})
+	s.GetRouter().GET("/events", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		c.Status(http.StatusOK)
+		c.Writer.WriteString("data: {}\n\n")
+		c.Writer.Flush()
+
+		<-c.Request.Context().Done()
+		close(cancelled)
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go s.server.Serve(ln)
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "data: {}\n", line)
+
+	// Подключенный SSE клиент не должен задерживать остановку до shutdownTimeout
+	start := time.Now()
+	require.NoError(t, s.Shutdown(context.Background()))
+	assert.Less(t, time.Since(start), _defaultShutdownTimeout)
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("request context was not cancelled")
+	}
+}
@@ -0,0 +1,121 @@
+// Package pkgLifecycle запускает и останавливает компоненты сервиса.
+package pkgLifecycle
+
+import (
+	"GoPVZ/pkg/pkgLogger"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"time"
+)
+
+const _defaultShutdownTimeout = 15 * time.Second
+
+// Hook описывает компонент: сервер, пул воркеров, пул соединений.
+type Hook struct {
+	Name string
+	// OnStart запускает компонент и не блокирует. nil — запускать нечего.
+	OnStart func(ctx context.Context) error
+	// OnStop останавливает компонент в пределах дедлайна ctx. nil — останавливать нечего.
+	OnStop func(ctx context.Context) error
+	// Done сообщает о непредвиденной остановке: ошибка или закрытие канала
+	// до начала штатной остановки считаются сбоем компонента.
+	Done <-chan error
+}
+
+// Manager запускает компоненты в порядке регистрации и останавливает в обратном
+// в пределах общего дедлайна.
+type Manager struct {
+	hooks           []Hook
+	log             pkgLogger.Interface
+	shutdownTimeout time.Duration
+}
+
+// New -.
+func New(log pkgLogger.Interface, opts ...Option) *Manager {
+	m := &Manager{log: log, shutdownTimeout: _defaultShutdownTimeout}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Add регистрирует компонент.
+func (m *Manager) Add(h Hook) {
+	m.hooks = append(m.hooks, h)
+}
+
+// Run запускает компоненты и ждет отмены ctx (сигнала остановки) или сбоя
+// любого компонента, после чего останавливает все запущенные. Возвращает nil,
+// только если остановка штатная и все компоненты остановились без ошибок.
+func (m *Manager) Run(ctx context.Context) error {
+	started := 0
+	var failure error
+	for _, h := range m.hooks {
+		if h.OnStart != nil {
+			if err := h.OnStart(ctx); err != nil {
+				failure = fmt.Errorf("start %s: %w", h.Name, err)
+				break
+			}
+		}
+		started++
+		m.log.Debug("Component started", slog.String("name", h.Name))
+	}
+
+	if failure == nil {
+		failure = m.wait(ctx, m.hooks[:started])
+	}
+	if failure != nil {
+		m.log.Error("Component failed, shutting down", pkgLogger.Err(failure))
+	} else {
+		m.log.Info("Shutdown signal received")
+	}
+
+	return errors.Join(failure, m.stop(m.hooks[:started]))
+}
+
+// wait ждет отмены ctx или сигнала Done любого компонента.
+func (m *Manager) wait(ctx context.Context, hooks []Hook) error {
+	cases := []reflect.SelectCase{{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}}
+	names := []string{""}
+	for _, h := range hooks {
+		if h.Done != nil {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(h.Done)})
+			names = append(names, h.Name)
+		}
+	}
+
+	chosen, value, ok := reflect.Select(cases)
+	if chosen == 0 {
+		return nil
+	}
+	if ok && !value.IsNil() {
+		return fmt.Errorf("%s: %w", names[chosen], value.Interface().(error))
+	}
+	return fmt.Errorf("%s stopped unexpectedly", names[chosen])
+}
+
+// stop останавливает компоненты в обратном порядке. Ошибка одного компонента
+// не мешает остановке остальных.
+func (m *Manager) stop(hooks []Hook) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.shutdownTimeout)
+	defer cancel()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		if h.OnStop == nil {
+			continue
+		}
+		start := time.Now()
+		if err := h.OnStop(ctx); err != nil {
+			m.log.Error("Component stop failed", slog.String("name", h.Name), pkgLogger.Err(err))
+			errs = append(errs, fmt.Errorf("stop %s: %w", h.Name, err))
+			continue
+		}
+		m.log.Info("Component stopped", slog.String("name", h.Name), slog.Duration("duration", time.Since(start)))
+	}
+	return errors.Join(errs...)
+}
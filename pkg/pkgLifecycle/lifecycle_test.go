@@ -0,0 +1,111 @@
+package pkgLifecycle
+
+import (
+	"GoPVZ/pkg/pkgLogger"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recorder записывает порядок вызовов хуков.
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) hook(name string) Hook {
+	return Hook{
+		Name: name,
+		OnStart: func(context.Context) error {
+			r.add("start " + name)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			r.add("stop " + name)
+			return nil
+		},
+	}
+}
+
+func (r *recorder) add(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func TestManager_GracefulStop(t *testing.T) {
+	rec := &recorder{}
+	m := New(pkgLogger.Discard())
+	m.Add(rec.hook("postgres"))
+	m.Add(rec.hook("workers"))
+	m.Add(rec.hook("http"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	require.NoError(t, m.Run(ctx))
+	require.Equal(t, []string{
+		"start postgres", "start workers", "start http",
+		"stop http", "stop workers", "stop postgres",
+	}, rec.calls)
+}
+
+func TestManager_ComponentFailure(t *testing.T) {
+	rec := &recorder{}
+	m := New(pkgLogger.Discard())
+	m.Add(rec.hook("postgres"))
+
+	notify := make(chan error, 1)
+	api := rec.hook("http")
+	api.Done = notify
+	m.Add(api)
+
+	notify <- errors.New("address already in use")
+	err := m.Run(context.Background())
+	require.EqualError(t, err, "http: address already in use")
+	require.Equal(t, []string{"start postgres", "start http", "stop http", "stop postgres"}, rec.calls)
+
+	// Закрытие канала без ошибки до остановки — тоже сбой
+	closed := make(chan error)
+	close(closed)
+	m = New(pkgLogger.Discard())
+	m.Add(Hook{Name: "metrics", Done: closed})
+	require.EqualError(t, m.Run(context.Background()), "metrics stopped unexpectedly")
+}
+
+func TestManager_StartFailure(t *testing.T) {
+	rec := &recorder{}
+	m := New(pkgLogger.Discard())
+	m.Add(rec.hook("postgres"))
+	m.Add(Hook{Name: "workers", OnStart: func(context.Context) error { return errors.New("boom") }})
+	m.Add(rec.hook("http"))
+
+	err := m.Run(context.Background())
+	require.EqualError(t, err, "start workers: boom")
+	// Запущенные до сбоя компоненты останавливаются, следующие не запускаются
+	require.Equal(t, []string{"start postgres", "stop postgres"}, rec.calls)
+}
+
+func TestManager_SharedDeadline(t *testing.T) {
+	rec := &recorder{}
+	m := New(pkgLogger.Discard(), ShutdownTimeout(20*time.Millisecond))
+	m.Add(rec.hook("postgres"))
+	m.Add(Hook{Name: "workers", OnStop: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	err := m.Run(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), time.Second)
+	// Ошибка остановки одного компонента не мешает остановить остальные
+	require.Equal(t, []string{"start postgres", "stop postgres"}, rec.calls)
+}
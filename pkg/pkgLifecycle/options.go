@@ -0,0 +1,13 @@
+package pkgLifecycle
+
+import "time"
+
+// Option -.
+type Option func(*Manager)
+
+// ShutdownTimeout — общий дедлайн остановки всех компонентов.
+func ShutdownTimeout(timeout time.Duration) Option {
+	return func(m *Manager) {
+		m.shutdownTimeout = timeout
+	}
+}
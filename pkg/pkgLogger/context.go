@@ -0,0 +1,20 @@
+package pkgLogger
+
+import "context"
+
+type contextKey struct{}
+
+// NewContext возвращает контекст с логгером запроса. Методы *Context любого
+// логгера добавляют к записи его поля: request_id, пользователя, маршрут.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext возвращает логгер запроса или nil, если его нет.
+func FromContext(ctx context.Context) *Logger {
+	if ctx == nil {
+		return nil
+	}
+	l, _ := ctx.Value(contextKey{}).(*Logger)
+	return l
+}
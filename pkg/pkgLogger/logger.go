@@ -3,11 +3,14 @@ package pkgLogger
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"runtime"
 	"strings"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Interface -.
@@ -17,38 +20,130 @@ type Interface interface {
 	Warn(message string, args ...interface{})
 	Error(message interface{}, args ...interface{})
 	Fatal(message interface{}, args ...interface{})
+
+	DebugContext(ctx context.Context, message string, args ...interface{})
+	InfoContext(ctx context.Context, message string, args ...interface{})
+	WarnContext(ctx context.Context, message string, args ...interface{})
+	ErrorContext(ctx context.Context, message string, args ...interface{})
 }
 
 // Logger -.
 type Logger struct {
 	logger *slog.Logger
+	level  *slog.LevelVar
+	// attrs — аргументы всех вызовов With, чтобы перенести их в другой логгер
+	attrs []interface{}
 }
 
 var _ Interface = (*Logger)(nil)
 
-// New создает новый экземпляр логгера.
+// Форматы вывода.
+const (
+	FormatPretty = "pretty"
+	FormatText   = "text"
+	FormatJSON   = "json"
+)
+
+// Options -.
+type Options struct {
+	// Env — local, dev или prod; задает уровень и формат по умолчанию
+	Env string
+	// Level — debug, info, warn или error; пусто — по окружению
+	Level string
+	// Format — pretty, text или json; пусто — по окружению
+	Format string
+	// Output — по умолчанию os.Stdout
+	Output io.Writer
+}
+
+// New создает логгер с настройками окружения env.
 func New(env string) *Logger {
-	var log *slog.Logger
+	l, _ := NewWithOptions(Options{Env: env})
+	return l
+}
 
-	switch strings.ToLower(env) {
+// NewWithOptions создает логгер; ошибка — неизвестный уровень или формат.
+// Для local по умолчанию цветной вывод и debug, для dev — JSON и debug,
+// для остальных окружений — JSON и info.
+func NewWithOptions(opts Options) (*Logger, error) {
+	level, format := slog.LevelInfo, FormatJSON
+	switch strings.ToLower(opts.Env) {
 	case "local":
-		log = setupPrettySlog()
+		level, format = slog.LevelDebug, FormatPretty
 	case "dev":
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
-		)
-	case "prod":
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
-		)
+		level = slog.LevelDebug
+	}
+
+	if opts.Level != "" {
+		parsed, err := ParseLevel(opts.Level)
+		if err != nil {
+			return nil, err
+		}
+		level = parsed
+	}
+	if opts.Format != "" {
+		format = strings.ToLower(opts.Format)
+	}
+	out := opts.Output
+	if out == nil {
+		out = os.Stdout
+	}
+
+	levelVar := &slog.LevelVar{}
+	levelVar.Set(level)
+	handlerOpts := &slog.HandlerOptions{Level: levelVar}
+
+	var handler slog.Handler
+	switch format {
+	case FormatPretty:
+		handler = PrettyHandlerOptions{SlogOpts: handlerOpts}.NewPrettyHandler(out)
+	case FormatText:
+		handler = slog.NewTextHandler(out, handlerOpts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(out, handlerOpts)
 	default:
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
-		)
+		return nil, fmt.Errorf("unknown log format %q", opts.Format)
+	}
+
+	return &Logger{logger: slog.New(handler), level: levelVar}, nil
+}
+
+// Discard возвращает логгер, который ничего не пишет; для тестов.
+func Discard() *Logger {
+	l, _ := NewWithOptions(Options{Output: io.Discard})
+	return l
+}
+
+// ParseLevel разбирает debug, info, warn или error без учета регистра.
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", s)
 	}
+	return level, nil
+}
 
+// Level возвращает текущий уровень в нижнем регистре.
+func (l *Logger) Level() string {
+	return strings.ToLower(l.level.Level().String())
+}
+
+// SetLevel меняет уровень на лету; действует и на логгеры, полученные через With.
+func (l *Logger) SetLevel(s string) error {
+	level, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	l.level.Set(level)
+	return nil
+}
+
+// With возвращает логгер, который добавляет args к каждой записи, например компонент.
+func (l *Logger) With(args ...interface{}) *Logger {
 	return &Logger{
-		logger: log,
+		logger: l.logger.With(args...),
+		level:  l.level,
+		attrs:  append(l.attrs[:len(l.attrs):len(l.attrs)], args...),
 	}
 }
 
@@ -78,19 +173,55 @@ func (l *Logger) Fatal(message interface{}, args ...interface{}) {
 	os.Exit(1)
 }
 
+// DebugContext пишет запись с полями логгера запроса из ctx, см. NewContext.
+func (l *Logger) DebugContext(ctx context.Context, message string, args ...interface{}) {
+	l.logContext(ctx, slog.LevelDebug, message, args...)
+}
+
+// InfoContext -.
+func (l *Logger) InfoContext(ctx context.Context, message string, args ...interface{}) {
+	l.logContext(ctx, slog.LevelInfo, message, args...)
+}
+
+// WarnContext -.
+func (l *Logger) WarnContext(ctx context.Context, message string, args ...interface{}) {
+	l.logContext(ctx, slog.LevelWarn, message, args...)
+}
+
+// ErrorContext -.
+func (l *Logger) ErrorContext(ctx context.Context, message string, args ...interface{}) {
+	l.logContext(ctx, slog.LevelError, message, args...)
+}
+
 func (l *Logger) log(level slog.Level, message string, args ...interface{}) {
-	if !l.logger.Enabled(context.TODO(), level) {
+	l.handle(context.TODO(), l.logger, level, message, args...)
+}
+
+func (l *Logger) logContext(ctx context.Context, level slog.Level, message string, args ...interface{}) {
+	logger := l.logger
+	if scoped := FromContext(ctx); scoped != nil && scoped != l && len(scoped.attrs) > 0 {
+		logger = logger.With(scoped.attrs...)
+	}
+	l.handle(ctx, logger, level, message, args...)
+}
+
+func (l *Logger) handle(ctx context.Context, logger *slog.Logger, level slog.Level, message string, args ...interface{}) {
+	if !logger.Enabled(ctx, level) {
 		return
 	}
 
 	var pcs [1]uintptr
-	runtime.Callers(3, pcs[:]) // skip [Callers, log, Interface.Method]
+	runtime.Callers(4, pcs[:]) // skip [Callers, handle, log, Interface.Method]
 
 	r := slog.NewRecord(time.Now(), level, message, pcs[0])
 	if len(args) > 0 {
 		r.Add(args...)
 	}
-	_ = l.logger.Handler().Handle(context.TODO(), r)
+	// Связываем запись с трассой, чтобы из лога можно было перейти к спану
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	_ = logger.Handler().Handle(ctx, r)
 }
 
 func (l *Logger) msg(level slog.Level, message interface{}, args ...interface{}) {
@@ -0,0 +1,80 @@
+package pkgLogger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNewWithOptions_Defaults(t *testing.T) {
+	tests := []struct {
+		opts      Options
+		wantLevel string
+	}{
+		{opts: Options{Env: "local"}, wantLevel: "debug"},
+		{opts: Options{Env: "dev"}, wantLevel: "debug"},
+		{opts: Options{Env: "prod"}, wantLevel: "info"},
+		{opts: Options{Env: "prod", Level: "WARN"}, wantLevel: "warn"},
+		{opts: Options{Env: "local", Level: "error", Format: "text"}, wantLevel: "error"},
+	}
+	for _, tt := range tests {
+		l, err := NewWithOptions(tt.opts)
+		require.NoError(t, err)
+		require.Equal(t, tt.wantLevel, l.Level(), "%+v", tt.opts)
+	}
+
+	_, err := NewWithOptions(Options{Level: "verbose"})
+	require.Error(t, err)
+	_, err = NewWithOptions(Options{Format: "xml"})
+	require.Error(t, err)
+}
+
+func TestLogger_SetLevel(t *testing.T) {
+	var out bytes.Buffer
+	l, err := NewWithOptions(Options{Env: "prod", Output: &out})
+	require.NoError(t, err)
+	child := l.With("component", "test")
+
+	child.Debug("hidden")
+	require.Zero(t, out.Len())
+
+	// Уровень общий для логгера и логгеров из With
+	require.NoError(t, l.SetLevel("debug"))
+	child.Debug("shown")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	require.Equal(t, "shown", record["msg"])
+	require.Equal(t, "test", record["component"])
+
+	require.Error(t, l.SetLevel("loud"))
+	require.Equal(t, "debug", l.Level())
+}
+
+func TestLogger_TraceContext(t *testing.T) {
+	var out bytes.Buffer
+	l, err := NewWithOptions(Options{Env: "prod", Output: &out})
+	require.NoError(t, err)
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3},
+		SpanID:     trace.SpanID{4, 5, 6},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	l.InfoContext(ctx, "traced")
+	l.InfoContext(context.Background(), "untraced")
+
+	dec := json.NewDecoder(&out)
+	var traced, untraced map[string]any
+	require.NoError(t, dec.Decode(&traced))
+	require.NoError(t, dec.Decode(&untraced))
+	require.Equal(t, sc.TraceID().String(), traced["trace_id"])
+	require.Equal(t, sc.SpanID().String(), traced["span_id"])
+	require.NotContains(t, untraced, "trace_id")
+}
@@ -0,0 +1,88 @@
+package pkgLogger
+
+import (
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader — заголовок с идентификатором запроса. Принимается от клиента
+// или прокси и возвращается в ответе.
+const RequestIDHeader = "X-Request-ID"
+
+// _requestIDPattern защищает логи от произвольных строк в заголовке.
+var _requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestMiddleware кладет в контекст запроса логгер с request_id, методом и
+// маршрутом и по завершении пишет одну строку access-лога. Должно идти первым:
+// для c.Request.Context() нужен gin.Engine.ContextWithFallback, чтобы логгер
+// был виден и через *gin.Context.
+func RequestMiddleware(log *Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if !_requestIDPattern.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+		c.Set("request_id", requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		route := c.FullPath()
+		setRequestLogger(c, log.With(
+			slog.String("request_id", requestID),
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+		))
+
+		// Паника перехватывается выше (pkgHttpserver.Recovery), поэтому
+		// запрос логируется как 500 здесь, не прерывая ее раскрутку
+		completed := false
+		defer func() {
+			status := c.Writer.Status()
+			if !completed {
+				status = http.StatusInternalServerError
+			}
+			logAccess(c, status, start)
+		}()
+
+		c.Next()
+		completed = true
+	}
+}
+
+func logAccess(c *gin.Context, status int, start time.Time) {
+	access := []interface{}{
+		slog.String("path", c.Request.URL.Path),
+		slog.Int("status", status),
+		slog.Duration("duration", time.Since(start)),
+		slog.Int("bytes", max(c.Writer.Size(), 0)),
+		slog.String("client_ip", c.ClientIP()),
+	}
+	if len(c.Errors) > 0 {
+		access = append(access, slog.String("error", c.Errors.String()))
+	}
+	// Логгер мог пополниться пользователем, см. AddRequestAttrs
+	ctx := c.Request.Context()
+	if status >= http.StatusInternalServerError {
+		FromContext(ctx).ErrorContext(ctx, "HTTP request", access...)
+		return
+	}
+	FromContext(ctx).InfoContext(ctx, "HTTP request", access...)
+}
+
+// AddRequestAttrs добавляет поля к логгеру текущего запроса, например пользователя
+// после проверки токена. Без RequestMiddleware ничего не делает.
+func AddRequestAttrs(c *gin.Context, args ...interface{}) {
+	if l := FromContext(c.Request.Context()); l != nil {
+		setRequestLogger(c, l.With(args...))
+	}
+}
+
+func setRequestLogger(c *gin.Context, l *Logger) {
+	c.Request = c.Request.WithContext(NewContext(c.Request.Context(), l))
+}
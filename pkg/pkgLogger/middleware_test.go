@@ -0,0 +1,71 @@
+package pkgLogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var out bytes.Buffer
+	log, err := NewWithOptions(Options{Env: "prod", Output: &out})
+	require.NoError(t, err)
+	component := log.With("component", "pvz")
+
+	router := gin.New()
+	router.ContextWithFallback = true
+	router.Use(RequestMiddleware(log))
+	router.GET("/pvz/:pvzId", func(c *gin.Context) {
+		AddRequestAttrs(c, "user_id", "u-1")
+		// Логгер компонента получает поля запроса через контекст
+		component.InfoContext(c, "PVZ loaded")
+		c.Status(http.StatusNoContent)
+	})
+
+	records := func() []map[string]any {
+		var result []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			var record map[string]any
+			require.NoError(t, json.Unmarshal([]byte(line), &record))
+			result = append(result, record)
+		}
+		out.Reset()
+		return result
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/pvz/42", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, "abc-123", w.Header().Get(RequestIDHeader))
+
+	logged := records()
+	require.Len(t, logged, 2)
+	require.Equal(t, "PVZ loaded", logged[0]["msg"])
+	require.Equal(t, "pvz", logged[0]["component"])
+	for _, record := range logged {
+		require.Equal(t, "abc-123", record["request_id"])
+		require.Equal(t, "u-1", record["user_id"])
+		require.Equal(t, "/pvz/:pvzId", record["route"])
+	}
+	require.Equal(t, "HTTP request", logged[1]["msg"])
+	require.Equal(t, float64(http.StatusNoContent), logged[1]["status"])
+	require.Equal(t, "/pvz/42", logged[1]["path"])
+
+	// Недопустимый идентификатор заменяется сгенерированным
+	req = httptest.NewRequest(http.MethodGet, "/pvz/42", nil)
+	req.Header.Set(RequestIDHeader, "bad id\n")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	generated := w.Header().Get(RequestIDHeader)
+	require.Len(t, generated, 36)
+	require.Equal(t, generated, records()[1]["request_id"])
+}
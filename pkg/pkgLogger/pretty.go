@@ -6,7 +6,6 @@ import (
 	"io"
 	stdLog "log"
 	"log/slog"
-	"os"
 
 	"github.com/fatih/color"
 )
@@ -17,8 +16,16 @@ type PrettyHandlerOptions struct {
 
 type PrettyHandler struct {
 	slog.Handler
-	l     *stdLog.Logger
-	attrs []slog.Attr
+	l *stdLog.Logger
+	// attrs — поля из WithAttrs вместе с группами, открытыми на момент вызова
+	attrs []groupedAttr
+	// groups — группы из WithGroup, в которые попадут следующие поля
+	groups []string
+}
+
+type groupedAttr struct {
+	groups []string
+	attr   slog.Attr
 }
 
 func (opts PrettyHandlerOptions) NewPrettyHandler(out io.Writer) *PrettyHandler {
@@ -42,17 +49,16 @@ func (h *PrettyHandler) Handle(_ context.Context, r slog.Record) error {
 		level = color.RedString(level)
 	}
 
-	fields := make(map[string]interface{}, r.NumAttrs())
+	fields := make(map[string]interface{}, len(h.attrs)+r.NumAttrs())
 
+	for _, a := range h.attrs {
+		addField(fields, a.groups, a.attr)
+	}
 	r.Attrs(func(a slog.Attr) bool {
-		fields[a.Key] = a.Value.Any()
+		addField(fields, h.groups, a)
 		return true
 	})
 
-	for _, a := range h.attrs {
-		fields[a.Key] = a.Value.Any()
-	}
-
 	var b []byte
 	var err error
 
@@ -63,7 +69,7 @@ func (h *PrettyHandler) Handle(_ context.Context, r slog.Record) error {
 		}
 	}
 
-	timeStr := r.Time.Format("[15:05:05.000]")
+	timeStr := r.Time.Format("[15:04:05.000]")
 	msg := color.CyanString(r.Message)
 
 	h.l.Println(
@@ -76,28 +82,64 @@ func (h *PrettyHandler) Handle(_ context.Context, r slog.Record) error {
 	return nil
 }
 
+// WithAttrs добавляет поля к уже накопленным, а не заменяет их.
 func (h *PrettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	withAttrs := make([]groupedAttr, len(h.attrs), len(h.attrs)+len(attrs))
+	copy(withAttrs, h.attrs)
+	for _, a := range attrs {
+		withAttrs = append(withAttrs, groupedAttr{groups: h.groups, attr: a})
+	}
 	return &PrettyHandler{
-		Handler: h.Handler,
+		Handler: h.Handler.WithAttrs(attrs),
 		l:       h.l,
-		attrs:   attrs,
+		attrs:   withAttrs,
+		groups:  h.groups,
 	}
 }
 
+// WithGroup вкладывает следующие поля в группу name; накопленные поля сохраняются.
 func (h *PrettyHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	groups := make([]string, len(h.groups), len(h.groups)+1)
+	copy(groups, h.groups)
 	return &PrettyHandler{
 		Handler: h.Handler.WithGroup(name),
 		l:       h.l,
+		attrs:   h.attrs,
+		groups:  append(groups, name),
 	}
 }
 
-func setupPrettySlog() *slog.Logger {
-	opts := PrettyHandlerOptions{
-		SlogOpts: &slog.HandlerOptions{
-			Level: slog.LevelDebug,
-		},
+// addField кладет поле в fields, создавая вложенные объекты для групп.
+func addField(fields map[string]interface{}, groups []string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) || (a.Value.Kind() == slog.KindGroup && len(a.Value.Group()) == 0) {
+		return
+	}
+	for _, group := range groups {
+		nested, ok := fields[group].(map[string]interface{})
+		if !ok {
+			nested = make(map[string]interface{})
+			fields[group] = nested
+		}
+		fields = nested
 	}
 
-	handler := opts.NewPrettyHandler(os.Stdout)
-	return slog.New(handler)
+	if a.Value.Kind() != slog.KindGroup {
+		fields[a.Key] = a.Value.Any()
+		return
+	}
+	// Группа без имени раскрывается в текущий уровень
+	var inner []string
+	if a.Key != "" {
+		inner = []string{a.Key}
+	}
+	for _, ga := range a.Value.Group() {
+		addField(fields, inner, ga)
+	}
 }
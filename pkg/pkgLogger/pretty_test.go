@@ -0,0 +1,43 @@
+package pkgLogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrettyHandler_WithAttrsAndGroups(t *testing.T) {
+	color.NoColor = true
+
+	var out bytes.Buffer
+	handler := PrettyHandlerOptions{SlogOpts: &slog.HandlerOptions{}}.NewPrettyHandler(&out)
+
+	// Поля копятся по цепочке вызовов, группа не теряет поля, добавленные до нее
+	logger := slog.New(handler).
+		With("component", "pvz").
+		With("request_id", "r-1").
+		WithGroup("http").
+		With("method", "GET")
+	logger.Info("done", "status", 200, slog.Group("user", "id", "u-1"))
+
+	line := out.String()
+	require.Contains(t, line, "INFO: done")
+	fields := line[strings.Index(line, "{"):]
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal([]byte(fields), &got))
+	require.Equal(t, map[string]any{
+		"component":  "pvz",
+		"request_id": "r-1",
+		"http": map[string]any{
+			"method": "GET",
+			"status": float64(200),
+			"user":   map[string]any{"id": "u-1"},
+		},
+	}, got)
+}
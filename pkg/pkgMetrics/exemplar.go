@@ -0,0 +1,25 @@
+package pkgMetrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Exemplar'ы видны только в формате OpenMetrics, поэтому /metrics нужно отдавать
+// через promhttp.HandlerOpts{EnableOpenMetrics: true}.
+
+// Observe записывает значение в гистограмму с exemplar trace_id. Пустой traceID —
+// обычное наблюдение без exemplar.
+func Observe(o prometheus.Observer, v float64, traceID string) {
+	if eo, ok := o.(prometheus.ExemplarObserver); ok && traceID != "" {
+		eo.ObserveWithExemplar(v, prometheus.Labels{"trace_id": traceID})
+		return
+	}
+	o.Observe(v)
+}
+
+// Add увеличивает счетчик с exemplar trace_id. Пустой traceID — обычный Add.
+func Add(c prometheus.Counter, v float64, traceID string) {
+	if ea, ok := c.(prometheus.ExemplarAdder); ok && traceID != "" {
+		ea.AddWithExemplar(v, prometheus.Labels{"trace_id": traceID})
+		return
+	}
+	c.Add(v)
+}
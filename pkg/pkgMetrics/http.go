@@ -0,0 +1,129 @@
+package pkgMetrics
+
+import (
+	"GoPVZ/pkg/pkgTracing"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Значения метки path для запросов, у которых нет шаблона маршрута или
+// которые отклонены до обработчика. Так сканеры и перебор токенов не создают
+// новых временных рядов и видны отдельно от нормального трафика.
+const (
+	PathUnmatched    = "unmatched"
+	PathUnauthorized = "unauthorized"
+	PathForbidden    = "forbidden"
+)
+
+// MethodOther — значение метки method для нестандартных методов: иначе
+// произвольный метод в запросе создавал бы новый временной ряд.
+const MethodOther = "other"
+
+// _durationBuckets рассчитаны на API, отвечающие за единицы миллисекунд.
+var _durationBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// _sizeBuckets — от 64 байт до 4 МБ.
+var _sizeBuckets = prometheus.ExponentialBuckets(64, 4, 9)
+
+// HTTP — метрики HTTP сервера.
+type HTTP struct {
+	requests     *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	requestSize  *prometheus.HistogramVec
+	responseSize *prometheus.HistogramVec
+	inFlight     prometheus.Gauge
+}
+
+// NewHTTP регистрирует метрики HTTP сервера в reg.
+func NewHTTP(reg prometheus.Registerer) *HTTP {
+	f := promauto.With(reg)
+	return &HTTP{
+		requests: f.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Total number of HTTP requests",
+		}, []string{"method", "path", "status"}),
+		duration: f.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Duration of HTTP requests",
+			Buckets: _durationBuckets,
+		}, []string{"method", "path"}),
+		requestSize: f.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_size_bytes",
+			Help:    "Size of HTTP request bodies",
+			Buckets: _sizeBuckets,
+		}, []string{"method", "path"}),
+		responseSize: f.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_response_size_bytes",
+			Help:    "Size of HTTP response bodies",
+			Buckets: _sizeBuckets,
+		}, []string{"method", "path"}),
+		inFlight: f.NewGauge(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "Number of HTTP requests being served",
+		}),
+	}
+}
+
+// Middleware собирает метрики запроса. Метка path — шаблон маршрута
+// (c.FullPath()), а не фактический путь. Должно идти после
+// pkgTracing.Middleware, чтобы к наблюдениям прикреплялся trace_id.
+func (m *HTTP) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		// Паника перехватывается выше (pkgHttpserver.Recovery) уже после
+		// этого middleware, поэтому запрос учитывается как 500 здесь
+		completed := false
+		defer func() {
+			status := c.Writer.Status()
+			if !completed {
+				status = http.StatusInternalServerError
+			}
+			m.observe(c, status, start)
+		}()
+
+		c.Next()
+		completed = true
+	}
+}
+
+func (m *HTTP) observe(c *gin.Context, status int, start time.Time) {
+	method := methodLabel(c.Request.Method)
+	path := routeLabel(c.FullPath(), status)
+	traceID := pkgTracing.TraceID(c.Request.Context())
+
+	Add(m.requests.WithLabelValues(method, path, strconv.Itoa(status)), 1, traceID)
+	Observe(m.duration.WithLabelValues(method, path), time.Since(start).Seconds(), traceID)
+	m.requestSize.WithLabelValues(method, path).Observe(float64(max(c.Request.ContentLength, 0)))
+	m.responseSize.WithLabelValues(method, path).Observe(float64(max(c.Writer.Size(), 0)))
+}
+
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return MethodOther
+	}
+}
+
+func routeLabel(route string, status int) string {
+	switch {
+	case route == "":
+		return PathUnmatched
+	case status == http.StatusUnauthorized:
+		return PathUnauthorized
+	case status == http.StatusForbidden:
+		return PathForbidden
+	default:
+		return route
+	}
+}
@@ -0,0 +1,114 @@
+package pkgMetrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_Middleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	m := NewHTTP(prometheus.NewRegistry())
+	router := gin.New()
+	router.Use(m.Middleware())
+	router.POST("/pvz/:pvzId/close_last_reception", func(c *gin.Context) {
+		// Во время обработки запрос учтен как выполняющийся
+		require.Equal(t, 1.0, testutil.ToFloat64(m.inFlight))
+		c.String(http.StatusOK, "closed")
+	})
+	router.GET("/pvz", func(c *gin.Context) {
+		c.AbortWithStatus(http.StatusUnauthorized)
+	})
+	router.GET("/admin/log-level", func(c *gin.Context) {
+		c.AbortWithStatus(http.StatusForbidden)
+	})
+
+	serve := func(method, target, body string) {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, target, strings.NewReader(body)))
+	}
+	serve(http.MethodPost, "/pvz/6f1c2a6e-0b5d-4a53-9d1e-1f1f6c5d0a01/close_last_reception", "{}")
+	serve(http.MethodPost, "/pvz/0b3c7e0e-3c0f-4f61-8f7e-6d1d3c4a2b02/close_last_reception", "")
+	serve(http.MethodGet, "/pvz", "")
+	serve(http.MethodGet, "/admin/log-level", "")
+	serve(http.MethodGet, "/wp-login.php", "")
+	serve(http.MethodGet, "/.env", "")
+
+	// Разные id схлопываются в один шаблон маршрута
+	require.Equal(t, 2.0, testutil.ToFloat64(m.requests.WithLabelValues(http.MethodPost, "/pvz/:pvzId/close_last_reception", "200")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues(http.MethodGet, PathUnauthorized, "401")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues(http.MethodGet, PathForbidden, "403")))
+	require.Equal(t, 2.0, testutil.ToFloat64(m.requests.WithLabelValues(http.MethodGet, PathUnmatched, "404")))
+	require.Equal(t, 4, testutil.CollectAndCount(m.requests))
+
+	require.Equal(t, 0.0, testutil.ToFloat64(m.inFlight))
+	require.Equal(t, 4, testutil.CollectAndCount(m.duration))
+
+	require.Equal(t, 4, testutil.CollectAndCount(m.responseSize))
+}
+
+func TestHTTP_RequestSize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	m := NewHTTP(prometheus.NewRegistry())
+	router := gin.New()
+	router.Use(m.Middleware())
+
+	router.POST("/pvz", func(c *gin.Context) { c.String(http.StatusCreated, "created") })
+
+	body := `{"city":"Москва"}`
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/pvz", strings.NewReader(body)))
+
+	sum := func(c prometheus.Collector) float64 {
+		ch := make(chan prometheus.Metric, 1)
+		c.Collect(ch)
+		var metric dto.Metric
+		require.NoError(t, (<-ch).Write(&metric))
+		return metric.GetHistogram().GetSampleSum()
+	}
+	require.Equal(t, float64(len(body)), sum(m.requestSize))
+	require.Equal(t, float64(len("created")), sum(m.responseSize))
+}
+
+func TestHTTP_Panic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	m := NewHTTP(prometheus.NewRegistry())
+	router := gin.New()
+	router.Use(gin.CustomRecovery(func(c *gin.Context, _ any) {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	router.Use(m.Middleware())
+	router.GET("/pvz", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/pvz", nil))
+
+	require.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues(http.MethodGet, "/pvz", "500")))
+	require.Equal(t, 0.0, testutil.ToFloat64(m.inFlight))
+}
+
+func TestHTTP_MethodLabel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	m := NewHTTP(prometheus.NewRegistry())
+	router := gin.New()
+	router.Use(m.Middleware())
+
+	for _, method := range []string{"PROPFIND", "XYZZY", "get"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/pvz", nil))
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/pvz", nil))
+
+	require.Equal(t, 3.0, testutil.ToFloat64(m.requests.WithLabelValues(MethodOther, PathUnmatched, "404")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues(http.MethodGet, PathUnmatched, "404")))
+	require.Equal(t, 2, testutil.CollectAndCount(m.requests))
+}
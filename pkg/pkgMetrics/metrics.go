@@ -6,29 +6,35 @@ import (
 )
 
 var (
-	HttpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
-		Name: "http_requests_total",
-		Help: "Total number of HTTP requests",
-	}, []string{"method", "path", "status"})
-
-	HttpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
-		Name:    "http_request_duration_seconds",
-		Help:    "Duration of HTTP requests",
-		Buckets: []float64{0.1, 0.3, 0.5, 0.7, 1, 1.5, 2, 3, 5},
-	}, []string{"method", "path"})
-
-	PVZCreatedTotal = promauto.NewCounter(prometheus.CounterOpts{
+	PVZCreatedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "pvz_created_total",
 		Help: "Total number of PVZ created",
-	})
+	}, []string{"city"})
 
-	ReceptionsCreatedTotal = promauto.NewCounter(prometheus.CounterOpts{
+	ReceptionsCreatedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "receptions_created_total",
 		Help: "Total number of receptions created",
-	})
+	}, []string{"city"})
 
-	ProductsAddedTotal = promauto.NewCounter(prometheus.CounterOpts{
+	ReceptionsClosedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "receptions_closed_total",
+		Help: "Total number of receptions closed",
+	}, []string{"city"})
+
+	ProductsAddedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "products_added_total",
 		Help: "Total number of products added",
-	})
+	}, []string{"city", "type"})
+
+	// Снимки из БД, обновляются периодически, см. PVZUseCase.RunMetricsRefresh
+
+	OpenReceptions = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "open_receptions",
+		Help: "Number of receptions in progress",
+	}, []string{"city"})
+
+	ProductsOnHand = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "products_on_hand",
+		Help: "Number of products accepted at PVZs",
+	}, []string{"city", "type"})
 )
@@ -0,0 +1,133 @@
+package pkgOIDC_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"GoPVZ/pkg/pkgOIDC"
+	"GoPVZ/pkg/pkgOIDC/oidctest"
+
+	"github.com/stretchr/testify/require"
+)
+
+// login проходит страницу входа мок-провайдера и возвращает code и state из редиректа.
+func login(t *testing.T, authURL string) (code, state string) {
+	t.Helper()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestProvider_AuthorizationCodeFlow(t *testing.T) {
+	idp := oidctest.NewServer("gopvz", "client-secret")
+	defer idp.Close()
+	idp.SetUser(map[string]any{
+		"sub":            "user-1",
+		"email":          "sso@example.com",
+		"email_verified": true,
+		"groups":         []string{"pvz-staff", "everyone"},
+	})
+
+	provider := pkgOIDC.New(idp.Config("http://localhost/auth/oidc/callback"))
+	ctx := context.Background()
+
+	verifier, err := pkgOIDC.NewRandom()
+	require.NoError(t, err)
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", pkgOIDC.CodeChallenge(verifier))
+	require.NoError(t, err)
+
+	code, state := login(t, authURL)
+	require.Equal(t, "state-1", state)
+
+	token, err := provider.Exchange(ctx, code, verifier, "nonce-1")
+	require.NoError(t, err)
+	require.Equal(t, idp.Issuer(), token.Issuer)
+	require.Equal(t, "user-1", token.Subject)
+	require.Equal(t, "sso@example.com", token.Email)
+	require.True(t, token.EmailVerified)
+	require.Equal(t, []string{"pvz-staff", "everyone"}, token.Strings("groups"))
+
+	// Код одноразовый
+	_, err = provider.Exchange(ctx, code, verifier, "nonce-1")
+	require.ErrorIs(t, err, pkgOIDC.ErrExchange)
+}
+
+func TestProvider_ExchangeRejectsWrongVerifierAndNonce(t *testing.T) {
+	idp := oidctest.NewServer("gopvz", "client-secret")
+	defer idp.Close()
+	idp.SetUser(map[string]any{"sub": "user-1"})
+
+	provider := pkgOIDC.New(idp.Config("http://localhost/callback"))
+	ctx := context.Background()
+
+	authURL, err := provider.AuthCodeURL(ctx, "state", "nonce", pkgOIDC.CodeChallenge("verifier"))
+	require.NoError(t, err)
+	code, _ := login(t, authURL)
+	_, err = provider.Exchange(ctx, code, "other-verifier", "nonce")
+	require.ErrorIs(t, err, pkgOIDC.ErrExchange)
+
+	authURL, err = provider.AuthCodeURL(ctx, "state", "nonce", pkgOIDC.CodeChallenge("verifier"))
+	require.NoError(t, err)
+	code, _ = login(t, authURL)
+	_, err = provider.Exchange(ctx, code, "verifier", "other-nonce")
+	require.ErrorIs(t, err, pkgOIDC.ErrInvalidIDToken)
+}
+
+func TestProvider_Verify(t *testing.T) {
+	idp := oidctest.NewServer("gopvz", "client-secret")
+	defer idp.Close()
+
+	provider := pkgOIDC.New(idp.Config("http://localhost/callback"))
+	ctx := context.Background()
+	now := time.Now()
+
+	valid := func() map[string]any {
+		return map[string]any{
+			"iss":   idp.Issuer(),
+			"aud":   "gopvz",
+			"sub":   "user-1",
+			"iat":   now.Unix(),
+			"exp":   now.Add(time.Hour).Unix(),
+			"nonce": "nonce",
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(map[string]any)
+		wantErr bool
+	}{
+		{name: "valid", mutate: func(map[string]any) {}},
+		{name: "other issuer", mutate: func(c map[string]any) { c["iss"] = "https://evil.example.com" }, wantErr: true},
+		{name: "other audience", mutate: func(c map[string]any) { c["aud"] = "other-client" }, wantErr: true},
+		{name: "expired", mutate: func(c map[string]any) { c["exp"] = now.Add(-time.Minute).Unix() }, wantErr: true},
+		{name: "no subject", mutate: func(c map[string]any) { delete(c, "sub") }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid()
+			tt.mutate(claims)
+
+			token, err := provider.Verify(ctx, idp.Sign(claims), "nonce")
+			if tt.wantErr {
+				require.ErrorIs(t, err, pkgOIDC.ErrInvalidIDToken)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "user-1", token.Subject)
+		})
+	}
+}
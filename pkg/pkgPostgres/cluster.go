@@ -0,0 +1,239 @@
+package pkgPostgres
+
+import (
+	"GoPVZ/pkg/pkgLogger"
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	_defaultReadAfterWrite = 5 * time.Second
+	_defaultCheckInterval  = 5 * time.Second
+	_defaultCheckTimeout   = 2 * time.Second
+)
+
+// Cluster направляет запись в основной пул, а чтение — в реплики.
+// Реплика, не ответившая на проверку, исключается из чтения до следующей
+// успешной проверки; если здоровых реплик нет, читается основной пул.
+//
+// После записи сессия в течение окна readAfterWrite читает из основного пула,
+// чтобы видеть свои изменения, еще не дошедшие до реплик. Сессия определяется
+// функцией sessionKey; по умолчанию все запросы — одна сессия.
+type Cluster struct {
+	primary        *pgxpool.Pool
+	replicas       []*replica
+	next           atomic.Uint64
+	readAfterWrite time.Duration
+	checkInterval  time.Duration
+	sessionKey     func(ctx context.Context) string
+	log            pkgLogger.Interface
+
+	mu     sync.Mutex
+	writes map[string]time.Time
+}
+
+type replica struct {
+	name    string
+	pool    *pgxpool.Pool
+	healthy atomic.Bool
+	// checked меняется только в Run
+	checked bool
+}
+
+// ClusterOption -.
+type ClusterOption func(*Cluster)
+
+// Replica добавляет пул реплики. До первой успешной проверки реплика не используется.
+func Replica(name string, pool *pgxpool.Pool) ClusterOption {
+	return func(c *Cluster) {
+		c.replicas = append(c.replicas, &replica{name: name, pool: pool})
+	}
+}
+
+// ReadAfterWrite задает окно после записи, в течение которого сессия читает
+// из основного пула; 0 — всегда читать из реплик.
+func ReadAfterWrite(window time.Duration) ClusterOption {
+	return func(c *Cluster) {
+		c.readAfterWrite = window
+	}
+}
+
+// SessionKey задает, как определить сессию по контексту, например по пользователю.
+func SessionKey(fn func(ctx context.Context) string) ClusterOption {
+	return func(c *Cluster) {
+		c.sessionKey = fn
+	}
+}
+
+// CheckInterval -.
+func CheckInterval(d time.Duration) ClusterOption {
+	return func(c *Cluster) {
+		c.checkInterval = d
+	}
+}
+
+// ClusterLogger -.
+func ClusterLogger(log pkgLogger.Interface) ClusterOption {
+	return func(c *Cluster) {
+		c.log = log
+	}
+}
+
+// NewCluster -.
+func NewCluster(primary *pgxpool.Pool, opts ...ClusterOption) *Cluster {
+	c := &Cluster{
+		primary:        primary,
+		readAfterWrite: _defaultReadAfterWrite,
+		checkInterval:  _defaultCheckInterval,
+		sessionKey:     func(context.Context) string { return "" },
+		log:            pkgLogger.Discard(),
+		writes:         make(map[string]time.Time),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Primary возвращает основной пул.
+func (c *Cluster) Primary() *pgxpool.Pool {
+	return c.primary
+}
+
+// Reader возвращает пул для чтения: здоровую реплику по кругу или основной
+// пул, если реплик нет, все недоступны или сессия недавно писала.
+func (c *Cluster) Reader(ctx context.Context) *pgxpool.Pool {
+	if len(c.replicas) == 0 || c.wroteRecently(ctx) {
+		return c.primary
+	}
+
+	start := c.next.Add(1)
+	for i := range c.replicas {
+		r := c.replicas[(start+uint64(i))%uint64(len(c.replicas))]
+		if r.healthy.Load() {
+			return r.pool
+		}
+	}
+	return c.primary
+}
+
+// WithinTransaction открывает транзакцию в основном пуле и после успешного
+// коммита отмечает запись для сессии, см. ReadAfterWrite.
+func (c *Cluster) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	if err := NewTransactor(c.primary).WithinTransaction(ctx, fn); err != nil {
+		return err
+	}
+	c.markWrite(ctx)
+	return nil
+}
+
+// ReadTransactor возвращает Transactor, открывающий транзакции только для
+// чтения в пуле из Reader: для курсоров и согласованных выборок на реплике.
+// Уже открытая транзакция переиспользуется.
+func (c *Cluster) ReadTransactor() Transactor {
+	return readTransactor{c: c}
+}
+
+type readTransactor struct {
+	c *Cluster
+}
+
+func (t readTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := t.c.Reader(ctx).BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("postgres - ReadTransactor - Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("postgres - ReadTransactor - Commit: %w", err)
+	}
+	return nil
+}
+
+func (c *Cluster) markWrite(ctx context.Context) {
+	if c.readAfterWrite <= 0 || len(c.replicas) == 0 {
+		return
+	}
+	c.mu.Lock()
+	c.writes[c.sessionKey(ctx)] = time.Now()
+	c.mu.Unlock()
+}
+
+func (c *Cluster) wroteRecently(ctx context.Context) bool {
+	if c.readAfterWrite <= 0 {
+		return false
+	}
+	c.mu.Lock()
+	at, ok := c.writes[c.sessionKey(ctx)]
+	c.mu.Unlock()
+	return ok && time.Since(at) < c.readAfterWrite
+}
+
+// Run проверяет реплики каждые checkInterval до отмены ctx и удаляет
+// истекшие отметки записи. Первая проверка выполняется сразу.
+func (c *Cluster) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.checkInterval)
+	defer ticker.Stop()
+	for {
+		c.checkReplicas(ctx)
+		c.pruneWrites()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Cluster) checkReplicas(ctx context.Context) {
+	for _, r := range c.replicas {
+		checkCtx, cancel := context.WithTimeout(ctx, _defaultCheckTimeout)
+		err := r.pool.Ping(checkCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		healthy := err == nil
+		if r.healthy.Swap(healthy) == healthy && r.checked {
+			continue
+		}
+		r.checked = true
+		if healthy {
+			c.log.Info("Replica is available for reads", slog.String("replica", r.name))
+		} else {
+			c.log.Warn("Replica is unavailable, reads fall back", slog.String("replica", r.name), pkgLogger.Err(err))
+		}
+	}
+}
+
+func (c *Cluster) pruneWrites() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, at := range c.writes {
+		if time.Since(at) >= c.readAfterWrite {
+			delete(c.writes, key)
+		}
+	}
+}
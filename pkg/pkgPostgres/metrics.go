@@ -0,0 +1,80 @@
+package pkgPostgres
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector отдает статистику пула pgxpool в Prometheus. Значения читаются
+// из pool.Stat() при каждом сборе метрик.
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquired      *prometheus.Desc
+	idle          *prometheus.Desc
+	constructing  *prometheus.Desc
+	total         *prometheus.Desc
+	max           *prometheus.Desc
+	acquires      *prometheus.Desc
+	emptyAcquires *prometheus.Desc
+	canceled      *prometheus.Desc
+	acquireTime   *prometheus.Desc
+	waitTime      *prometheus.Desc
+}
+
+// NewPoolCollector -. Метка pool различает пулы, например primary и replica.
+func NewPoolCollector(pool *pgxpool.Pool, name string) prometheus.Collector {
+	labels := prometheus.Labels{"pool": name}
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc("pgxpool_"+metric, help, nil, labels)
+	}
+	return &poolCollector{
+		pool:          pool,
+		acquired:      desc("acquired_conns", "Number of connections currently acquired"),
+		idle:          desc("idle_conns", "Number of idle connections in the pool"),
+		constructing:  desc("constructing_conns", "Number of connections being established"),
+		total:         desc("total_conns", "Total number of connections in the pool"),
+		max:           desc("max_conns", "Maximum size of the pool"),
+		acquires:      desc("acquires_total", "Total number of successful acquires"),
+		emptyAcquires: desc("empty_acquires_total", "Total number of acquires that waited for a connection"),
+		canceled:      desc("canceled_acquires_total", "Total number of acquires canceled by context"),
+		acquireTime:   desc("acquire_duration_seconds_total", "Total time spent acquiring connections"),
+		waitTime:      desc("empty_acquire_wait_seconds_total", "Total time acquires waited for a connection"),
+	}
+}
+
+// Describe -.
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquired
+	ch <- c.idle
+	ch <- c.constructing
+	ch <- c.total
+	ch <- c.max
+	ch <- c.acquires
+	ch <- c.emptyAcquires
+	ch <- c.canceled
+	ch <- c.acquireTime
+	ch <- c.waitTime
+}
+
+// Collect -.
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.pool.Stat()
+	gauge := func(d *prometheus.Desc, v int32) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, float64(v))
+	}
+	counter := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, v)
+	}
+
+	gauge(c.acquired, s.AcquiredConns())
+	gauge(c.idle, s.IdleConns())
+	gauge(c.constructing, s.ConstructingConns())
+	gauge(c.total, s.TotalConns())
+	gauge(c.max, s.MaxConns())
+	counter(c.acquires, float64(s.AcquireCount()))
+	counter(c.emptyAcquires, float64(s.EmptyAcquireCount()))
+	counter(c.canceled, float64(s.CanceledAcquireCount()))
+	counter(c.acquireTime, s.AcquireDuration().Seconds())
+	counter(c.waitTime, s.EmptyAcquireWaitTime().Seconds())
+}
@@ -1,6 +1,9 @@
 package pkgPostgres
 
-import "time"
+import (
+	"GoPVZ/pkg/pkgLogger"
+	"time"
+)
 
 // Option -.
 type Option func(*Postgres)
@@ -12,6 +15,41 @@ func MaxPoolSize(size int) Option {
 	}
 }
 
+// MinIdleConns задает число простаивающих соединений, которые пул держит открытыми.
+func MinIdleConns(n int) Option {
+	return func(c *Postgres) {
+		c.minIdleConns = n
+	}
+}
+
+// MaxConnLifetime -.
+func MaxConnLifetime(d time.Duration) Option {
+	return func(c *Postgres) {
+		c.maxConnLifetime = d
+	}
+}
+
+// MaxConnIdleTime -.
+func MaxConnIdleTime(d time.Duration) Option {
+	return func(c *Postgres) {
+		c.maxConnIdleTime = d
+	}
+}
+
+// HealthCheckPeriod задает, как часто пул проверяет простаивающие соединения.
+func HealthCheckPeriod(d time.Duration) Option {
+	return func(c *Postgres) {
+		c.healthCheckPeriod = d
+	}
+}
+
+// StatementTimeout задает statement_timeout для каждого соединения; 0 — без ограничения.
+func StatementTimeout(d time.Duration) Option {
+	return func(c *Postgres) {
+		c.statementTimeout = d
+	}
+}
+
 // ConnAttempts -.
 func ConnAttempts(attempts int) Option {
 	return func(c *Postgres) {
@@ -19,9 +57,31 @@ func ConnAttempts(attempts int) Option {
 	}
 }
 
-// ConnTimeout -.
+// ConnTimeout задает первую задержку между попытками подключения.
 func ConnTimeout(timeout time.Duration) Option {
 	return func(c *Postgres) {
 		c.connTimeout = timeout
 	}
 }
+
+// MaxConnBackoff ограничивает задержку между попытками подключения.
+func MaxConnBackoff(d time.Duration) Option {
+	return func(c *Postgres) {
+		c.maxConnBackoff = d
+	}
+}
+
+// LazyConnect создает пул без проверки соединения и без повторных попыток:
+// для реплик, доступность которых отслеживает Cluster.
+func LazyConnect() Option {
+	return func(c *Postgres) {
+		c.lazy = true
+	}
+}
+
+// Logger -.
+func Logger(log pkgLogger.Interface) Option {
+	return func(c *Postgres) {
+		c.log = log
+	}
+}
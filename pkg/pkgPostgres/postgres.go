@@ -2,9 +2,11 @@
 package pkgPostgres
 
 import (
+	"GoPVZ/pkg/pkgLogger"
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"strconv"
 	"time"
 
 	"github.com/Masterminds/squirrel"
@@ -12,28 +14,49 @@ import (
 )
 
 const (
-	_defaultMaxPoolSize  = 1
-	_defaultConnAttempts = 10
-	_defaultConnTimeout  = time.Second
+	_defaultMaxPoolSize       = 10
+	_defaultMinIdleConns      = 2
+	_defaultMaxConnLifetime   = time.Hour
+	_defaultMaxConnIdleTime   = 30 * time.Minute
+	_defaultHealthCheckPeriod = time.Minute
+	_defaultConnAttempts      = 10
+	_defaultConnTimeout       = time.Second
+	_defaultMaxConnBackoff    = 10 * time.Second
+	_defaultPingTimeout       = 5 * time.Second
 )
 
 // Postgres -.
 type Postgres struct {
-	maxPoolSize  int
-	connAttempts int
-	connTimeout  time.Duration
+	maxPoolSize       int
+	minIdleConns      int
+	maxConnLifetime   time.Duration
+	maxConnIdleTime   time.Duration
+	healthCheckPeriod time.Duration
+	statementTimeout  time.Duration
+	connAttempts      int
+	connTimeout       time.Duration
+	maxConnBackoff    time.Duration
+	lazy              bool
+	log               pkgLogger.Interface
 
 	Builder squirrel.StatementBuilderType
 	Pool    *pgxpool.Pool
 }
 
-// New -.
+// New создает пул и проверяет соединение Ping. Неудачные попытки повторяются
+// с экспоненциальной задержкой: от connTimeout, удваиваясь до maxConnBackoff.
 func New(url string, opts ...Option) (*Postgres, error) {
 
 	pg := &Postgres{
-		maxPoolSize:  _defaultMaxPoolSize,
-		connAttempts: _defaultConnAttempts,
-		connTimeout:  _defaultConnTimeout,
+		maxPoolSize:       _defaultMaxPoolSize,
+		minIdleConns:      _defaultMinIdleConns,
+		maxConnLifetime:   _defaultMaxConnLifetime,
+		maxConnIdleTime:   _defaultMaxConnIdleTime,
+		healthCheckPeriod: _defaultHealthCheckPeriod,
+		connAttempts:      _defaultConnAttempts,
+		connTimeout:       _defaultConnTimeout,
+		maxConnBackoff:    _defaultMaxConnBackoff,
+		log:               pkgLogger.Discard(),
 	}
 
 	// Custom options
@@ -48,26 +71,63 @@ func New(url string, opts ...Option) (*Postgres, error) {
 		return nil, fmt.Errorf("postgres - NewPostgres - pgxpool.ParseConfig: %w", err)
 	}
 
-	poolConfig.MaxConns = int32(pg.maxPoolSize) //nolint:gosec // skip integer overflow conversion int -> int32
+	poolConfig.MaxConns = int32(pg.maxPoolSize)      //nolint:gosec // skip integer overflow conversion int -> int32
+	poolConfig.MinIdleConns = int32(pg.minIdleConns) //nolint:gosec // skip integer overflow conversion int -> int32
+	poolConfig.MaxConnLifetime = pg.maxConnLifetime
+	// Разброс, чтобы соединения, открытые при старте, не закрывались разом
+	poolConfig.MaxConnLifetimeJitter = pg.maxConnLifetime / 10
+	poolConfig.MaxConnIdleTime = pg.maxConnIdleTime
+	poolConfig.HealthCheckPeriod = pg.healthCheckPeriod
+	if pg.statementTimeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(pg.statementTimeout.Milliseconds(), 10)
+	}
+	poolConfig.ConnConfig.Tracer = queryTracer{}
 
-	for pg.connAttempts > 0 {
+	if pg.lazy {
 		pg.Pool, err = pgxpool.NewWithConfig(context.Background(), poolConfig)
+		if err != nil {
+			return nil, fmt.Errorf("postgres - NewPostgres - pgxpool.NewWithConfig: %w", err)
+		}
+		return pg, nil
+	}
+
+	backoff := pg.connTimeout
+	for attempt := 1; ; attempt++ {
+		pg.Pool, err = pg.connect(poolConfig)
 		if err == nil {
 			break
 		}
+		if attempt >= pg.connAttempts {
+			return nil, fmt.Errorf("postgres - NewPostgres - connAttempts == 0: %w", err)
+		}
 
-		log.Printf("Postgres is trying to connect, attempts left: %d", pg.connAttempts)
-
-		time.Sleep(pg.connTimeout)
-
-		pg.connAttempts--
+		pg.log.Warn("Postgres is trying to connect",
+			slog.Int("attempts_left", pg.connAttempts-attempt),
+			slog.Duration("retry_in", backoff),
+			pkgLogger.Err(err),
+		)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, pg.maxConnBackoff)
 	}
 
+	return pg, nil
+}
+
+// connect создает пул и проверяет соединение: pgxpool подключается лениво,
+// и без Ping недоступная БД обнаружилась бы только на первом запросе.
+func (p *Postgres) connect(poolConfig *pgxpool.Config) (*pgxpool.Pool, error) {
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
-		return nil, fmt.Errorf("postgres - NewPostgres - connAttempts == 0: %w", err)
+		return nil, err
 	}
 
-	return pg, nil
+	ctx, cancel := context.WithTimeout(context.Background(), _defaultPingTimeout)
+	defer cancel()
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return pool, nil
 }
 
 // Close -.
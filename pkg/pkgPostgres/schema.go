@@ -0,0 +1,51 @@
+package pkgPostgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrSchemaNotManaged — в БД нет таблицы schema_migrations: схема создана не
+// через migrate (например, init-скриптами docker-compose).
+var ErrSchemaNotManaged = errors.New("schema_migrations table not found")
+
+// SchemaVersion возвращает версию схемы из таблицы schema_migrations golang-migrate.
+func (p *Postgres) SchemaVersion(ctx context.Context) (version uint, dirty bool, err error) {
+	var exists bool
+	if err := p.Pool.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return 0, false, err
+	}
+	if !exists {
+		return 0, false, ErrSchemaNotManaged
+	}
+
+	err = p.Pool.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// CheckSchema — проверка готовности: схема не ниже want и не в состоянии dirty.
+// Схема без schema_migrations считается исправной.
+func (p *Postgres) CheckSchema(want uint) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		version, dirty, err := p.SchemaVersion(ctx)
+		if errors.Is(err, ErrSchemaNotManaged) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("schema version %d is dirty", version)
+		}
+		if version < want {
+			return fmt.Errorf("schema version %d, want %d", version, want)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,64 @@
+package pkgPostgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Querier — общий набор методов пула и транзакции, которым пользуются репозитории.
+type Querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Transactor выполняет функцию в рамках одной транзакции.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txKey struct{}
+
+type poolTransactor struct {
+	pool *pgxpool.Pool
+}
+
+// NewTransactor -.
+func NewTransactor(pool *pgxpool.Pool) Transactor {
+	return &poolTransactor{pool: pool}
+}
+
+// WithinTransaction открывает транзакцию и кладёт её в контекст.
+// Вложенные вызовы переиспользуют уже открытую транзакцию.
+func (t *poolTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := t.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("postgres - WithinTransaction - Begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("postgres - WithinTransaction - Commit: %w", err)
+	}
+	return nil
+}
+
+// Conn возвращает транзакцию из контекста, если она открыта, иначе пул.
+func Conn(ctx context.Context, pool *pgxpool.Pool) Querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return pool
+}
@@ -0,0 +1,81 @@
+// Package pkgPubSub implements in-process publish/subscribe with an optional Postgres LISTEN/NOTIFY backend.
+package pkgPubSub
+
+import (
+	"context"
+	"sync"
+)
+
+const _defaultBufferSize = 64
+
+// Broker -.
+type Broker interface {
+	Publish(ctx context.Context, topic string, data []byte) error
+	// Subscribe возвращает канал сообщений топика и функцию отписки.
+	Subscribe(topic string) (<-chan []byte, func())
+}
+
+// Memory — брокер внутри одного процесса. Медленный подписчик не блокирует
+// публикацию: если его буфер заполнен, сообщение для него отбрасывается.
+type Memory struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[chan []byte]struct{}
+	bufferSize  int
+}
+
+var _ Broker = (*Memory)(nil)
+
+// NewMemory -.
+func NewMemory(bufferSize int) *Memory {
+	if bufferSize <= 0 {
+		bufferSize = _defaultBufferSize
+	}
+
+	return &Memory{
+		subscribers: make(map[string]map[chan []byte]struct{}),
+		bufferSize:  bufferSize,
+	}
+}
+
+// Publish -.
+func (m *Memory) Publish(_ context.Context, topic string, data []byte) error {
+	m.deliver(topic, data)
+	return nil
+}
+
+func (m *Memory) deliver(topic string, data []byte) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for ch := range m.subscribers[topic] {
+		select {
+		case ch <- data:
+		default:
+		}
+	}
+}
+
+// Subscribe -.
+func (m *Memory) Subscribe(topic string) (<-chan []byte, func()) {
+	ch := make(chan []byte, m.bufferSize)
+
+	m.mu.Lock()
+	if m.subscribers[topic] == nil {
+		m.subscribers[topic] = make(map[chan []byte]struct{})
+	}
+	m.subscribers[topic][ch] = struct{}{}
+	m.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			m.mu.Lock()
+			delete(m.subscribers[topic], ch)
+			if len(m.subscribers[topic]) == 0 {
+				delete(m.subscribers, topic)
+			}
+			m.mu.Unlock()
+			close(ch)
+		})
+	}
+}
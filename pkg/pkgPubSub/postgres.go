@@ -0,0 +1,117 @@
+package pkgPubSub
+
+import (
+	"GoPVZ/pkg/pkgLogger"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	_defaultChannel        = "gopvz_events"
+	_defaultReconnectDelay = time.Second
+	_maxReconnectDelay     = 30 * time.Second
+)
+
+type envelope struct {
+	Topic string          `json:"topic"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// Postgres рассылает сообщения через NOTIFY, а слушатель доставляет их
+// локальным подписчикам. Так подписчики любой реплики получают события,
+// опубликованные на любой другой.
+type Postgres struct {
+	local   *Memory
+	pool    *pgxpool.Pool
+	channel string
+	log     pkgLogger.Interface
+}
+
+var _ Broker = (*Postgres)(nil)
+
+// NewPostgres -.
+func NewPostgres(pool *pgxpool.Pool, bufferSize int, log pkgLogger.Interface) *Postgres {
+	return &Postgres{
+		local:   NewMemory(bufferSize),
+		pool:    pool,
+		channel: _defaultChannel,
+		log:     log,
+	}
+}
+
+// Publish отправляет NOTIFY. Данные должны быть JSON и не длиннее ~8000 байт.
+func (p *Postgres) Publish(ctx context.Context, topic string, data []byte) error {
+	payload, err := json.Marshal(envelope{Topic: topic, Data: data})
+	if err != nil {
+		return err
+	}
+
+	_, err = p.pool.Exec(ctx, `SELECT pg_notify($1, $2)`, p.channel, string(payload))
+	return err
+}
+
+// Subscribe -.
+func (p *Postgres) Subscribe(topic string) (<-chan []byte, func()) {
+	return p.local.Subscribe(topic)
+}
+
+// Run держит отдельное соединение с LISTEN и переподключается при обрывах.
+// Задержка растет, пока подключиться не удается, и сбрасывается после
+// успешного LISTEN. Блокируется до отмены контекста.
+func (p *Postgres) Run(ctx context.Context) {
+	delay := _defaultReconnectDelay
+
+	for ctx.Err() == nil {
+		listening, err := p.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if listening {
+			delay = _defaultReconnectDelay
+		}
+
+		p.log.WarnContext(ctx, "PubSub listener stopped, reconnecting",
+			slog.Duration("retry_in", delay),
+			pkgLogger.Err(err),
+		)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, _maxReconnectDelay)
+	}
+}
+
+// listen возвращает true, если LISTEN успел выполниться до обрыва.
+func (p *Postgres) listen(ctx context.Context) (bool, error) {
+	// Отдельное соединение вне пула: LISTEN держит его все время работы
+	conn, err := pgx.ConnectConfig(ctx, p.pool.Config().ConnConfig.Copy())
+	if err != nil {
+		return false, fmt.Errorf("pubsub - listen - connect: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{p.channel}.Sanitize()); err != nil {
+		return false, fmt.Errorf("pubsub - listen - LISTEN: %w", err)
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, err
+		}
+
+		var env envelope
+		if err := json.Unmarshal([]byte(n.Payload), &env); err != nil {
+			continue
+		}
+		p.local.deliver(env.Topic, env.Data)
+	}
+}
@@ -0,0 +1,106 @@
+// Package pkgRateLimit implements token bucket rate limiting with pluggable storage.
+package pkgRateLimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter -.
+type Limiter interface {
+	// Allow списывает один токен из корзины ключа. Если токенов нет, возвращает
+	// false и время, через которое появится следующий.
+	Allow(ctx context.Context, key string) (bool, time.Duration, error)
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Memory — token bucket в памяти процесса: корзина емкостью burst пополняется
+// со скоростью rate токенов в секунду.
+type Memory struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	rate    float64
+	burst   float64
+	now     func() time.Time
+}
+
+var _ Limiter = (*Memory)(nil)
+
+// NewMemory -.
+func NewMemory(rate float64, burst int) *Memory {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &Memory{
+		buckets: make(map[string]*bucket),
+		rate:    rate,
+		burst:   float64(burst),
+		now:     time.Now,
+	}
+}
+
+// Allow -.
+func (m *Memory) Allow(_ context.Context, key string) (bool, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: m.burst, updated: now}
+		m.buckets[key] = b
+	}
+	b.tokens = m.refill(b, now)
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+
+	// Корзина без пополнения: время ожидания не определено
+	if m.rate <= 0 {
+		return false, 0, nil
+	}
+	wait := time.Duration((1 - b.tokens) / m.rate * float64(time.Second))
+	return false, wait, nil
+}
+
+func (m *Memory) refill(b *bucket, now time.Time) float64 {
+	tokens := b.tokens + now.Sub(b.updated).Seconds()*m.rate
+	return math.Min(tokens, m.burst)
+}
+
+// RunCleanup периодически удаляет полные корзины: они не отличаются от отсутствующих.
+func (m *Memory) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.cleanup()
+		}
+	}
+}
+
+func (m *Memory) cleanup() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for key, b := range m.buckets {
+		if m.refill(b, now) >= m.burst {
+			delete(m.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Package pkgTOTP implements time-based one-time passwords (RFC 6238) in the
+// flavour supported by common authenticator apps: HMAC-SHA1, 6 digits, 30 second step.
+package pkgTOTP
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Digits — длина кода
+	Digits = 6
+	// Period — шаг, в течение которого код действителен
+	Period = 30 * time.Second
+
+	_secretSize = 20
+)
+
+var _encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret возвращает случайный секрет в base32, как его вводят в приложение вручную.
+func GenerateSecret() (string, error) {
+	b := make([]byte, _secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return _encoding.EncodeToString(b), nil
+}
+
+// URI возвращает otpauth:// ссылку для QR кода.
+func URI(issuer, account, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(Digits))
+	params.Set("period", fmt.Sprint(int(Period.Seconds())))
+
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// Step возвращает номер шага для момента t.
+func Step(t time.Time) int64 {
+	return t.Unix() / int64(Period.Seconds())
+}
+
+// Code вычисляет код для шага step.
+func Code(secret string, step int64) (string, error) {
+	key, err := _encoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil {
+		return "", err
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", Digits, value%1_000_000), nil
+}
+
+// Validate проверяет код для момента t с допуском skew шагов в обе стороны,
+// чтобы пережить расхождение часов. Возвращает шаг совпавшего кода: по нему
+// вызывающий отклоняет повторное использование.
+func Validate(secret, code string, t time.Time, skew int) (int64, bool) {
+	if len(code) != Digits {
+		return 0, false
+	}
+
+	current := Step(t)
+	for delta := -int64(skew); delta <= int64(skew); delta++ {
+		expected, err := Code(secret, current+delta)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return current + delta, true
+		}
+	}
+	return 0, false
+}
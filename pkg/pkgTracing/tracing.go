@@ -0,0 +1,132 @@
+// Package pkgTracing настраивает OpenTelemetry трассировку.
+package pkgTracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Экспортеры спанов.
+const (
+	// ExporterNone — спаны не создаются; по умолчанию
+	ExporterNone = "none"
+	// ExporterStdout — спаны в JSON в stdout, для локального запуска
+	ExporterStdout = "stdout"
+	// ExporterFile — спаны в JSON в файл
+	ExporterFile = "file"
+	// ExporterOTLP — OTLP/HTTP; адрес и заголовки задаются стандартными OTEL_EXPORTER_OTLP_*
+	ExporterOTLP = "otlp"
+)
+
+// Config -.
+type Config struct {
+	Exporter    string
+	FilePath    string
+	ServiceName string
+	// SampleRatio — доля трассируемых запросов, от 0 до 1. Если вызывающий сервис
+	// уже принял решение (traceparent), используется оно
+	SampleRatio float64
+}
+
+// Provider -.
+type Provider struct {
+	shutdown func(context.Context) error
+}
+
+// New настраивает глобальный TracerProvider и распространение контекста через
+// заголовки traceparent/baggage. В режиме none глобальный провайдер остается no-op.
+func New(ctx context.Context, cfg Config) (*Provider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var (
+		exporter sdktrace.SpanExporter
+		closer   io.Closer
+		err      error
+	)
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return &Provider{shutdown: func(context.Context) error { return nil }}, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		if cfg.FilePath == "" {
+			return nil, errors.New("file path is required for file exporter")
+		}
+		var f *os.File
+		f, err = os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		closer = f
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(f))
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+
+	return &Provider{shutdown: func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		if closer != nil {
+			err = errors.Join(err, closer.Close())
+		}
+		return err
+	}}, nil
+}
+
+// Shutdown отправляет накопленные спаны и останавливает экспортер.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	return p.shutdown(ctx)
+}
+
+// End завершает спан и, если err не nil, отмечает его ошибкой. Удобно вызывать
+// в defer с именованным результатом:
+//
+//	ctx, span := tracer.Start(ctx, "PVZUseCase.CreatePVZ")
+//	defer func() { pkgTracing.End(span, err) }()
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceID возвращает идентификатор трассы из ctx или пустую строку, если спан
+// не записывается.
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() || !sc.IsSampled() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
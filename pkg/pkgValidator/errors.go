@@ -5,18 +5,68 @@ import "errors"
 var (
 	ErrInvalidInput             = errors.New("invalid input")
 	ErrInvalidEmail             = errors.New("invalid email")
-	ErrPasswordTooWeak          = errors.New("password must be at least 8 characters")
-	ErrInvalidRole              = errors.New("role must be employee or moderator")
+	ErrPasswordTooWeak          = errors.New("password is too short")
+	ErrInvalidRole              = errors.New("role must be employee, moderator, regional_manager, auditor, service or admin")
 	ErrInvalidCity              = errors.New("city must be Moscow, Saint Petersburg or Kazan")
 	ErrUserExists               = errors.New("user already exists")
 	ErrInvalidCredentials       = errors.New("invalid credentials")
 	ErrInvalidReceptionCreation = errors.New("pvz's last reception is still in progress")
 	ErrInvalidPVZID             = errors.New("invalid pvz_id")
+	ErrPVZNotFound              = errors.New("pvz not found")
 	ErrInvalidProductType       = errors.New("type must be electronics, clothes or shoes")
 	ErrNoActiveReception        = errors.New("no active reception found")
+	ErrNoProductsToDelete       = errors.New("no products to delete in active reception")
 	ErrInvalidPage              = errors.New("page must be greater than 0")
 	ErrInvalidLimit             = errors.New("limit must be between 1 and 30")
 	ErrInvalidDateFormat        = errors.New("date must be in RFC3339 format")
 	ErrInvalidDateRange         = errors.New("end date must be after start date")
 	ErrLimitTooHigh             = errors.New("limit cannot be higher than 30")
+	ErrInvalidWebhookURL        = errors.New("url must be an absolute http or https URL")
+	ErrInvalidEventType         = errors.New("unknown event type")
+	ErrInvalidWebhookSecret     = errors.New("secret must be at least 16 characters")
+	ErrInvalidWebhookID         = errors.New("invalid webhook id")
+	ErrWebhookNotFound          = errors.New("webhook subscription not found")
+	ErrInvalidDeliveriesLimit   = errors.New("limit must be between 1 and 100")
+	ErrInvalidExportFormat      = errors.New("format must be csv or xlsx")
+	ErrInvalidExportColumn      = errors.New("unknown export column")
+	ErrInvalidTimezone          = errors.New("timezone must be UTC, local or an IANA time zone name")
+	ErrInvalidImportHeader      = errors.New("csv header must contain city, address and external_id")
+	ErrImportTooLarge           = errors.New("import file has too many rows")
+	ErrInvalidAddress           = errors.New("address must be between 1 and 255 characters")
+	ErrInvalidExternalID        = errors.New("external_id must be between 1 and 100 characters")
+	ErrInvalidIdempotencyKey    = errors.New("Idempotency-Key must be between 1 and 255 characters")
+	ErrIdempotencyKeyReused     = errors.New("Idempotency-Key was already used with a different request")
+	ErrIdempotencyInProgress    = errors.New("request with this Idempotency-Key is still in progress")
+	ErrIdempotencyKeyNotFound   = errors.New("idempotency key not found")
+	ErrRequestTooLarge          = errors.New("request body is too large")
+	ErrVersionMismatch          = errors.New("resource was modified by another request")
+	ErrInvalidReceptionID       = errors.New("invalid reception_id")
+	ErrReceptionNotFound        = errors.New("reception not found")
+	ErrTooManyRequests          = errors.New("too many requests, try again later")
+	ErrPasswordTooSimple        = errors.New("password does not meet complexity requirements")
+	ErrPasswordTooCommon        = errors.New("password is too common")
+	ErrInvalidResetToken        = errors.New("password reset token is invalid or expired")
+	ErrUserNotFound             = errors.New("user not found")
+	ErrInvalidUserID            = errors.New("invalid user_id")
+	ErrOutOfScope               = errors.New("pvz city is outside of your region")
+	ErrInvalidAPIKey            = errors.New("api key is invalid, revoked or expired")
+	ErrAPIKeyNotFound           = errors.New("api key not found")
+	ErrInvalidAPIKeyID          = errors.New("invalid api key id")
+	ErrInvalidAPIKeyName        = errors.New("name must be between 1 and 100 characters")
+	ErrInvalidPermission        = errors.New("unknown permission")
+	ErrPermissionNotGrantable   = errors.New("api keys cannot be granted user:manage or api_key:manage")
+	ErrPermissionNotHeld        = errors.New("api key cannot be granted a permission your role does not have")
+	ErrInvalidExpiresAt         = errors.New("expiresAt must be in the future")
+	ErrOwnRegions               = errors.New("you cannot change your own regions")
+	ErrRegionNotHeld            = errors.New("you can only assign and change regions within your own")
+	ErrInvalidOIDCState         = errors.New("sso login session is invalid or expired, start again")
+	ErrOIDCEmailNotVerified     = errors.New("identity provider did not return a verified email")
+	ErrOIDCNoRole               = errors.New("none of your identity provider groups is allowed to sign in")
+	ErrOIDCAccountExists        = errors.New("an account with this email already exists, sign in with your password")
+	ErrAccountLocked            = errors.New("account is temporarily locked after too many failed login attempts")
+	ErrInvalidMFAChallenge      = errors.New("two-factor challenge is invalid or expired, log in again")
+	ErrInvalidTOTPCode          = errors.New("two-factor code is invalid or was already used")
+	ErrTOTPNotEnrolled          = errors.New("two-factor authentication is not set up")
+	ErrTOTPAlreadyEnabled       = errors.New("two-factor authentication is already enabled")
+	ErrInvalidLogLevel          = errors.New("level must be one of debug, info, warn, error")
 )
@@ -0,0 +1,109 @@
+package pkgValidator
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const _defaultPasswordMinLength = 8
+
+//go:embed common_passwords.txt
+var _commonPasswords string
+
+// PasswordPolicy — требования к новым паролям: при регистрации, смене и сбросе.
+type PasswordPolicy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	denylist      map[string]struct{}
+}
+
+// NewPasswordPolicy создает политику. extraDenylist дополняет встроенный список
+// распространенных паролей.
+func NewPasswordPolicy(minLength int, requireUpper, requireLower, requireDigit, requireSymbol bool, extraDenylist []string) *PasswordPolicy {
+	if minLength < 1 {
+		minLength = _defaultPasswordMinLength
+	}
+
+	p := &PasswordPolicy{
+		MinLength:     minLength,
+		RequireUpper:  requireUpper,
+		RequireLower:  requireLower,
+		RequireDigit:  requireDigit,
+		RequireSymbol: requireSymbol,
+		denylist:      make(map[string]struct{}),
+	}
+	for _, password := range strings.Fields(_commonPasswords) {
+		p.denylist[password] = struct{}{}
+	}
+	for _, password := range extraDenylist {
+		if password = strings.ToLower(strings.TrimSpace(password)); password != "" {
+			p.denylist[password] = struct{}{}
+		}
+	}
+	return p
+}
+
+// DefaultPasswordPolicy — минимум 8 символов и запрет распространенных паролей.
+func DefaultPasswordPolicy() *PasswordPolicy {
+	return NewPasswordPolicy(_defaultPasswordMinLength, false, false, false, false, nil)
+}
+
+// ReadDenylist читает список запрещенных паролей: по одному в строке, # — комментарий.
+func ReadDenylist(r io.Reader) ([]string, error) {
+	var passwords []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords = append(passwords, line)
+	}
+	return passwords, scanner.Err()
+}
+
+// Validate -.
+func (p *PasswordPolicy) Validate(password string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return fmt.Errorf("%w: at least %d characters required", ErrPasswordTooWeak, p.MinLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	switch {
+	case p.RequireUpper && !hasUpper:
+		return fmt.Errorf("%w: an uppercase letter is required", ErrPasswordTooSimple)
+	case p.RequireLower && !hasLower:
+		return fmt.Errorf("%w: a lowercase letter is required", ErrPasswordTooSimple)
+	case p.RequireDigit && !hasDigit:
+		return fmt.Errorf("%w: a digit is required", ErrPasswordTooSimple)
+	case p.RequireSymbol && !hasSymbol:
+		return fmt.Errorf("%w: a special character is required", ErrPasswordTooSimple)
+	}
+
+	if _, ok := p.denylist[strings.ToLower(password)]; ok {
+		return ErrPasswordTooCommon
+	}
+	return nil
+}